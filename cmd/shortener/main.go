@@ -73,9 +73,9 @@ func gracefulShutdown(
 	logger.Sync()
 }
 
-// Функция для вывода информации о конфигурации приложения
+// Функция для вывода информации о конфигурации приложения без секретных ключей
 func printAppConfig(cfg *config.Config) {
-	jsonConfig, err := json.MarshalIndent(cfg, "", " ")
+	jsonConfig, err := json.MarshalIndent(cfg.Redacted(), "", " ")
 	if err != nil {
 		fmt.Printf("Failed to print app config: %v", err)
 		return
@@ -12,26 +12,29 @@ import (
 
 // ShortenerApp - основное приложение для сокращения URL
 type ShortenerApp struct {
-	cfg    *config.Config
-	server pkghttp.Server
-	dbConn *sql.DB
-	logger *zap.SugaredLogger
+	cfg        *config.Config
+	server     pkghttp.Server
+	grpcServer pkghttp.Server
+	dbConn     *sql.DB
+	logger     *zap.SugaredLogger
 }
 
 // Конструктор для ShortenerApp
+// grpcServer может быть nil, если gRPC сервер не сконфигурирован
 func NewShortenerApp(
 	cfg *config.Config,
 	server pkghttp.Server,
+	grpcServer pkghttp.Server,
 	dbConn *sql.DB,
 	logger *zap.SugaredLogger,
 ) *ShortenerApp {
-	return &ShortenerApp{cfg, server, dbConn, logger}
+	return &ShortenerApp{cfg, server, grpcServer, dbConn, logger}
 }
 
 // Start - запускает приложение
 func (app *ShortenerApp) Start(appCtx context.Context) error {
 
-	serverErr := make(chan error, 1)
+	serverErr := make(chan error, 2)
 	go func() {
 		app.logger.Info("Server starting")
 		if err := app.server.Start(appCtx); err != nil && err != http.ErrServerClosed {
@@ -39,6 +42,15 @@ func (app *ShortenerApp) Start(appCtx context.Context) error {
 		}
 	}()
 
+	if app.grpcServer != nil {
+		go func() {
+			app.logger.Info("gRPC server starting")
+			if err := app.grpcServer.Start(appCtx); err != nil {
+				serverErr <- err
+			}
+		}()
+	}
+
 	select {
 	case err := <-serverErr:
 		return err
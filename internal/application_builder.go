@@ -2,18 +2,34 @@ package internal
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/oegegr/shortener/internal/config"
 	"github.com/oegegr/shortener/internal/config/db"
+	"github.com/oegegr/shortener/internal/grpcapi"
+	"github.com/oegegr/shortener/internal/metrics"
+	"github.com/oegegr/shortener/internal/middleware"
 	"github.com/oegegr/shortener/internal/repository"
 	"github.com/oegegr/shortener/internal/service"
+	pb "github.com/oegegr/shortener/pkg/api/shortener"
+	"github.com/oegegr/shortener/pkg/cache"
+	"github.com/oegegr/shortener/pkg/grpcserver"
 	pkghttp "github.com/oegegr/shortener/pkg/http"
+	"github.com/oegegr/shortener/pkg/ratelimit"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // ShotenerAppBuilder - билдер для создания ShortenerApp
@@ -39,21 +55,132 @@ func (b *ShotenerAppBuilder) Build(ctx context.Context) (*ShortenerApp, func(con
 		}
 	}
 
-	repo, err := createURLRepository(*b.cfg, *b.logger, dbConn)
+	metricsRegistry, err := metrics.NewRegistry()
+	if err != nil {
+		b.logger.Error("failed to create metrics registry: %w", err)
+		return nil, nil, err
+	}
+
+	repo, err := createURLRepository(*b.cfg, *b.logger, dbConn, metricsRegistry)
 	if err != nil {
 		b.logger.Error("failed to create repository: %w", err)
 		return nil, nil, err
 	}
 
-	urlDelStrategy := createURLDeletionStrategy(*b.logger, repo)
+	deletionJobRepo, err := createDeletionJobRepository(*b.cfg, *b.logger, dbConn)
+	if err != nil {
+		b.logger.Error("failed to create deletion job repository: %w", err)
+		return nil, nil, err
+	}
+
+	urlDelStrategy, err := createURLDeletionStrategy(*b.cfg, *b.logger, repo, deletionJobRepo)
+	if err != nil {
+		b.logger.Error("failed to create url deletion strategy: %w", err)
+		return nil, nil, err
+	}
+
+	shortDomains, err := createShortDomains(*b.cfg)
+	if err != nil {
+		b.logger.Error("failed to create short domains: %w", err)
+		return nil, nil, err
+	}
+
+	domains := service.NewDomainRegistry(b.cfg.BaseURL, shortDomains...)
+
+	urlPolicy, err := createURLPolicy(*b.cfg, *b.logger, shortDomains)
+	if err != nil {
+		b.logger.Error("failed to create url policy: %w", err)
+		return nil, nil, err
+	}
+
+	shortCodeProvider, err := createShortCodeProvider(*b.cfg, *b.logger, dbConn)
+	if err != nil {
+		b.logger.Error("failed to create short code provider: %w", err)
+		return nil, nil, err
+	}
+
+	codeLength, err := createShortCodeLength(ctx, *b.cfg, *b.logger, dbConn)
+	if err != nil {
+		b.logger.Error("failed to create short code length: %w", err)
+		return nil, nil, err
+	}
+
+	passwordLimiter, err := createPasswordAttemptLimiter(*b.cfg)
+	if err != nil {
+		b.logger.Error("failed to create password attempt limiter: %w", err)
+		return nil, nil, err
+	}
+
+	service := createShortnerService(*b.cfg, *b.logger, repo, domains, codeLength, shortCodeProvider, urlDelStrategy, urlPolicy, passwordLimiter)
+
+	jwtParser, err := createJWTParser(*b.cfg, *b.logger)
+	if err != nil {
+		b.logger.Error("failed to create jwt parser: %w", err)
+		return nil, nil, err
+	}
+
+	apiKeyRepo, err := createAPIKeyRepository(*b.cfg, *b.logger, dbConn)
+	if err != nil {
+		b.logger.Error("failed to create api key repository: %w", err)
+		return nil, nil, err
+	}
+
+	apiKeyService := createAPIKeyService(apiKeyRepo)
+
+	logAudit := createLogAudit(*b.cfg, *b.logger)
+
+	clickRepo := createClickRepository(*b.cfg, *b.logger, dbConn)
 
-	service := createShortnerService(*b.cfg, *b.logger, repo, urlDelStrategy)
+	expiredURLSweeper, err := createExpiredURLSweeper(*b.cfg, *b.logger, repo, clickRepo)
+	if err != nil {
+		b.logger.Error("failed to create expired url sweeper: %w", err)
+		return nil, nil, err
+	}
 
-	jwtParser := createJWTParser(*b.cfg, *b.logger)
+	clickTracker, err := createClickTracker(*b.cfg, *b.logger, clickRepo, repo)
+	if err != nil {
+		b.logger.Error("failed to create click tracker: %w", err)
+		return nil, nil, err
+	}
 
-	logAudit := createLogAudit(*b.cfg)
+	statsService := createURLStatsService(repo, clickRepo)
 
-	router := NewShortenerRouter(*b.logger, service, jwtParser, repo, logAudit)
+	internalStatsService := createInternalStatsService(repo, codeLength)
+
+	trustedSubnet, err := parseTrustedSubnet(*b.cfg)
+	if err != nil {
+		b.logger.Error("failed to parse trusted subnet: %w", err)
+		return nil, nil, err
+	}
+
+	trustedProxies, err := pkghttp.ParseTrustedProxies(b.cfg.TrustedProxies)
+	if err != nil {
+		b.logger.Error("failed to parse trusted proxies: %w", err)
+		return nil, nil, err
+	}
+
+	rateLimitStore := ratelimit.NewInMemoryStore(time.Minute)
+	rateLimits := createRateLimits(*b.cfg)
+
+	router := NewShortenerRouter(
+		*b.logger,
+		service,
+		jwtParser,
+		apiKeyService,
+		repo,
+		logAudit,
+		clickTracker,
+		statsService,
+		internalStatsService,
+		urlDelStrategy,
+		service,
+		rateLimitStore,
+		rateLimits,
+		trustedSubnet,
+		trustedProxies,
+		domains,
+		metricsRegistry,
+	)
 
 	server, err := createServer(router, *b.cfg)
 	if err != nil {
@@ -61,21 +188,18 @@ func (b *ShotenerAppBuilder) Build(ctx context.Context) (*ShortenerApp, func(con
 		return nil, nil, err
 	}
 
+	grpcServer, err := createGRPCServer(*b.cfg, *b.logger, service, jwtParser, apiKeyService, repo, logAudit, domains, rateLimitStore, rateLimits)
+	if err != nil {
+		b.logger.Error("failed to create gRPC server: %w", err)
+		return nil, nil, err
+	}
+
 	stopApp := func(stopCtx context.Context, logger *zap.SugaredLogger) {
 		var stopErrors []error
 
 		logger.Info("Starting application cleanup...")
 
-		if dbConn != nil {
-			b.logger.Info("Closing database connection...")
-			if err := dbConn.Close(); err != nil {
-				stopErrors = append(stopErrors, fmt.Errorf("failed to close database: %w", err))
-			}
-		}
-
-		b.logger.Info("Stoping urlDelStrategy...")
-		urlDelStrategy.Stop()
-
+		// Серверы останавливаются первыми, чтобы запросы в обработке завершились до остановки фоновых задач и хранилищ.
 		if server != nil {
 			logger.Info("Shutting down HTTP server...")
 			shutdownCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
@@ -86,6 +210,59 @@ func (b *ShotenerAppBuilder) Build(ctx context.Context) (*ShortenerApp, func(con
 			}
 		}
 
+		if grpcServer != nil {
+			logger.Info("Shutting down gRPC server...")
+			shutdownCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+
+			if err := grpcServer.Stop(shutdownCtx); err != nil {
+				stopErrors = append(stopErrors, fmt.Errorf("gRPC server shutdown failed: %w", err))
+			}
+		}
+
+		b.logger.Info("Stoping clickTracker...")
+		clickTracker.Stop()
+
+		b.logger.Info("Stoping urlDelStrategy...")
+		urlDelStrategy.Stop()
+
+		b.logger.Info("Stoping expiredURLSweeper...")
+		expiredURLSweeper.Stop()
+
+		b.logger.Info("Stoping urlPolicy...")
+		urlPolicy.Stop()
+
+		b.logger.Info("Flushing audit logs...")
+		logAudit.Stop(stopCtx)
+
+		if closer, ok := deletionJobRepo.(io.Closer); ok {
+			b.logger.Info("Closing deletion job repository...")
+			if err := closer.Close(); err != nil {
+				stopErrors = append(stopErrors, fmt.Errorf("failed to close deletion job repository: %w", err))
+			}
+		}
+
+		if closer, ok := apiKeyRepo.(io.Closer); ok {
+			b.logger.Info("Closing api key repository...")
+			if err := closer.Close(); err != nil {
+				stopErrors = append(stopErrors, fmt.Errorf("failed to close api key repository: %w", err))
+			}
+		}
+
+		if closer, ok := repo.(io.Closer); ok {
+			b.logger.Info("Closing URL repository...")
+			if err := closer.Close(); err != nil {
+				stopErrors = append(stopErrors, fmt.Errorf("failed to close URL repository: %w", err))
+			}
+		}
+
+		if dbConn != nil {
+			b.logger.Info("Closing database connection...")
+			if err := dbConn.Close(); err != nil {
+				stopErrors = append(stopErrors, fmt.Errorf("failed to close database: %w", err))
+			}
+		}
+
 		logger.Info("Syncing logger...")
 		if err := logger.Sync(); err != nil {
 			logger.Debugf("Logger sync warning: %v", err)
@@ -96,7 +273,7 @@ func (b *ShotenerAppBuilder) Build(ctx context.Context) (*ShortenerApp, func(con
 		}
 	}
 
-	return NewShortenerApp(b.cfg, server, dbConn, b.logger), stopApp, nil
+	return NewShortenerApp(b.cfg, server, grpcServer, dbConn, b.logger), stopApp, nil
 }
 
 func createServer(handler http.Handler, cfg config.Config) (pkghttp.Server, error) {
@@ -113,29 +290,332 @@ func createServer(handler http.Handler, cfg config.Config) (pkghttp.Server, erro
 	return server, nil
 }
 
-// createURLRepository - создает репозиторий URL (БД или in-memory)
+// createGRPCServer - создает gRPC сервер, если задан его адрес
+func createGRPCServer(
+	c config.Config,
+	logger zap.SugaredLogger,
+	urlService service.URLShortener,
+	jwtParser service.JWTParser,
+	apiKeys service.APIKeyAuthenticator,
+	repo repository.URLRepository,
+	logAudit service.LogAuditManager,
+	domains *service.DomainRegistry,
+	rateLimitStore ratelimit.Store,
+	rateLimits middleware.RateLimits,
+) (pkghttp.Server, error) {
+	if c.GRPCServerAddress == "" {
+		return nil, nil
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			middleware.AuthUnaryInterceptor(logger, jwtParser, apiKeys),
+			middleware.ShortDomainUnaryInterceptor(domains),
+			middleware.RateLimitUnaryInterceptor(logger, rateLimitStore, rateLimits),
+		),
+	}
+
+	if c.EnableHTTPS {
+		creds, err := credentials.NewServerTLSFromFile(c.TLSCertFile, c.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	server := grpc.NewServer(opts...)
+	shortenerServer := grpcapi.NewShortenerServer(urlService, &middleware.AuthContextUserIDPovider{}, repo, logAudit)
+	pb.RegisterShortenerServer(server, shortenerServer)
+
+	return grpcserver.NewServer(c.GRPCServerAddress, server), nil
+}
+
+// createURLRepository - создает репозиторий URL (БД или in-memory) с кэшем, если он задан
 func createURLRepository(
 	c config.Config,
 	logger zap.SugaredLogger,
 	db *sql.DB,
+	registry prometheus.Registerer,
+) (repository.URLRepository, error) {
+	repo, err := createStorageURLRepository(c, logger, db)
+	if err != nil {
+		return nil, err
+	}
+
+	return createCachedURLRepository(c, logger, repo, registry)
+}
+
+// createCachedURLRepository - оборачивает репозиторий URL в кэширующий декоратор
+func createCachedURLRepository(
+	c config.Config,
+	logger zap.SugaredLogger,
+	repo repository.URLRepository,
+	registry prometheus.Registerer,
+) (repository.URLRepository, error) {
+	var urlCache cache.Cache
+	switch c.CacheBackend {
+	case "", "none":
+		return repo, nil
+	case "memory":
+		urlCache = cache.NewLRUCache(c.CacheSize)
+	case "redis":
+		urlCache = cache.NewRedisCache(c.RedisAddress, "shortener:url:")
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", c.CacheBackend)
+	}
+
+	ttl := time.Duration(c.CacheTTLSeconds) * time.Second
+	negativeTTL := min(ttl, 30*time.Second)
+	cachedRepo := repository.NewCachedURLRepository(repo, urlCache, ttl, negativeTTL, logger)
+	err := metrics.RegisterURLCacheStats(
+		registry,
+		func() float64 { return float64(cachedRepo.Stats().Hits) },
+		func() float64 { return float64(cachedRepo.Stats().NegativeHits) },
+		func() float64 { return float64(cachedRepo.Stats().Misses) },
+	)
+	if err != nil {
+		return nil, err
+	}
+	return cachedRepo, nil
+}
+
+// createStorageURLRepository - создает репозиторий URL (БД или in-memory)
+func createStorageURLRepository(
+	c config.Config,
+	logger zap.SugaredLogger,
+	db *sql.DB,
 ) (repository.URLRepository, error) {
 
 	if c.DBConnectionString != "" {
 		return repository.NewDBURLRepository(db, logger)
 	}
 
-	return repository.NewInMemoryURLRepository(c.FileStoragePath, logger)
+	syncPolicy, err := repository.ParseSyncPolicy(c.FileStorageSync)
+	if err != nil {
+		return nil, err
+	}
+	journalCfg := repository.DefaultJournalConfig()
+	journalCfg.SyncPolicy = syncPolicy
+
+	return repository.NewInMemoryURLRepository(c.FileStoragePath, journalCfg, logger)
+}
+
+// createClickRepository - создает репозиторий переходов (БД или in-memory)
+func createClickRepository(
+	c config.Config,
+	logger zap.SugaredLogger,
+	db *sql.DB,
+) repository.ClickRepository {
+
+	if c.DBConnectionString != "" {
+		return repository.NewDBClickRepository(db, logger)
+	}
+
+	return repository.NewInMemoryClickRepository()
+}
+
+// createClickTracker - создает асинхронную запись переходов;
+// ключ хеширования IP-адресов обязателен, в режиме разработки без него используется случайный ключ
+func createClickTracker(
+	c config.Config,
+	logger zap.SugaredLogger,
+	repo repository.ClickRepository,
+	urlRepo repository.URLRepository,
+) (*service.QueueClickTracker, error) {
+	ipHashKey := c.ClickIPHashKey
+	if ipHashKey == "" {
+		if !c.DevMode {
+			return nil, errors.New("click ip hash key is required: set click ip hash key or enable dev mode")
+		}
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		ipHashKey = hex.EncodeToString(key)
+		logger.Warn("click ip hash key is not set, using random key: unique visitors are not comparable across restarts")
+	}
+
+	queueSize := 10000
+	batchSize := 100
+	flushInterval := 1 * time.Second
+	return service.NewQueueClickTracker(repo, urlRepo, logger, ipHashKey, queueSize, batchSize, flushInterval), nil
+}
+
+// createURLStatsService - создает сервис статистики переходов
+func createURLStatsService(
+	urlRepo repository.URLRepository,
+	clickRepo repository.ClickRepository,
+) service.URLStatsProvider {
+	return service.NewURLStatsService(urlRepo, clickRepo)
+}
+
+// createInternalStatsService - создает сервис внутренней статистики
+func createInternalStatsService(repo repository.URLRepository, codeLength *service.ShortCodeLength) service.InternalStatsProvider {
+	return service.NewInternalStatsService(repo, codeLength)
+}
+
+// parseTrustedSubnet - разбирает доверенную подсеть; пустая строка означает запрет доступа к внутренним эндпоинтам
+func parseTrustedSubnet(c config.Config) (*net.IPNet, error) {
+	if c.TrustedSubnet == "" {
+		return nil, nil
+	}
+
+	_, subnet, err := net.ParseCIDR(c.TrustedSubnet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted subnet %q: %w", c.TrustedSubnet, err)
+	}
+	return subnet, nil
+}
+
+// createRateLimits - создает ограничения частоты запросов из конфигурации
+func createRateLimits(c config.Config) middleware.RateLimits {
+	return middleware.RateLimits{
+		Shorten:      ratelimit.PerMinute(c.RateLimitShortenPerMinute, c.RateLimitShortenBurst),
+		Batch:        ratelimit.PerMinute(c.RateLimitBatchPerMinute, c.RateLimitBatchBurst),
+		BatchMaxSize: c.BatchMaxSize,
+		Redirect:     ratelimit.PerMinute(c.RateLimitRedirectPerMinute, c.RateLimitRedirectBurst),
+	}
+}
+
+// createDeletionJobRepository - создает хранилище задач удаления (БД или in-memory с журналом рядом с файлом хранилища)
+func createDeletionJobRepository(
+	c config.Config,
+	logger zap.SugaredLogger,
+	db *sql.DB,
+) (repository.DeletionJobRepository, error) {
+
+	if c.DBConnectionString != "" {
+		return repository.NewDBDeletionJobRepository(db, logger), nil
+	}
+
+	var filePath string
+	if c.FileStoragePath != "" {
+		filePath = c.FileStoragePath + ".deletions"
+	}
+	return repository.NewInMemoryDeletionJobRepository(filePath, logger)
 }
 
-// createURLDeletionStrategy - создает стратегию удаления URL через очередь
+// createAPIKeyRepository - создает хранилище API-ключей (БД или in-memory с журналом рядом с файлом хранилища)
+func createAPIKeyRepository(
+	c config.Config,
+	logger zap.SugaredLogger,
+	db *sql.DB,
+) (repository.APIKeyRepository, error) {
+
+	if c.DBConnectionString != "" {
+		return repository.NewDBAPIKeyRepository(db, logger), nil
+	}
+
+	var filePath string
+	if c.FileStoragePath != "" {
+		filePath = c.FileStoragePath + ".keys"
+	}
+	return repository.NewInMemoryAPIKeyRepository(filePath, logger)
+}
+
+// createAPIKeyService - создает сервис API-ключей
+func createAPIKeyService(repo repository.APIKeyRepository) *service.APIKeyService {
+	return service.NewAPIKeyService(repo)
+}
+
+// createURLDeletionStrategy - создает стратегию удаления URL через очередь задач;
+// завершенные задачи хранятся в течение срока из конфигурации
 func createURLDeletionStrategy(
+	c config.Config,
 	logger zap.SugaredLogger,
 	repo repository.URLRepository,
-) *service.QueueDeletionStrategy {
+	jobRepo repository.DeletionJobRepository,
+) (*service.QueueDeletionStrategy, error) {
+	if c.DeletionJobRetentionSeconds <= 0 {
+		return nil, fmt.Errorf("invalid deletion job retention: %d", c.DeletionJobRetentionSeconds)
+	}
+
 	workerNum := 5
-	taskNum := 1000
-	waitTimeout := 1 * time.Second
-	return service.NewQueueURLDeletionStrategy(repo, logger, workerNum, taskNum, waitTimeout)
+	batchSize := 100
+	pollInterval := 1 * time.Second
+	waitTimeout := 10 * time.Second
+	retention := time.Duration(c.DeletionJobRetentionSeconds) * time.Second
+	return service.NewQueueURLDeletionStrategy(repo, jobRepo, logger, workerNum, batchSize, pollInterval, waitTimeout, retention), nil
+}
+
+// createExpiredURLSweeper - создает фоновую очистку URL с истекшим сроком действия;
+// URL удаляются вместе с переходами по ним после срока хранения из конфигурации
+func createExpiredURLSweeper(
+	c config.Config,
+	logger zap.SugaredLogger,
+	repo repository.URLRepository,
+	clickRepo repository.ClickRepository,
+) (*service.ExpiredURLSweeper, error) {
+	if c.ExpiredURLSweepIntervalSeconds <= 0 {
+		return nil, fmt.Errorf("invalid expired url sweep interval: %d", c.ExpiredURLSweepIntervalSeconds)
+	}
+	if c.ExpiredURLRetentionSeconds < 0 {
+		return nil, fmt.Errorf("invalid expired url retention: %d", c.ExpiredURLRetentionSeconds)
+	}
+
+	interval := time.Duration(c.ExpiredURLSweepIntervalSeconds) * time.Second
+	retention := time.Duration(c.ExpiredURLRetentionSeconds) * time.Second
+	return service.NewExpiredURLSweeper(repo, clickRepo, logger, interval, retention), nil
+}
+
+// createShortCodeProvider - создает провайдер сокращенных идентификаторов по способу генерации из конфигурации;
+// счетчик для последовательных идентификаторов хранится в БД или в файле рядом с файлом хранилища
+func createShortCodeProvider(
+	c config.Config,
+	logger zap.SugaredLogger,
+	db *sql.DB,
+) (service.ShortCodeProvider, error) {
+
+	switch c.ShortCodeStrategy {
+	case "", service.ShortCodeRandom:
+		return &service.RandomShortCodeProvider{}, nil
+	case service.ShortCodeHash:
+		return &service.HashShortCodeProvider{}, nil
+	case service.ShortCodeSequential, service.ShortCodeObfuscated:
+	default:
+		return nil, fmt.Errorf("unknown short code strategy %q", c.ShortCodeStrategy)
+	}
+
+	var secret string
+	if c.ShortCodeStrategy == service.ShortCodeObfuscated {
+		if c.ShortCodeSecret == "" {
+			return nil, errors.New("short code secret is required for obfuscated short codes")
+		}
+		secret = c.ShortCodeSecret
+	}
+
+	if c.DBConnectionString != "" {
+		return service.NewSequenceShortCodeProvider(repository.NewDBSequenceRepository(db, logger), secret), nil
+	}
+
+	var filePath string
+	if c.FileStoragePath != "" {
+		filePath = c.FileStoragePath + ".seq"
+	}
+	sequence, err := repository.NewInMemorySequenceRepository(filePath, repository.DefaultSequenceBlockSize)
+	if err != nil {
+		return nil, err
+	}
+	return service.NewSequenceShortCodeProvider(sequence, secret), nil
+}
+
+// createShortCodeLength - создает учет длины сокращенных идентификаторов, восстанавливая длину, сохраненную до перезапуска
+func createShortCodeLength(ctx context.Context, c config.Config, logger zap.SugaredLogger, db *sql.DB) (*service.ShortCodeLength, error) {
+	var store repository.ShortCodeLengthRepository
+	if c.DBConnectionString != "" {
+		store = repository.NewDBShortCodeLengthRepository(db, logger)
+	} else {
+		var filePath string
+		if c.FileStoragePath != "" {
+			filePath = c.FileStoragePath + ".length"
+		}
+		fileStore, err := repository.NewInMemoryShortCodeLengthRepository(filePath)
+		if err != nil {
+			return nil, err
+		}
+		store = fileStore
+	}
+	return service.RestoreShortCodeLength(ctx, store, c.ShortURLLength, c.ShortURLMaxLength)
 }
 
 // createShortnerService - создает сервис сокращения URL
@@ -143,21 +623,94 @@ func createShortnerService(
 	c config.Config,
 	logger zap.SugaredLogger,
 	repo repository.URLRepository,
+	domains *service.DomainRegistry,
+	codeLength *service.ShortCodeLength,
+	shortCodeProvider service.ShortCodeProvider,
 	urlDelStrategy service.URLDeletionStrategy,
-) service.URLShortener {
+	urlValidator service.URLValidator,
+	passwordLimiter service.PasswordAttemptLimiter,
+) *service.ShortenURLService {
 
 	return service.NewShortenerService(
 		repo,
-		c.BaseURL,
-		c.ShortURLLength,
-		&service.RandomShortCodeProvider{},
+		domains,
+		codeLength,
+		shortCodeProvider,
 		urlDelStrategy,
+		urlValidator,
+		passwordLimiter,
 		logger,
 	)
 }
 
+// createPasswordAttemptLimiter - создает учет неудачных попыток ввода пароля защищенных URL-адресов
+func createPasswordAttemptLimiter(c config.Config) (*service.InMemoryPasswordAttemptLimiter, error) {
+	if c.PasswordMaxFailures <= 0 || c.PasswordFailureWindowSeconds <= 0 || c.PasswordLockoutSeconds <= 0 {
+		return nil, errors.New("password max failures, failure window and lockout must be positive")
+	}
+	window := time.Duration(c.PasswordFailureWindowSeconds) * time.Second
+	lockout := time.Duration(c.PasswordLockoutSeconds) * time.Second
+	return service.NewInMemoryPasswordAttemptLimiter(c.PasswordMaxFailures, window, lockout), nil
+}
+
+// createShortDomains - создает список дополнительных доменов сокращенных URL-адресов из конфигурации
+func createShortDomains(c config.Config) ([]service.ShortDomain, error) {
+	domains := make([]service.ShortDomain, 0, len(c.ShortDomains))
+	for _, configured := range c.ShortDomains {
+		domain, err := service.NewShortDomain(configured.BaseURL, configured.Users)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, domain)
+	}
+	return domains, nil
+}
+
+// createURLPolicy - создает набор правил проверки сокращаемых URL-адресов
+func createURLPolicy(c config.Config, logger zap.SugaredLogger, shortDomains []service.ShortDomain) (*service.URLPolicyEngine, error) {
+	policies := []service.URLPolicy{
+		service.NewSchemePolicy(strings.Split(c.URLAllowedSchemes, ",")),
+		service.NewMaxLengthPolicy(c.URLMaxLength),
+	}
+
+	if !c.URLAllowPrivateHosts {
+		var resolver service.HostResolver
+		if !c.URLSkipResolveHosts {
+			resolver = net.DefaultResolver
+		}
+		policies = append(policies, service.NewPrivateHostPolicy(resolver, 2*time.Second))
+	}
+
+	if c.URLRejectSelfReference {
+		selfPolicy, err := service.NewSelfReferencePolicy(c.BaseURL)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, selfPolicy)
+
+		for _, domain := range shortDomains {
+			domainPolicy, err := service.NewSelfReferencePolicy(domain.BaseURL)
+			if err != nil {
+				return nil, err
+			}
+			policies = append(policies, domainPolicy)
+		}
+	}
+
+	if c.URLBlocklistFile != "" {
+		reloadInterval := time.Duration(c.URLBlocklistReloadSeconds) * time.Second
+		blocklistPolicy, err := service.NewBlocklistPolicy(c.URLBlocklistFile, reloadInterval, logger)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, blocklistPolicy)
+	}
+
+	return service.NewURLPolicyEngine(policies...), nil
+}
+
 // createLogAudit - создает менеджер аудита логов
-func createLogAudit(c config.Config) service.LogAuditManager {
+func createLogAudit(c config.Config, logger zap.SugaredLogger) *service.AsyncLogAuditManager {
 	auditors := make([]service.LogAuditor, 0, 2)
 
 	if c.AuditFile != "" {
@@ -168,13 +721,67 @@ func createLogAudit(c config.Config) service.LogAuditManager {
 		auditors = append(auditors, service.NewHTTPLogAuditor(c.AuditURL))
 	}
 
-	return service.NewDefaultLogAuditManager(auditors)
+	deadLetterPath := c.AuditDeadLetterFile
+	if deadLetterPath == "" && c.FileStoragePath != "" {
+		deadLetterPath = c.FileStoragePath + ".audit-dead-letter"
+	}
+
+	var deadLetter *service.AuditDeadLetter
+	if deadLetterPath != "" {
+		deadLetter = service.NewAuditDeadLetter(deadLetterPath)
+	} else if len(auditors) > 0 {
+		logger.Warn("audit dead letter file is not set, undelivered audit logs will be dropped")
+	}
+
+	queueSize := 10000
+	batchSize := 100
+	flushInterval := 1 * time.Second
+	return service.NewAsyncLogAuditManager(auditors, deadLetter, logger, queueSize, batchSize, flushInterval)
 }
 
-// createJWTParser - создает парсер JWT токенов
+// createJWTParser - создает парсер JWT токенов (HS256 или RS256/EdDSA с ключами из PEM-файлов)
 func createJWTParser(
 	c config.Config,
 	logger zap.SugaredLogger,
-) service.JWTParser {
-	return service.NewJWTParser(c.JWTSecret, logger)
+) (service.JWTParser, error) {
+	if c.JWTLifetimeSeconds <= 0 {
+		return service.JWTParser{}, errors.New("jwt lifetime must be positive")
+	}
+	lifetime := time.Duration(c.JWTLifetimeSeconds) * time.Second
+	defaultSecret := c.JWTSecret == "" || c.JWTSecret == config.DefaultJWTSecret
+
+	if c.JWTPrivateKeyFile == "" {
+		if defaultSecret && !c.DevMode {
+			return service.JWTParser{}, errors.New("refusing to start with default jwt secret: set jwt secret or private key, or enable dev mode")
+		}
+		return service.NewJWTParser(c.JWTSecret, lifetime, logger), nil
+	}
+
+	privateKey, err := os.ReadFile(c.JWTPrivateKeyFile)
+	if err != nil {
+		return service.JWTParser{}, fmt.Errorf("failed to read jwt private key: %w", err)
+	}
+
+	var verifyKeys [][]byte
+	for _, path := range strings.Split(c.JWTVerifyKeyFiles, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return service.JWTParser{}, fmt.Errorf("failed to read jwt verification key: %w", err)
+		}
+		verifyKeys = append(verifyKeys, data)
+	}
+
+	// Токены, подписанные секретным ключом, принимаются только при явном включении на время перехода на асимметричную подпись.
+	var legacySecret string
+	if c.JWTAcceptLegacySecret {
+		if defaultSecret {
+			return service.JWTParser{}, errors.New("refusing to accept legacy jwt tokens signed with default jwt secret")
+		}
+		logger.Warn("accepting legacy hs256 jwt tokens signed with jwt secret; disable once they expire")
+		legacySecret = c.JWTSecret
+	}
+	return service.NewAsymmetricJWTParser(privateKey, verifyKeys, legacySecret, lifetime, logger)
 }
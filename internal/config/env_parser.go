@@ -2,7 +2,9 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
 )
 
 // EnvConfigParser парсит конфигурацию из переменных окружения
@@ -15,12 +17,58 @@ func (e *EnvConfigParser) Parse(cfg *Config) (*Config, error) {
 	if envServerAddress, ok := os.LookupEnv("SERVER_ADDRESS"); ok {
 		cfg.ServerAddress = envServerAddress
 	}
+	if envGRPCServerAddress, ok := os.LookupEnv("GRPC_SERVER_ADDRESS"); ok {
+		cfg.GRPCServerAddress = envGRPCServerAddress
+	}
 	if envBaseURL, ok := os.LookupEnv("BASE_URL"); ok {
 		cfg.BaseURL = envBaseURL
 	}
+	if shortDomains, ok := os.LookupEnv("SHORT_DOMAINS"); ok {
+		domains, err := ParseShortDomains(shortDomains)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SHORT_DOMAINS: %w", err)
+		}
+		cfg.ShortDomains = domains
+	}
+	if shortURLMaxLength, ok := os.LookupEnv("SHORT_URL_MAX_LENGTH"); ok {
+		value, err := strconv.Atoi(shortURLMaxLength)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SHORT_URL_MAX_LENGTH: %w", err)
+		}
+		cfg.ShortURLMaxLength = value
+	}
+	if shortCodeStrategy, ok := os.LookupEnv("SHORT_CODE_STRATEGY"); ok {
+		cfg.ShortCodeStrategy = shortCodeStrategy
+	}
+	if shortCodeSecret, ok := os.LookupEnv("SHORT_CODE_SECRET"); ok {
+		cfg.ShortCodeSecret = shortCodeSecret
+	}
 	if fileStoragePath, ok := os.LookupEnv("FILE_STORAGE_PATH"); ok {
 		cfg.FileStoragePath = fileStoragePath
 	}
+	if fileStorageSync, ok := os.LookupEnv("FILE_STORAGE_SYNC"); ok {
+		cfg.FileStorageSync = fileStorageSync
+	}
+	if cacheBackend, ok := os.LookupEnv("CACHE_BACKEND"); ok {
+		cfg.CacheBackend = cacheBackend
+	}
+	if cacheSize, ok := os.LookupEnv("CACHE_SIZE"); ok {
+		size, err := strconv.Atoi(cacheSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CACHE_SIZE: %w", err)
+		}
+		cfg.CacheSize = size
+	}
+	if cacheTTL, ok := os.LookupEnv("CACHE_TTL_SECONDS"); ok {
+		ttl, err := strconv.Atoi(cacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CACHE_TTL_SECONDS: %w", err)
+		}
+		cfg.CacheTTLSeconds = ttl
+	}
+	if redisAddress, ok := os.LookupEnv("REDIS_ADDRESS"); ok {
+		cfg.RedisAddress = redisAddress
+	}
 	if logLevel, ok := os.LookupEnv("LOG_LEVEL"); ok {
 		cfg.LogLevel = logLevel
 	}
@@ -30,16 +78,48 @@ func (e *EnvConfigParser) Parse(cfg *Config) (*Config, error) {
 	if jwtSecret, ok := os.LookupEnv("JWT_SECRET"); ok {
 		cfg.JWTSecret = jwtSecret
 	}
+	if jwtPrivateKeyFile, ok := os.LookupEnv("JWT_PRIVATE_KEY_FILE"); ok {
+		cfg.JWTPrivateKeyFile = jwtPrivateKeyFile
+	}
+	if jwtVerifyKeyFiles, ok := os.LookupEnv("JWT_VERIFY_KEY_FILES"); ok {
+		cfg.JWTVerifyKeyFiles = jwtVerifyKeyFiles
+	}
+	if jwtAcceptLegacySecret, ok := os.LookupEnv("JWT_ACCEPT_LEGACY_SECRET"); ok {
+		value, err := strconv.ParseBool(jwtAcceptLegacySecret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT_ACCEPT_LEGACY_SECRET: %w", err)
+		}
+		cfg.JWTAcceptLegacySecret = value
+	}
+	if jwtLifetime, ok := os.LookupEnv("JWT_LIFETIME_SECONDS"); ok {
+		value, err := strconv.Atoi(jwtLifetime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT_LIFETIME_SECONDS: %w", err)
+		}
+		cfg.JWTLifetimeSeconds = value
+	}
+	if devMode, ok := os.LookupEnv("DEV_MODE"); ok {
+		value, err := strconv.ParseBool(devMode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DEV_MODE: %w", err)
+		}
+		cfg.DevMode = value
+	}
 	if auditFile, ok := os.LookupEnv("AUDIT_FILE"); ok {
 		cfg.AuditFile = auditFile
 	}
 	if auditURL, ok := os.LookupEnv("AUDIT_URL"); ok {
 		cfg.AuditURL = auditURL
 	}
+	if auditDeadLetterFile, ok := os.LookupEnv("AUDIT_DEAD_LETTER_FILE"); ok {
+		cfg.AuditDeadLetterFile = auditDeadLetterFile
+	}
 	if envEnableHTTPS, ok := os.LookupEnv("ENABLE_HTTPS"); ok {
-		if envEnableHTTPS == "true" {
-			cfg.EnableHTTPS = true
+		value, err := strconv.ParseBool(envEnableHTTPS)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ENABLE_HTTPS: %w", err)
 		}
+		cfg.EnableHTTPS = value
 	}
 	if envCertFile, ok := os.LookupEnv("TLS_CERT_FILE"); ok {
 		cfg.TLSCertFile = envCertFile
@@ -47,6 +127,148 @@ func (e *EnvConfigParser) Parse(cfg *Config) (*Config, error) {
 	if envKeyFile, ok := os.LookupEnv("TLS_KEY_FILE"); ok {
 		cfg.TLSKeyFile = envKeyFile
 	}
+	if trustedSubnet, ok := os.LookupEnv("TRUSTED_SUBNET"); ok {
+		cfg.TrustedSubnet = trustedSubnet
+	}
+	if trustedProxies, ok := os.LookupEnv("TRUSTED_PROXIES"); ok {
+		cfg.TrustedProxies = trustedProxies
+	}
+	if clickIPHashKey, ok := os.LookupEnv("CLICK_IP_HASH_KEY"); ok {
+		cfg.ClickIPHashKey = clickIPHashKey
+	}
+	if rateLimitShortenPerMinute, ok := os.LookupEnv("RATE_LIMIT_SHORTEN_PER_MINUTE"); ok {
+		value, err := strconv.Atoi(rateLimitShortenPerMinute)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_SHORTEN_PER_MINUTE: %w", err)
+		}
+		cfg.RateLimitShortenPerMinute = value
+	}
+	if rateLimitShortenBurst, ok := os.LookupEnv("RATE_LIMIT_SHORTEN_BURST"); ok {
+		value, err := strconv.Atoi(rateLimitShortenBurst)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_SHORTEN_BURST: %w", err)
+		}
+		cfg.RateLimitShortenBurst = value
+	}
+	if rateLimitBatchPerMinute, ok := os.LookupEnv("RATE_LIMIT_BATCH_PER_MINUTE"); ok {
+		value, err := strconv.Atoi(rateLimitBatchPerMinute)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_BATCH_PER_MINUTE: %w", err)
+		}
+		cfg.RateLimitBatchPerMinute = value
+	}
+	if rateLimitBatchBurst, ok := os.LookupEnv("RATE_LIMIT_BATCH_BURST"); ok {
+		value, err := strconv.Atoi(rateLimitBatchBurst)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_BATCH_BURST: %w", err)
+		}
+		cfg.RateLimitBatchBurst = value
+	}
+	if batchMaxSize, ok := os.LookupEnv("BATCH_MAX_SIZE"); ok {
+		value, err := strconv.Atoi(batchMaxSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BATCH_MAX_SIZE: %w", err)
+		}
+		cfg.BatchMaxSize = value
+	}
+	if rateLimitRedirectPerMinute, ok := os.LookupEnv("RATE_LIMIT_REDIRECT_PER_MINUTE"); ok {
+		value, err := strconv.Atoi(rateLimitRedirectPerMinute)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_REDIRECT_PER_MINUTE: %w", err)
+		}
+		cfg.RateLimitRedirectPerMinute = value
+	}
+	if rateLimitRedirectBurst, ok := os.LookupEnv("RATE_LIMIT_REDIRECT_BURST"); ok {
+		value, err := strconv.Atoi(rateLimitRedirectBurst)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_REDIRECT_BURST: %w", err)
+		}
+		cfg.RateLimitRedirectBurst = value
+	}
+	if passwordMaxFailures, ok := os.LookupEnv("PASSWORD_MAX_FAILURES"); ok {
+		value, err := strconv.Atoi(passwordMaxFailures)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PASSWORD_MAX_FAILURES: %w", err)
+		}
+		cfg.PasswordMaxFailures = value
+	}
+	if passwordFailureWindow, ok := os.LookupEnv("PASSWORD_FAILURE_WINDOW_SECONDS"); ok {
+		value, err := strconv.Atoi(passwordFailureWindow)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PASSWORD_FAILURE_WINDOW_SECONDS: %w", err)
+		}
+		cfg.PasswordFailureWindowSeconds = value
+	}
+	if passwordLockout, ok := os.LookupEnv("PASSWORD_LOCKOUT_SECONDS"); ok {
+		value, err := strconv.Atoi(passwordLockout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PASSWORD_LOCKOUT_SECONDS: %w", err)
+		}
+		cfg.PasswordLockoutSeconds = value
+	}
+	if expiredURLSweepInterval, ok := os.LookupEnv("EXPIRED_URL_SWEEP_INTERVAL_SECONDS"); ok {
+		value, err := strconv.Atoi(expiredURLSweepInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EXPIRED_URL_SWEEP_INTERVAL_SECONDS: %w", err)
+		}
+		cfg.ExpiredURLSweepIntervalSeconds = value
+	}
+	if expiredURLRetention, ok := os.LookupEnv("EXPIRED_URL_RETENTION_SECONDS"); ok {
+		value, err := strconv.Atoi(expiredURLRetention)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EXPIRED_URL_RETENTION_SECONDS: %w", err)
+		}
+		cfg.ExpiredURLRetentionSeconds = value
+	}
+	if deletionJobRetention, ok := os.LookupEnv("DELETION_JOB_RETENTION_SECONDS"); ok {
+		value, err := strconv.Atoi(deletionJobRetention)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DELETION_JOB_RETENTION_SECONDS: %w", err)
+		}
+		cfg.DeletionJobRetentionSeconds = value
+	}
+
+	if urlAllowedSchemes, ok := os.LookupEnv("URL_ALLOWED_SCHEMES"); ok {
+		cfg.URLAllowedSchemes = urlAllowedSchemes
+	}
+	if urlMaxLength, ok := os.LookupEnv("URL_MAX_LENGTH"); ok {
+		value, err := strconv.Atoi(urlMaxLength)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL_MAX_LENGTH: %w", err)
+		}
+		cfg.URLMaxLength = value
+	}
+	if urlBlocklistFile, ok := os.LookupEnv("URL_BLOCKLIST_FILE"); ok {
+		cfg.URLBlocklistFile = urlBlocklistFile
+	}
+	if urlBlocklistReload, ok := os.LookupEnv("URL_BLOCKLIST_RELOAD_SECONDS"); ok {
+		value, err := strconv.Atoi(urlBlocklistReload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL_BLOCKLIST_RELOAD_SECONDS: %w", err)
+		}
+		cfg.URLBlocklistReloadSeconds = value
+	}
+	if urlAllowPrivateHosts, ok := os.LookupEnv("URL_ALLOW_PRIVATE_HOSTS"); ok {
+		value, err := strconv.ParseBool(urlAllowPrivateHosts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL_ALLOW_PRIVATE_HOSTS: %w", err)
+		}
+		cfg.URLAllowPrivateHosts = value
+	}
+	if urlSkipResolveHosts, ok := os.LookupEnv("URL_SKIP_RESOLVE_HOSTS"); ok {
+		value, err := strconv.ParseBool(urlSkipResolveHosts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL_SKIP_RESOLVE_HOSTS: %w", err)
+		}
+		cfg.URLSkipResolveHosts = value
+	}
+	if urlRejectSelfReference, ok := os.LookupEnv("URL_REJECT_SELF_REFERENCE"); ok {
+		value, err := strconv.ParseBool(urlRejectSelfReference)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL_REJECT_SELF_REFERENCE: %w", err)
+		}
+		cfg.URLRejectSelfReference = value
+	}
 
 	if jsonConfig, ok := os.LookupEnv("CONFIG"); ok {
 		cfg.JSONConfig = jsonConfig
@@ -10,17 +10,63 @@ type FlagsConfigParser struct {
 // парсит конфигурацию из флагов командной строки
 func (f *FlagsConfigParser) Parse(cfg *Config) (*Config, error) {
 	flag.StringVar(&cfg.ServerAddress, "a", cfg.ServerAddress, "address to startup server")
+	flag.StringVar(&cfg.GRPCServerAddress, "grpc-address", cfg.GRPCServerAddress, "address to startup gRPC server")
 	flag.StringVar(&cfg.BaseURL, "b", cfg.BaseURL, "domain to use for short urls")
+	flag.Func("short-domains", `additional short url domains as json array ([{"base_url": "https://brand.link", "users": ["user1"]}])`, func(value string) error {
+		domains, err := ParseShortDomains(value)
+		if err != nil {
+			return err
+		}
+		cfg.ShortDomains = domains
+		return nil
+	})
 	flag.StringVar(&cfg.FileStoragePath, "f", cfg.FileStoragePath, "file path to save storage")
+	flag.StringVar(&cfg.FileStorageSync, "file-sync", cfg.FileStorageSync, "file storage journal sync policy (always, interval, never)")
+	flag.StringVar(&cfg.CacheBackend, "cache", cfg.CacheBackend, "url cache backend (none, memory, redis)")
+	flag.IntVar(&cfg.CacheSize, "cache-size", cfg.CacheSize, "max number of urls in memory cache")
+	flag.IntVar(&cfg.CacheTTLSeconds, "cache-ttl", cfg.CacheTTLSeconds, "url cache ttl in seconds")
+	flag.StringVar(&cfg.RedisAddress, "redis-address", cfg.RedisAddress, "redis address for redis cache backend")
 	flag.StringVar(&cfg.DBConnectionString, "d", cfg.DBConnectionString, "database connection string")
 	flag.StringVar(&cfg.LogLevel, "l", cfg.LogLevel, "log level")
 	flag.StringVar(&cfg.JWTSecret, "jwtkey", cfg.JWTSecret, "jwt secret key")
+	flag.StringVar(&cfg.JWTPrivateKeyFile, "jwt-private-key", cfg.JWTPrivateKeyFile, "pem file with rsa or ed25519 private key to sign jwt tokens")
+	flag.StringVar(&cfg.JWTVerifyKeyFiles, "jwt-verify-keys", cfg.JWTVerifyKeyFiles, "comma-separated pem files with additional jwt verification keys")
+	flag.BoolVar(&cfg.JWTAcceptLegacySecret, "jwt-accept-legacy-secret", cfg.JWTAcceptLegacySecret, "accept hs256 tokens signed with jwt secret while signing with private key")
+	flag.IntVar(&cfg.JWTLifetimeSeconds, "jwt-lifetime", cfg.JWTLifetimeSeconds, "jwt token lifetime in seconds")
+	flag.BoolVar(&cfg.DevMode, "dev", cfg.DevMode, "development mode: allow default jwt secret")
 	flag.StringVar(&cfg.AuditFile, "audit-file", cfg.AuditFile, "file to keep audit logs")
 	flag.StringVar(&cfg.AuditURL, "audit-url", cfg.AuditURL, "URL to pass audit logs")
+	flag.StringVar(&cfg.AuditDeadLetterFile, "audit-dead-letter", cfg.AuditDeadLetterFile, "file to keep undelivered audit logs")
 	flag.IntVar(&cfg.ShortURLLength, "short-len", cfg.ShortURLLength, "length of generated short url")
+	flag.IntVar(&cfg.ShortURLMaxLength, "short-max-len", cfg.ShortURLMaxLength, "max length generated short url may grow to on collisions")
+	flag.StringVar(&cfg.ShortCodeStrategy, "short-code", cfg.ShortCodeStrategy, "short code generation strategy (random, sequential, obfuscated, hash)")
+	flag.StringVar(&cfg.ShortCodeSecret, "short-code-secret", cfg.ShortCodeSecret, "secret key for obfuscated short codes")
 	flag.BoolVar(&cfg.EnableHTTPS, "s", cfg.EnableHTTPS, "Enable HTTPS")
 	flag.StringVar(&cfg.TLSCertFile, "tlscert", cfg.TLSCertFile, "TLS certificate file")
 	flag.StringVar(&cfg.TLSKeyFile, "tlskey", cfg.TLSKeyFile, "TLS key file")
+	flag.StringVar(&cfg.TrustedSubnet, "t", cfg.TrustedSubnet, "trusted subnet (CIDR) for internal endpoints")
+	flag.StringVar(&cfg.TrustedProxies, "trusted-proxies", cfg.TrustedProxies, "comma-separated trusted proxy subnets (CIDR) allowed to pass client ip headers")
+	flag.StringVar(&cfg.ClickIPHashKey, "click-ip-hash-key", cfg.ClickIPHashKey, "secret key to hash client ip addresses in click statistics")
+	flag.IntVar(&cfg.RateLimitShortenPerMinute, "rate-shorten", cfg.RateLimitShortenPerMinute, "max shorten requests per minute per client (0 disables)")
+	flag.IntVar(&cfg.RateLimitShortenBurst, "rate-shorten-burst", cfg.RateLimitShortenBurst, "shorten requests burst per client")
+	flag.IntVar(&cfg.RateLimitBatchPerMinute, "rate-batch", cfg.RateLimitBatchPerMinute, "max urls in batch requests per minute per client (0 disables)")
+	flag.IntVar(&cfg.RateLimitBatchBurst, "rate-batch-burst", cfg.RateLimitBatchBurst, "batch urls burst per client")
+	flag.IntVar(&cfg.BatchMaxSize, "batch-max-size", cfg.BatchMaxSize, "max urls in one batch request (0 disables)")
+	flag.IntVar(&cfg.RateLimitRedirectPerMinute, "rate-redirect", cfg.RateLimitRedirectPerMinute, "max redirects per minute per client (0 disables)")
+	flag.IntVar(&cfg.RateLimitRedirectBurst, "rate-redirect-burst", cfg.RateLimitRedirectBurst, "redirects burst per client")
+	flag.IntVar(&cfg.PasswordMaxFailures, "password-max-failures", cfg.PasswordMaxFailures, "failed password attempts before client lockout")
+	flag.IntVar(&cfg.PasswordFailureWindowSeconds, "password-failure-window", cfg.PasswordFailureWindowSeconds, "window to count failed password attempts in seconds")
+	flag.IntVar(&cfg.PasswordLockoutSeconds, "password-lockout", cfg.PasswordLockoutSeconds, "client lockout duration after failed password attempts in seconds")
+	flag.IntVar(&cfg.ExpiredURLSweepIntervalSeconds, "expired-url-sweep-interval", cfg.ExpiredURLSweepIntervalSeconds, "interval to purge expired urls in seconds")
+	flag.IntVar(&cfg.ExpiredURLRetentionSeconds, "expired-url-retention", cfg.ExpiredURLRetentionSeconds, "how long expired urls are kept before purge in seconds")
+	flag.IntVar(&cfg.DeletionJobRetentionSeconds, "deletion-job-retention", cfg.DeletionJobRetentionSeconds, "how long finished deletion jobs are kept in seconds")
+	flag.StringVar(&cfg.URLAllowedSchemes, "url-schemes", cfg.URLAllowedSchemes, "comma separated allowed url schemes")
+	flag.IntVar(&cfg.URLMaxLength, "url-max-length", cfg.URLMaxLength, "max url length in bytes")
+	flag.StringVar(&cfg.URLBlocklistFile, "url-blocklist", cfg.URLBlocklistFile, "file with blocked domains and regex patterns")
+	flag.IntVar(&cfg.URLBlocklistReloadSeconds, "url-blocklist-reload", cfg.URLBlocklistReloadSeconds, "blocklist file reload check interval in seconds")
+	flag.BoolVar(&cfg.URLAllowPrivateHosts, "url-allow-private", cfg.URLAllowPrivateHosts, "allow urls pointing to localhost and private networks")
+	flag.BoolVar(&cfg.URLSkipResolveHosts, "url-skip-resolve-hosts", cfg.URLSkipResolveHosts, "do not resolve url hosts to check for private addresses")
+	flag.BoolVar(&cfg.URLRejectSelfReference, "url-reject-self", cfg.URLRejectSelfReference, "reject urls pointing back to base url host")
 
 	var configFileShort string
 	var configFileLong string
@@ -75,12 +75,33 @@ func (j *JSONConfigParser) mergeConfig(main *Config, json *Config) {
 	if json.ServerAddress != "" {
 		main.ServerAddress = json.ServerAddress
 	}
+	if json.GRPCServerAddress != "" {
+		main.GRPCServerAddress = json.GRPCServerAddress
+	}
 	if json.BaseURL != "" {
 		main.BaseURL = json.BaseURL
 	}
+	if len(json.ShortDomains) > 0 {
+		main.ShortDomains = json.ShortDomains
+	}
 	if json.FileStoragePath != "" {
 		main.FileStoragePath = json.FileStoragePath
 	}
+	if json.FileStorageSync != "" {
+		main.FileStorageSync = json.FileStorageSync
+	}
+	if json.CacheBackend != "" {
+		main.CacheBackend = json.CacheBackend
+	}
+	if json.CacheSize > 0 {
+		main.CacheSize = json.CacheSize
+	}
+	if json.CacheTTLSeconds > 0 {
+		main.CacheTTLSeconds = json.CacheTTLSeconds
+	}
+	if json.RedisAddress != "" {
+		main.RedisAddress = json.RedisAddress
+	}
 	if json.DBConnectionString != "" {
 		main.DBConnectionString = json.DBConnectionString
 	}
@@ -90,20 +111,116 @@ func (j *JSONConfigParser) mergeConfig(main *Config, json *Config) {
 	if json.JWTSecret != "" {
 		main.JWTSecret = json.JWTSecret
 	}
+	if json.JWTPrivateKeyFile != "" {
+		main.JWTPrivateKeyFile = json.JWTPrivateKeyFile
+	}
+	if json.JWTVerifyKeyFiles != "" {
+		main.JWTVerifyKeyFiles = json.JWTVerifyKeyFiles
+	}
+	if json.JWTAcceptLegacySecret {
+		main.JWTAcceptLegacySecret = true
+	}
+	if json.JWTLifetimeSeconds > 0 {
+		main.JWTLifetimeSeconds = json.JWTLifetimeSeconds
+	}
+	if json.DevMode {
+		main.DevMode = true
+	}
 	if json.AuditFile != "" {
 		main.AuditFile = json.AuditFile
 	}
 	if json.AuditURL != "" {
 		main.AuditURL = json.AuditURL
 	}
+	if json.AuditDeadLetterFile != "" {
+		main.AuditDeadLetterFile = json.AuditDeadLetterFile
+	}
 	if json.TLSCertFile != "" {
 		main.TLSCertFile = json.TLSCertFile
 	}
 	if json.TLSKeyFile != "" {
 		main.TLSKeyFile = json.TLSKeyFile
 	}
+	if json.TrustedSubnet != "" {
+		main.TrustedSubnet = json.TrustedSubnet
+	}
+	if json.TrustedProxies != "" {
+		main.TrustedProxies = json.TrustedProxies
+	}
+	if json.ClickIPHashKey != "" {
+		main.ClickIPHashKey = json.ClickIPHashKey
+	}
+	if json.RateLimitShortenPerMinute != 0 {
+		main.RateLimitShortenPerMinute = json.RateLimitShortenPerMinute
+	}
+	if json.RateLimitShortenBurst > 0 {
+		main.RateLimitShortenBurst = json.RateLimitShortenBurst
+	}
+	if json.RateLimitBatchPerMinute != 0 {
+		main.RateLimitBatchPerMinute = json.RateLimitBatchPerMinute
+	}
+	if json.RateLimitBatchBurst > 0 {
+		main.RateLimitBatchBurst = json.RateLimitBatchBurst
+	}
+	if json.BatchMaxSize != 0 {
+		main.BatchMaxSize = json.BatchMaxSize
+	}
+	if json.RateLimitRedirectPerMinute != 0 {
+		main.RateLimitRedirectPerMinute = json.RateLimitRedirectPerMinute
+	}
+	if json.RateLimitRedirectBurst > 0 {
+		main.RateLimitRedirectBurst = json.RateLimitRedirectBurst
+	}
+	if json.PasswordMaxFailures > 0 {
+		main.PasswordMaxFailures = json.PasswordMaxFailures
+	}
+	if json.PasswordFailureWindowSeconds > 0 {
+		main.PasswordFailureWindowSeconds = json.PasswordFailureWindowSeconds
+	}
+	if json.PasswordLockoutSeconds > 0 {
+		main.PasswordLockoutSeconds = json.PasswordLockoutSeconds
+	}
+	if json.ExpiredURLSweepIntervalSeconds > 0 {
+		main.ExpiredURLSweepIntervalSeconds = json.ExpiredURLSweepIntervalSeconds
+	}
+	if json.ExpiredURLRetentionSeconds > 0 {
+		main.ExpiredURLRetentionSeconds = json.ExpiredURLRetentionSeconds
+	}
+	if json.DeletionJobRetentionSeconds > 0 {
+		main.DeletionJobRetentionSeconds = json.DeletionJobRetentionSeconds
+	}
+	if json.URLAllowedSchemes != "" {
+		main.URLAllowedSchemes = json.URLAllowedSchemes
+	}
+	if json.URLMaxLength > 0 {
+		main.URLMaxLength = json.URLMaxLength
+	}
+	if json.URLBlocklistFile != "" {
+		main.URLBlocklistFile = json.URLBlocklistFile
+	}
+	if json.URLBlocklistReloadSeconds > 0 {
+		main.URLBlocklistReloadSeconds = json.URLBlocklistReloadSeconds
+	}
+	if json.URLAllowPrivateHosts {
+		main.URLAllowPrivateHosts = true
+	}
+	if json.URLSkipResolveHosts {
+		main.URLSkipResolveHosts = true
+	}
+	if json.URLRejectSelfReference {
+		main.URLRejectSelfReference = true
+	}
 	main.EnableHTTPS = json.EnableHTTPS
 	if json.ShortURLLength > 0 {
 		main.ShortURLLength = json.ShortURLLength
 	}
+	if json.ShortURLMaxLength > 0 {
+		main.ShortURLMaxLength = json.ShortURLMaxLength
+	}
+	if json.ShortCodeStrategy != "" {
+		main.ShortCodeStrategy = json.ShortCodeStrategy
+	}
+	if json.ShortCodeSecret != "" {
+		main.ShortCodeSecret = json.ShortCodeSecret
+	}
 }
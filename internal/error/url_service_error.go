@@ -10,3 +10,57 @@ var ErrServiceFailedToGetShortURL = errors.New("failed to get short url")
 
 // ErrServiceURLGone представляет ошибку, которая возникает при попытке доступа к удаленному URL-адресу.
 var ErrServiceURLGone = errors.New("url has been deleted")
+
+// ErrServiceInvalidAlias представляет ошибку, которая возникает при недопустимом пользовательском идентификаторе.
+var ErrServiceInvalidAlias = errors.New("invalid alias")
+
+// ErrServiceURLAlreadyShortened представляет ошибку, которая возникает, когда URL-адрес уже сокращен под другим идентификатором, чем запрошенный пользовательский.
+var ErrServiceURLAlreadyShortened = errors.New("url is already shortened with another code")
+
+// ErrServiceURLExpired представляет ошибку, которая возникает при попытке доступа к URL-адресу с истекшим сроком действия.
+var ErrServiceURLExpired = errors.New("url has expired")
+
+// ErrServiceURLNotOwned представляет ошибку, которая возникает при доступе к URL-адресу другого пользователя.
+var ErrServiceURLNotOwned = errors.New("url is owned by another user")
+
+// ErrServiceURLRejected представляет ошибку, которая возникает, когда URL-адрес отклонен политикой проверки URL-адресов.
+var ErrServiceURLRejected = errors.New("url rejected by policy")
+
+// ErrServiceInvalidTitle представляет ошибку, которая возникает при недопустимом заголовке URL-адреса.
+var ErrServiceInvalidTitle = errors.New("invalid title")
+
+// ErrServiceInvalidPassword представляет ошибку, которая возникает при недопустимом пароле URL-адреса.
+var ErrServiceInvalidPassword = errors.New("invalid password")
+
+// ErrServiceURLProtected представляет ошибку, которая возникает при доступе к защищенному паролем URL-адресу без пароля.
+var ErrServiceURLProtected = errors.New("url is password protected")
+
+// ErrServiceWrongPassword представляет ошибку, которая возникает при неверном пароле защищенного URL-адреса.
+var ErrServiceWrongPassword = errors.New("wrong password")
+
+// ErrServiceTooManyAttempts представляет ошибку, которая возникает, когда клиент заблокирован после неудачных попыток ввода пароля.
+var ErrServiceTooManyAttempts = errors.New("too many failed password attempts")
+
+// ErrServiceInvalidTag представляет ошибку, которая возникает при недопустимой метке URL-адреса.
+var ErrServiceInvalidTag = errors.New("invalid tag")
+
+// ErrServiceShortCodeSpaceExhausted представляет ошибку, которая возникает, когда значение счетчика не помещается в сокращенный идентификатор.
+var ErrServiceShortCodeSpaceExhausted = errors.New("short code space exhausted")
+
+// ErrServiceUnknownDomain представляет ошибку, которая возникает, когда домен сокращенного URL-адреса не настроен.
+var ErrServiceUnknownDomain = errors.New("unknown short domain")
+
+// ErrServiceDomainNotAllowed представляет ошибку, которая возникает, когда пользователю не разрешено сокращать URL-адреса в домене.
+var ErrServiceDomainNotAllowed = errors.New("short domain is not allowed for user")
+
+// ErrServiceInvalidAPIKey представляет ошибку, которая возникает при неизвестном или отозванном API-ключе.
+var ErrServiceInvalidAPIKey = errors.New("invalid api key")
+
+// ErrServiceInvalidAPIKeyName представляет ошибку, которая возникает при недопустимом названии API-ключа.
+var ErrServiceInvalidAPIKeyName = errors.New("invalid api key name")
+
+// ErrServiceInvalidAPIKeyScope представляет ошибку, которая возникает при недопустимой области доступа API-ключа.
+var ErrServiceInvalidAPIKeyScope = errors.New("invalid api key scope")
+
+// ErrServiceTooManyAPIKeys представляет ошибку, которая возникает, когда у пользователя слишком много действующих API-ключей.
+var ErrServiceTooManyAPIKeys = errors.New("too many api keys")
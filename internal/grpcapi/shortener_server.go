@@ -0,0 +1,223 @@
+// Package grpcapi содержит реализацию gRPC API сервиса сокращения URL-адресов.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/handler"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+	pb "github.com/oegegr/shortener/pkg/api/shortener"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ShortenerServer реализует gRPC сервис Shortener поверх service.URLShortener.
+type ShortenerServer struct {
+	pb.UnimplementedShortenerServer
+	// urlService предоставляет сервис для работы с URL-адресами.
+	urlService service.URLShortener
+	// userIDProvider предоставляет провайдер для получения идентификатора пользователя.
+	userIDProvider handler.UserIDProvider
+	// repo предоставляет репозиторий URL-адресов для проверки доступности.
+	repo repository.URLRepository
+	// logAudit предоставляет менеджер для аудита логов.
+	logAudit service.LogAuditManager
+}
+
+// NewShortenerServer возвращает новый экземпляр ShortenerServer.
+func NewShortenerServer(
+	urlService service.URLShortener,
+	provider handler.UserIDProvider,
+	repo repository.URLRepository,
+	logAudit service.LogAuditManager,
+) *ShortenerServer {
+	return &ShortenerServer{
+		urlService:     urlService,
+		userIDProvider: provider,
+		repo:           repo,
+		logAudit:       logAudit,
+	}
+}
+
+// Shorten сокращает URL-адрес.
+func (s *ShortenerServer) Shorten(ctx context.Context, req *pb.ShortenRequest) (*pb.ShortenResponse, error) {
+	params, err := shortenParams(req.GetUrl(), req.GetAlias(), req.GetTtlSeconds(), req.GetExpiresAt(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := s.userIDProvider.Get(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	shortURL, err := s.urlService.GetShortURL(ctx, params, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRepoURLAlreadyExists) {
+			return &pb.ShortenResponse{Result: shortURL, AlreadyExists: true}, nil
+		}
+		return nil, shortenStatusError(err)
+	}
+
+	s.logAudit.NotifyAllAuditors(ctx, *model.NewLogAuditItem(params.URL, userID, model.LogActionShorten))
+	return &pb.ShortenResponse{Result: shortURL}, nil
+}
+
+// ShortenBatch сокращает несколько URL-адресов.
+func (s *ShortenerServer) ShortenBatch(ctx context.Context, req *pb.ShortenBatchRequest) (*pb.ShortenBatchResponse, error) {
+	now := time.Now()
+	params := make([]model.ShortenParams, 0, len(req.GetItems()))
+	for _, item := range req.GetItems() {
+		param, err := shortenParams(item.GetOriginalUrl(), item.GetAlias(), item.GetTtlSeconds(), item.GetExpiresAt(), now)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+
+	userID, err := s.userIDProvider.Get(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	shortURLs, err := s.urlService.GetShortURLBatch(ctx, params, userID)
+	if err != nil {
+		return nil, shortenStatusError(err)
+	}
+
+	resp := &pb.ShortenBatchResponse{Items: make([]*pb.BatchResult, 0, len(shortURLs))}
+	for idx, shortURL := range shortURLs {
+		resp.Items = append(resp.Items, &pb.BatchResult{
+			CorrelationId: req.GetItems()[idx].GetCorrelationId(),
+			ShortUrl:      shortURL,
+		})
+	}
+	return resp, nil
+}
+
+// Expand возвращает оригинальный URL-адрес по сокращенному идентификатору.
+func (s *ShortenerServer) Expand(ctx context.Context, req *pb.ExpandRequest) (*pb.ExpandResponse, error) {
+	if req.GetShortId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing short id")
+	}
+	if !model.IsShortCode(req.GetShortId()) {
+		return nil, status.Error(codes.InvalidArgument, "invalid short id")
+	}
+
+	userID, _ := s.userIDProvider.Get(ctx)
+
+	originalURL, err := s.urlService.GetOriginalURL(ctx, model.ShortKey(service.DomainFromContext(ctx), req.GetShortId()))
+	if err != nil {
+		if errors.Is(err, repository.ErrRepoNotFound) ||
+			errors.Is(err, app_error.ErrServiceURLGone) ||
+			errors.Is(err, app_error.ErrServiceURLExpired) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(err, app_error.ErrServiceURLProtected) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	s.logAudit.NotifyAllAuditors(ctx, *model.NewLogAuditItem(originalURL, userID, model.LogActionFollow))
+	return &pb.ExpandResponse{OriginalUrl: originalURL}, nil
+}
+
+// ListUserURLs возвращает URL-адреса пользователя.
+func (s *ShortenerServer) ListUserURLs(ctx context.Context, req *pb.ListUserURLsRequest) (*pb.ListUserURLsResponse, error) {
+	userID, err := s.userIDProvider.Get(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	userURLs, err := s.urlService.GetUserURL(ctx, userID)
+	if err != nil && !errors.Is(err, repository.ErrRepoNotFound) {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &pb.ListUserURLsResponse{Urls: make([]*pb.UserURL, 0, len(userURLs))}
+	for _, userURL := range userURLs {
+		resp.Urls = append(resp.Urls, &pb.UserURL{ShortUrl: userURL.ShortURL, OriginalUrl: userURL.URL})
+	}
+	return resp, nil
+}
+
+// DeleteUserURLs ставит в очередь удаление URL-адресов пользователя.
+func (s *ShortenerServer) DeleteUserURLs(ctx context.Context, req *pb.DeleteUserURLsRequest) (*pb.DeleteUserURLsResponse, error) {
+	userID, err := s.userIDProvider.Get(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	domain := service.DomainFromContext(ctx)
+	shortKeys := make([]string, 0, len(req.GetShortIds()))
+	for _, shortID := range req.GetShortIds() {
+		if !model.IsShortCode(shortID) {
+			return nil, status.Error(codes.InvalidArgument, "invalid short id")
+		}
+		shortKeys = append(shortKeys, model.ShortKey(domain, shortID))
+	}
+
+	jobID, err := s.urlService.DeleteUserURL(ctx, userID, shortKeys)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.DeleteUserURLsResponse{JobId: jobID}, nil
+}
+
+// Ping проверяет доступность хранилища.
+func (s *ShortenerServer) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingResponse, error) {
+	if err := s.repo.Ping(ctx); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &pb.PingResponse{}, nil
+}
+
+// shortenParams проверяет параметры сокращения и возвращает их в виде model.ShortenParams.
+func shortenParams(originalURL string, alias string, ttlSeconds int64, expiresAt *timestamppb.Timestamp, now time.Time) (model.ShortenParams, error) {
+	if _, err := url.ParseRequestURI(originalURL); err != nil {
+		return model.ShortenParams{}, status.Error(codes.InvalidArgument, "invalid url")
+	}
+
+	var absolute *time.Time
+	if expiresAt != nil {
+		t := expiresAt.AsTime()
+		absolute = &t
+	}
+
+	expires, err := model.ResolveExpiration(ttlSeconds, absolute, now)
+	if err != nil {
+		return model.ShortenParams{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return model.ShortenParams{URL: originalURL, Alias: alias, ExpiresAt: expires}, nil
+}
+
+// shortenStatusError преобразует ошибку сокращения URL-адреса в gRPC статус.
+func shortenStatusError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrRepoAliasAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, repository.ErrRepoURLAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, app_error.ErrServiceURLAlreadyShortened):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, app_error.ErrServiceInvalidAlias),
+		errors.Is(err, app_error.ErrServiceInvalidTitle),
+		errors.Is(err, app_error.ErrServiceInvalidPassword),
+		errors.Is(err, app_error.ErrServiceInvalidTag):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, app_error.ErrServiceURLRejected):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,254 @@
+package grpcapi_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/grpcapi"
+	"github.com/oegegr/shortener/internal/middleware"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+	pb "github.com/oegegr/shortener/pkg/api/shortener"
+	"github.com/oegegr/shortener/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestClient(t *testing.T, urlService service.URLShortener, jwtParser service.JWTParser, apiKeys service.APIKeyAuthenticator, opts ...grpc.DialOption) pb.ShortenerClient {
+	return newLimitedTestClient(t, urlService, jwtParser, apiKeys, middleware.RateLimits{}, opts...)
+}
+
+// newLimitedTestClient возвращает клиент сервера с заданными ограничениями частоты запросов.
+func newLimitedTestClient(t *testing.T, urlService service.URLShortener, jwtParser service.JWTParser, apiKeys service.APIKeyAuthenticator, limits middleware.RateLimits, opts ...grpc.DialOption) pb.ShortenerClient {
+	logger := zaptest.NewLogger(t).Sugar()
+	repo := new(repository.MockURLRepository)
+	repo.On("Ping", mock.Anything).Return(nil)
+
+	listener := bufconn.Listen(1024 * 1024)
+	domains := service.NewDomainRegistry("http://localhost:8080", service.ShortDomain{BaseURL: "https://brand.link"})
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		middleware.AuthUnaryInterceptor(*logger, jwtParser, apiKeys),
+		middleware.ShortDomainUnaryInterceptor(domains),
+		middleware.RateLimitUnaryInterceptor(*logger, ratelimit.NewInMemoryStore(time.Minute), limits),
+	))
+	pb.RegisterShortenerServer(server, grpcapi.NewShortenerServer(
+		urlService,
+		&middleware.AuthContextUserIDPovider{},
+		repo,
+		new(service.MockLogAuditManager),
+	))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	opts = append(opts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewShortenerClient(conn)
+}
+
+// newTestAPIKeys возвращает сервис API-ключей с хранилищем в памяти.
+func newTestAPIKeys(t *testing.T) *service.APIKeyService {
+	logger := zaptest.NewLogger(t).Sugar()
+	repo, err := repository.NewInMemoryAPIKeyRepository("", *logger)
+	require.NoError(t, err)
+	return service.NewAPIKeyService(repo)
+}
+
+func TestShortenerServer_Shorten(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	jwtParser := service.NewJWTParser("secret", time.Hour, *logger)
+	urlService := new(service.MockURLService)
+	client := newTestClient(t, urlService, jwtParser, newTestAPIKeys(t))
+
+	token, err := jwtParser.CreateNewJWTToken("user")
+	require.NoError(t, err)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", token)
+
+	t.Run("Valid Shortening", func(t *testing.T) {
+		urlService.On("GetShortURL", mock.Anything, model.ShortenParams{URL: "https://google.com"}, "user").Return("abc123", nil).Once()
+
+		var header metadata.MD
+		resp, err := client.Shorten(ctx, &pb.ShortenRequest{Url: "https://google.com"}, grpc.Header(&header))
+
+		require.NoError(t, err)
+		assert.Equal(t, "abc123", resp.GetResult())
+		assert.False(t, resp.GetAlreadyExists())
+		assert.NotEmpty(t, header.Get("authorization"))
+	})
+
+	t.Run("Already Exists", func(t *testing.T) {
+		urlService.On("GetShortURL", mock.Anything, model.ShortenParams{URL: "https://yandex.ru"}, "user").Return("old", repository.ErrRepoURLAlreadyExists).Once()
+
+		resp, err := client.Shorten(ctx, &pb.ShortenRequest{Url: "https://yandex.ru"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "old", resp.GetResult())
+		assert.True(t, resp.GetAlreadyExists())
+	})
+
+	t.Run("Invalid URL", func(t *testing.T) {
+		_, err := client.Shorten(ctx, &pb.ShortenRequest{Url: "not a url"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Invalid Title", func(t *testing.T) {
+		urlService.On("GetShortURL", mock.Anything, model.ShortenParams{URL: "https://title.com"}, "user").Return("", app_error.ErrServiceInvalidTitle).Once()
+
+		_, err := client.Shorten(ctx, &pb.ShortenRequest{Url: "https://title.com"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Bad Token", func(t *testing.T) {
+		badCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "bad")
+		_, err := client.Shorten(badCtx, &pb.ShortenRequest{Url: "https://google.com"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func TestShortenerServer_Ping(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	jwtParser := service.NewJWTParser("secret", time.Hour, *logger)
+	client := newTestClient(t, new(service.MockURLService), jwtParser, newTestAPIKeys(t))
+
+	_, err := client.Ping(context.Background(), &pb.PingRequest{})
+	assert.NoError(t, err)
+}
+
+func TestShortenerServer_APIKey(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	jwtParser := service.NewJWTParser("secret", time.Hour, *logger)
+	urlService := new(service.MockURLService)
+	apiKeys := newTestAPIKeys(t)
+	client := newTestClient(t, urlService, jwtParser, apiKeys)
+
+	_, rawKey, err := apiKeys.CreateKey(context.Background(), "bot-owner", "ci", []model.APIKeyScope{model.APIKeyScopeShorten})
+	require.NoError(t, err)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+rawKey)
+
+	t.Run("Scoped Method", func(t *testing.T) {
+		urlService.On("GetShortURL", mock.Anything, model.ShortenParams{URL: "https://google.com"}, "bot-owner").Return("abc123", nil).Once()
+
+		var header metadata.MD
+		resp, err := client.Shorten(ctx, &pb.ShortenRequest{Url: "https://google.com"}, grpc.Header(&header))
+
+		require.NoError(t, err)
+		assert.Equal(t, "abc123", resp.GetResult())
+		// Вызовы с API-ключом не получают JWT-токен.
+		assert.Empty(t, header.Get("authorization"))
+	})
+
+	t.Run("Missing Scope", func(t *testing.T) {
+		_, err := client.DeleteUserURLs(ctx, &pb.DeleteUserURLsRequest{})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Unknown Key", func(t *testing.T) {
+		badCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer sk_unknown")
+		_, err := client.Shorten(badCtx, &pb.ShortenRequest{Url: "https://google.com"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func TestShortenerServer_BrandedLink(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	jwtParser := service.NewJWTParser("secret", time.Hour, *logger)
+	urlService := new(service.MockURLService)
+	client := newTestClient(t, urlService, jwtParser, newTestAPIKeys(t), grpc.WithAuthority("brand.link"))
+
+	token, err := jwtParser.CreateNewJWTToken("user")
+	require.NoError(t, err)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", token)
+
+	t.Run("Expand", func(t *testing.T) {
+		urlService.On("GetOriginalURL", mock.Anything, "abc@brand.link").Return("https://brand.example.com", nil).Once()
+
+		resp, err := client.Expand(ctx, &pb.ExpandRequest{ShortId: "abc"})
+		require.NoError(t, err)
+		assert.Equal(t, "https://brand.example.com", resp.GetOriginalUrl())
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		urlService.On("DeleteUserURL", mock.Anything, "user", []string{"abc@brand.link"}).Return("job", nil).Once()
+
+		resp, err := client.DeleteUserURLs(ctx, &pb.DeleteUserURLsRequest{ShortIds: []string{"abc"}})
+		require.NoError(t, err)
+		assert.Equal(t, "job", resp.GetJobId())
+	})
+
+	t.Run("Key Rejected", func(t *testing.T) {
+		_, err := client.Expand(ctx, &pb.ExpandRequest{ShortId: "abc@brand.link"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	urlService.AssertExpectations(t)
+}
+
+func TestShortenerServer_RateLimit(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	jwtParser := service.NewJWTParser("secret", time.Hour, *logger)
+	urlService := new(service.MockURLService)
+	client := newLimitedTestClient(t, urlService, jwtParser, newTestAPIKeys(t), middleware.RateLimits{
+		Shorten:      ratelimit.PerMinute(10, 10),
+		Batch:        ratelimit.PerMinute(3, 3),
+		BatchMaxSize: 3,
+		Redirect:     ratelimit.PerMinute(1, 1),
+	})
+
+	token, err := jwtParser.CreateNewJWTToken("user")
+	require.NoError(t, err)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", token)
+
+	batch := func(size int) *pb.ShortenBatchRequest {
+		req := &pb.ShortenBatchRequest{}
+		for range size {
+			req.Items = append(req.Items, &pb.BatchItem{CorrelationId: "id", OriginalUrl: "https://google.com"})
+		}
+		return req
+	}
+
+	t.Run("Batch Too Large", func(t *testing.T) {
+		_, err := client.ShortenBatch(ctx, batch(4))
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Batch Cost", func(t *testing.T) {
+		urlService.On("GetShortURLBatch", mock.Anything, mock.Anything, "user").Return([]string{"a", "b", "c"}, nil).Once()
+
+		_, err := client.ShortenBatch(ctx, batch(3))
+		require.NoError(t, err)
+
+		var header metadata.MD
+		_, err = client.ShortenBatch(ctx, batch(1), grpc.Header(&header))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.NotEmpty(t, header.Get("retry-after"))
+	})
+
+	t.Run("Expand", func(t *testing.T) {
+		urlService.On("GetOriginalURL", mock.Anything, "abc").Return("https://google.com", nil).Once()
+
+		_, err := client.Expand(ctx, &pb.ExpandRequest{ShortId: "abc"})
+		require.NoError(t, err)
+
+		_, err = client.Expand(ctx, &pb.ExpandRequest{ShortId: "abc"})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	urlService.AssertExpectations(t)
+}
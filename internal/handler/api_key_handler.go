@@ -0,0 +1,128 @@
+// Package handler содержит обработчик HTTP-запросов управления API-ключами.
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+)
+
+// APIKeyHandler обрабатывает HTTP-запросы управления API-ключами пользователя.
+type APIKeyHandler struct {
+	// apiKeys предоставляет сервис API-ключей.
+	apiKeys service.APIKeyManager
+	// userIDProvider предоставляет провайдер для получения идентификатора пользователя.
+	userIDProvider UserIDProvider
+}
+
+// NewAPIKeyHandler возвращает новый экземпляр APIKeyHandler.
+func NewAPIKeyHandler(apiKeys service.APIKeyManager, provider UserIDProvider) APIKeyHandler {
+	return APIKeyHandler{
+		apiKeys:        apiKeys,
+		userIDProvider: provider,
+	}
+}
+
+// APIUserCreateKey обрабатывает HTTP-запрос на создание API-ключа пользователя.
+// Значение ключа возвращается только в этом ответе.
+func (h *APIKeyHandler) APIUserCreateKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req model.CreateAPIKeyRequest
+
+	if r.Header.Get("Content-type") != "application/json" {
+		http.Error(w, "wrong content-type", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "failed to deserialize body", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := h.userIDProvider.Get(ctx)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	key, rawKey, err := h.apiKeys.CreateKey(ctx, userID, req.Name, req.Scopes)
+	if err != nil {
+		switch {
+		case errors.Is(err, app_error.ErrServiceInvalidAPIKeyName), errors.Is(err, app_error.ErrServiceInvalidAPIKeyScope):
+			writeJSONError(w, http.StatusBadRequest, err)
+		case errors.Is(err, app_error.ErrServiceTooManyAPIKeys):
+			writeJSONError(w, http.StatusConflict, err)
+		default:
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := model.NewAPIKeyResponse(*key)
+	resp.Key = rawKey
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APIUserKeys обрабатывает HTTP-запрос на получение API-ключей пользователя, включая отозванные.
+func (h *APIKeyHandler) APIUserKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := h.userIDProvider.Get(ctx)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	keys, err := h.apiKeys.ListKeys(ctx, userID)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]model.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resp = append(resp, model.NewAPIKeyResponse(key))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APIUserRevokeKey обрабатывает HTTP-запрос на отзыв API-ключа пользователя.
+func (h *APIKeyHandler) APIUserRevokeKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	keyID := chi.URLParam(r, "key_id")
+	if keyID == "" {
+		http.Error(w, "missing key id at params", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := h.userIDProvider.Get(ctx)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.apiKeys.RevokeKey(ctx, userID, keyID); err != nil {
+		if errors.Is(err, repository.ErrRepoNotFound) {
+			writeJSONError(w, http.StatusNotFound, err)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,137 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oegegr/shortener/internal/handler"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// createKeyRequest возвращает запрос на создание API-ключа с JSON-телом.
+func createKeyRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/user/keys", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// revokeKeyRequest возвращает запрос на отзыв API-ключа с параметром маршрута key_id.
+func revokeKeyRequest(keyID string) *http.Request {
+	req := httptest.NewRequest(http.MethodDelete, "/api/user/keys/"+keyID, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("key_id", keyID)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestAPIKeyHandler(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	repo, err := repository.NewInMemoryAPIKeyRepository("", *logger)
+	require.NoError(t, err)
+	apiKeys := service.NewAPIKeyService(repo)
+
+	userIDProvider := new(MockUserIDProvider)
+	userIDProvider.On("Get", mock.Anything).Return("user", nil)
+	app := handler.NewAPIKeyHandler(apiKeys, userIDProvider)
+
+	var created model.APIKeyResponse
+
+	t.Run("Create Key", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.APIUserCreateKey(w, createKeyRequest(`{"name":"ci","scopes":["read","shorten"]}`))
+
+		res := w.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Equal(t, "no-store", res.Header.Get("Cache-Control"))
+
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&created))
+		assert.Equal(t, "ci", created.Name)
+		assert.Equal(t, []model.APIKeyScope{model.APIKeyScopeShorten, model.APIKeyScopeRead}, created.Scopes)
+		assert.True(t, strings.HasPrefix(created.Key, model.APIKeyPrefix))
+		assert.True(t, strings.HasPrefix(created.Key, created.Prefix))
+	})
+
+	t.Run("Invalid Scope", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.APIUserCreateKey(w, createKeyRequest(`{"name":"ci","scopes":["admin"]}`))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Wrong Content Type", func(t *testing.T) {
+		req := createKeyRequest(`{"name":"ci","scopes":["read"]}`)
+		req.Header.Set("Content-Type", "text/plain")
+		w := httptest.NewRecorder()
+		app.APIUserCreateKey(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("List Keys Without Secret", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.APIUserKeys(w, httptest.NewRequest(http.MethodGet, "/api/user/keys", nil))
+
+		res := w.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var keys []model.APIKeyResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&keys))
+		require.Len(t, keys, 1)
+		assert.Equal(t, created.ID, keys[0].ID)
+		assert.Empty(t, keys[0].Key)
+		assert.Nil(t, keys[0].RevokedAt)
+	})
+
+	t.Run("Revoke Key", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.APIUserRevokeKey(w, revokeKeyRequest(created.ID))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+
+		w = httptest.NewRecorder()
+		app.APIUserRevokeKey(w, revokeKeyRequest(created.ID))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Missing Key ID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.APIUserRevokeKey(w, httptest.NewRequest(http.MethodDelete, "/api/user/keys/", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Too Many Keys", func(t *testing.T) {
+		bulk := new(MockUserIDProvider)
+		bulk.On("Get", mock.Anything).Return("bulk", nil)
+		app := handler.NewAPIKeyHandler(apiKeys, bulk)
+
+		for range 50 {
+			w := httptest.NewRecorder()
+			app.APIUserCreateKey(w, createKeyRequest(`{"name":"bot","scopes":["read"]}`))
+			require.Equal(t, http.StatusCreated, w.Code)
+		}
+
+		w := httptest.NewRecorder()
+		app.APIUserCreateKey(w, createKeyRequest(`{"name":"bot","scopes":["read"]}`))
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		anonymous := new(MockUserIDProvider)
+		anonymous.On("Get", mock.Anything).Return("", errors.New("no user"))
+		app := handler.NewAPIKeyHandler(apiKeys, anonymous)
+
+		w := httptest.NewRecorder()
+		app.APIUserKeys(w, httptest.NewRequest(http.MethodGet, "/api/user/keys", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
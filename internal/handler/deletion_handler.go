@@ -0,0 +1,78 @@
+// Package handler содержит обработчик HTTP-запросов состояния задач удаления.
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+)
+
+// DeletionHandler обрабатывает HTTP-запросы состояния задач удаления URL-адресов.
+type DeletionHandler struct {
+	// jobProvider предоставляет состояние задач удаления.
+	jobProvider service.DeletionJobProvider
+	// userIDProvider предоставляет провайдер для получения идентификатора пользователя.
+	userIDProvider UserIDProvider
+}
+
+// NewDeletionHandler возвращает новый экземпляр DeletionHandler.
+func NewDeletionHandler(jobProvider service.DeletionJobProvider, provider UserIDProvider) DeletionHandler {
+	return DeletionHandler{
+		jobProvider:    jobProvider,
+		userIDProvider: provider,
+	}
+}
+
+// APIUserDeletionJob обрабатывает HTTP-запрос на получение состояния задачи удаления URL-адресов пользователя.
+func (h *DeletionHandler) APIUserDeletionJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	jobID := chi.URLParam(r, "job_id")
+	if jobID == "" {
+		http.Error(w, "missing job id at params", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := h.userIDProvider.Get(ctx)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	job, err := h.jobProvider.GetDeletionJob(ctx, userID, jobID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRepoNotFound) {
+			writeJSONError(w, http.StatusNotFound, err)
+			return
+		}
+
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	results := job.Results
+	if results == nil {
+		results = []model.DeleteResult{}
+	}
+
+	resp := model.DeletionJobResponse{
+		JobID:     job.ID,
+		Status:    job.Status,
+		Total:     len(job.ShortIDs),
+		Processed: len(job.Results),
+		Attempts:  job.Attempts,
+		Results:   results,
+		Error:     job.Error,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
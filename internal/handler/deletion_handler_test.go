@@ -0,0 +1,122 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oegegr/shortener/internal/handler"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// deletionJobRequest возвращает запрос состояния задачи удаления с параметром маршрута job_id.
+func deletionJobRequest(jobID string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/user/urls/deletions/"+jobID, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("job_id", jobID)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestAPIUserDeletionJob(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	urlRepo, err := repository.NewInMemoryURLRepository("", repository.DefaultJournalConfig(), *logger)
+	require.NoError(t, err)
+	jobRepo, err := repository.NewInMemoryDeletionJobRepository("", *logger)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	require.NoError(t, jobRepo.CreateJob(ctx, *model.NewDeletionJob("pending", "user", []string{"a", "b"}, now)))
+	done := model.NewDeletionJob("done", "user", []string{"c"}, now)
+	require.NoError(t, jobRepo.CreateJob(ctx, *done))
+	done.Status = model.DeletionJobDone
+	done.Attempts = 1
+	done.Results = []model.DeleteResult{{ShortID: "c", Status: model.DeleteStatusDeleted}}
+	require.NoError(t, jobRepo.UpdateJob(ctx, *done))
+
+	// Рабочие потоки не запускаются, чтобы задачи оставались в сохраненном состоянии.
+	strategy := service.NewQueueURLDeletionStrategy(urlRepo, jobRepo, *logger, 0, 10, time.Hour, time.Second, time.Hour)
+	defer strategy.Stop()
+
+	userIDProvider := new(MockUserIDProvider)
+	userIDProvider.On("Get", mock.Anything).Return("user", nil)
+	app := handler.NewDeletionHandler(strategy, userIDProvider)
+
+	t.Run("Pending Job", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.APIUserDeletionJob(w, deletionJobRequest("pending"))
+
+		res := w.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+
+		var resp model.DeletionJobResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+		assert.Equal(t, "pending", resp.JobID)
+		assert.Equal(t, model.DeletionJobPending, resp.Status)
+		assert.Equal(t, 2, resp.Total)
+		assert.Equal(t, 0, resp.Processed)
+		assert.Equal(t, []model.DeleteResult{}, resp.Results)
+	})
+
+	t.Run("Done Job", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.APIUserDeletionJob(w, deletionJobRequest("done"))
+
+		res := w.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var resp model.DeletionJobResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+		assert.Equal(t, model.DeletionJobDone, resp.Status)
+		assert.Equal(t, 1, resp.Processed)
+		assert.Equal(t, 1, resp.Attempts)
+		assert.Equal(t, done.Results, resp.Results)
+	})
+
+	t.Run("Foreign Job", func(t *testing.T) {
+		stranger := new(MockUserIDProvider)
+		stranger.On("Get", mock.Anything).Return("stranger", nil)
+		app := handler.NewDeletionHandler(strategy, stranger)
+
+		w := httptest.NewRecorder()
+		app.APIUserDeletionJob(w, deletionJobRequest("done"))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.APIUserDeletionJob(w, deletionJobRequest("missing"))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("Missing Job ID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.APIUserDeletionJob(w, httptest.NewRequest(http.MethodGet, "/api/user/urls/deletions/", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		anonymous := new(MockUserIDProvider)
+		anonymous.On("Get", mock.Anything).Return("", errors.New("no user"))
+		app := handler.NewDeletionHandler(strategy, anonymous)
+
+		w := httptest.NewRecorder()
+		app.APIUserDeletionJob(w, deletionJobRequest("done"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,33 @@
+// Package handler содержит обработчик HTTP-запросов внутренней статистики сервиса.
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/oegegr/shortener/internal/service"
+)
+
+// InternalStatsHandler обрабатывает HTTP-запросы внутренней статистики сервиса.
+type InternalStatsHandler struct {
+	// statsProvider предоставляет сервис агрегированной статистики.
+	statsProvider service.InternalStatsProvider
+}
+
+// NewInternalStatsHandler возвращает новый экземпляр InternalStatsHandler.
+func NewInternalStatsHandler(statsProvider service.InternalStatsProvider) InternalStatsHandler {
+	return InternalStatsHandler{statsProvider: statsProvider}
+}
+
+// APIInternalStats обрабатывает HTTP-запрос на получение количества URL-адресов и пользователей.
+func (h *InternalStatsHandler) APIInternalStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.statsProvider.GetInternalStats(r.Context())
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,33 @@
+// Package handler содержит обработчик HTTP-запросов открытых ключей проверки JWT-токенов.
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/oegegr/shortener/internal/service"
+)
+
+// jwksCacheControl представляет заголовок Cache-Control набора ключей: короткое время кэширования,
+// чтобы новый ключ подписи становился известен клиентам вскоре после ротации.
+const jwksCacheControl = "public, max-age=300"
+
+// JWKSHandler обрабатывает HTTP-запросы открытых ключей проверки JWT-токенов.
+type JWKSHandler struct {
+	// keysProvider предоставляет открытые ключи проверки JWT-токенов.
+	keysProvider service.JWKSProvider
+}
+
+// NewJWKSHandler возвращает новый экземпляр JWKSHandler.
+func NewJWKSHandler(keysProvider service.JWKSProvider) JWKSHandler {
+	return JWKSHandler{keysProvider: keysProvider}
+}
+
+// JWKS обрабатывает HTTP-запрос на получение открытых ключей проверки JWT-токенов в формате JSON Web Key Set.
+// При подписи алгоритмом HS256 набор ключей пуст.
+func (h *JWKSHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", jwksCacheControl)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.keysProvider.JWKS())
+}
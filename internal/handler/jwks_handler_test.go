@@ -0,0 +1,72 @@
+package handler_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oegegr/shortener/internal/handler"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// requestJWKS выполняет запрос набора ключей и возвращает ответ и декодированный набор.
+func requestJWKS(t *testing.T, parser service.JWKSProvider) (*http.Response, model.JSONWebKeySet) {
+	app := handler.NewJWKSHandler(parser)
+	w := httptest.NewRecorder()
+	app.JWKS(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	res := w.Result()
+	t.Cleanup(func() { res.Body.Close() })
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var set model.JSONWebKeySet
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&set))
+	return res, set
+}
+
+func TestJWKSHandler(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+
+	t.Run("Asymmetric Key", func(t *testing.T) {
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(private)
+		require.NoError(t, err)
+		privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+		parser, err := service.NewAsymmetricJWTParser(privatePEM, nil, "legacy-secret", time.Hour, *logger)
+		require.NoError(t, err)
+
+		res, set := requestJWKS(t, &parser)
+		assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+		assert.Equal(t, "public, max-age=300", res.Header.Get("Cache-Control"))
+
+		// Секретный ключ HS256 не публикуется.
+		require.Len(t, set.Keys, 1)
+		key := set.Keys[0]
+		assert.Equal(t, "OKP", key.KeyType)
+		assert.Equal(t, "EdDSA", key.Algorithm)
+		assert.Equal(t, "Ed25519", key.Curve)
+		assert.Equal(t, "sig", key.Use)
+		assert.NotEmpty(t, key.KeyID)
+		assert.Equal(t, base64.RawURLEncoding.EncodeToString(public), key.X)
+	})
+
+	t.Run("Symmetric Key", func(t *testing.T) {
+		parser := service.NewJWTParser("secret", time.Hour, *logger)
+
+		_, set := requestJWKS(t, &parser)
+		assert.Empty(t, set.Keys)
+	})
+}
@@ -0,0 +1,48 @@
+// Package handler содержит форму ввода пароля защищенных сокращенных URL-адресов.
+package handler
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/oegegr/shortener/internal/model"
+)
+
+// maxUnlockFormSize представляет максимальный размер тела запроса с паролем в байтах.
+const maxUnlockFormSize = 4 << 10
+
+// passwordTemplate представляет шаблон формы ввода пароля.
+var passwordTemplate = template.Must(template.ParseFS(templatesFS, "templates/password.html"))
+
+// passwordPage представляет данные формы ввода пароля.
+type passwordPage struct {
+	// ShortID представляет сокращенный идентификатор URL-адреса.
+	ShortID string
+	// Action представляет путь, на который отправляется форма.
+	Action string
+	// Error представляет сообщение об ошибке предыдущей попытки; пустая строка, если ошибки не было.
+	Error string
+}
+
+// writePasswordPage записывает в ответ форму ввода пароля с заданным статусом.
+// Форма не содержит оригинальный URL-адрес, чтобы он не раскрывался до ввода пароля.
+func writePasswordPage(w http.ResponseWriter, statusCode int, shortID string, errMsg string) {
+	page := passwordPage{
+		ShortID: shortID,
+		Action:  "/" + shortID + model.UnlockPath,
+		Error:   errMsg,
+	}
+
+	var buf bytes.Buffer
+	if err := passwordTemplate.Execute(&buf, page); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	w.WriteHeader(statusCode)
+	w.Write(buf.Bytes())
+}
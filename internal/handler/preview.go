@@ -0,0 +1,60 @@
+// Package handler содержит страницу предпросмотра сокращенных URL-адресов.
+package handler
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"net/http"
+
+	"github.com/oegegr/shortener/internal/model"
+)
+
+// previewSuffix представляет суффикс сокращенного кода, запрашивающий страницу предпросмотра вместо перенаправления.
+const previewSuffix = "+"
+
+// previewDateLayout представляет формат даты создания URL-адреса на странице предпросмотра.
+const previewDateLayout = "2006-01-02 15:04 UTC"
+
+//go:embed templates/preview.html templates/password.html
+var templatesFS embed.FS
+
+// previewTemplate представляет шаблон страницы предпросмотра.
+var previewTemplate = template.Must(template.ParseFS(templatesFS, "templates/preview.html"))
+
+// previewPage представляет данные страницы предпросмотра.
+type previewPage struct {
+	// ShortID представляет сокращенный идентификатор URL-адреса.
+	ShortID string
+	// URL представляет оригинальный URL-адрес.
+	URL string
+	// Title представляет заголовок, заданный владельцем URL-адреса.
+	Title string
+	// CreatedAt представляет дату создания URL-адреса; пустая строка, если дата неизвестна.
+	CreatedAt string
+}
+
+// writePreviewPage записывает в ответ страницу предпросмотра URL-адреса.
+// Шаблон экранирует значения, а небезопасные схемы в ссылке заменяются, поэтому данные владельца не исполняются в браузере.
+func writePreviewPage(w http.ResponseWriter, item model.URLItem) {
+	page := previewPage{
+		ShortID: item.Code(),
+		URL:     item.URL,
+		Title:   item.Title,
+	}
+	if !item.CreatedAt.IsZero() {
+		page.CreatedAt = item.CreatedAt.UTC().Format(previewDateLayout)
+	}
+
+	var buf bytes.Buffer
+	if err := previewTemplate.Execute(&buf, page); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,131 @@
+// Package handler содержит обработчик HTTP-запросов QR-кодов сокращенных URL-адресов.
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/oegegr/shortener/pkg/qr"
+)
+
+// qrCacheControl представляет значение заголовка Cache-Control для изображений QR-кодов.
+// Изображение зависит только от сокращенного URL-адреса и параметров, но ссылка может быть удалена, поэтому срок ограничен.
+const qrCacheControl = "public, max-age=3600"
+
+// QRHandler обрабатывает HTTP-запросы QR-кодов сокращенных URL-адресов.
+type QRHandler struct {
+	// qrProvider предоставляет изображения QR-кодов.
+	qrProvider service.QRCodeProvider
+}
+
+// NewQRHandler возвращает новый экземпляр QRHandler.
+func NewQRHandler(qrProvider service.QRCodeProvider) QRHandler {
+	return QRHandler{qrProvider: qrProvider}
+}
+
+// QRCode обрабатывает HTTP-запрос на получение QR-кода сокращенного URL-адреса.
+// Параметры запроса size, level и format задают размер в пикселях, уровень коррекции ошибок (L, M, Q, H) и формат (png, svg).
+// Ответ содержит ETag, вычисленный по сокращенному URL-адресу и параметрам изображения;
+// при совпадении с If-None-Match возвращается 304 без построения изображения.
+func (h *QRHandler) QRCode(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	shortURL := chi.URLParam(r, "short_url")
+	if shortURL == "" {
+		http.Error(w, "missing short url at params", http.StatusBadRequest)
+		return
+	}
+	if !model.IsShortCode(shortURL) {
+		http.Error(w, "invalid short url", http.StatusBadRequest)
+		return
+	}
+
+	opts, err := parseQROptions(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	content, err := h.qrProvider.GetQRContent(ctx, model.ShortKey(service.DomainFromContext(ctx), shortURL))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRepoNotFound):
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		case errors.Is(err, app_error.ErrServiceURLGone), errors.Is(err, app_error.ErrServiceURLExpired):
+			http.Error(w, err.Error(), http.StatusGone)
+		default:
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	etag := qrETag(content, opts)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", qrCacheControl)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	data, err := qr.Encode(content, opts)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", opts.ContentType())
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// parseQROptions возвращает параметры изображения QR-кода из параметров запроса.
+func parseQROptions(r *http.Request) (qr.Options, error) {
+	opts := qr.DefaultOptions()
+	query := r.URL.Query()
+
+	if size := query.Get("size"); size != "" {
+		value, err := strconv.Atoi(size)
+		if err != nil {
+			return opts, qr.ErrInvalidSize
+		}
+		opts.Size = value
+	}
+	if level := query.Get("level"); level != "" {
+		opts.Level = strings.ToUpper(level)
+	}
+	if format := query.Get("format"); format != "" {
+		opts.Format = qr.Format(strings.ToLower(format))
+	}
+
+	return opts, opts.Validate()
+}
+
+// qrETag возвращает ETag изображения QR-кода.
+// Изображение однозначно определяется содержимым и параметрами, поэтому ETag вычисляется без его построения.
+func qrETag(content string, opts qr.Options) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%s", content, opts.Format, opts.Size, opts.Level)))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches возвращает true, если значение заголовка If-None-Match содержит заданный ETag или "*".
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
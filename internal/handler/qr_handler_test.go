@@ -0,0 +1,97 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/handler"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/oegegr/shortener/pkg/qr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// qrRequest возвращает запрос QR-кода с параметром маршрута short_url.
+func qrRequest(shortURL string, query string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/"+shortURL+"/qr"+query, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("short_url", shortURL)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestQRCode(t *testing.T) {
+	svc := new(service.MockURLService)
+	app := handler.NewQRHandler(svc)
+
+	svgOpts := qr.Options{Format: qr.FormatSVG, Size: 512, Level: "H"}
+	svc.On("GetQRContent", mock.Anything, "abc").Return("https://short.com/abc", nil)
+	svc.On("GetQRContent", mock.Anything, "missing").Return("", repository.ErrRepoNotFound)
+	svc.On("GetQRContent", mock.Anything, "deleted").Return("", app_error.ErrServiceURLGone)
+
+	t.Run("Render With Options", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.QRCode(w, qrRequest("abc", "?size=512&level=h&format=SVG"))
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "image/svg+xml", res.Header.Get("Content-Type"))
+		assert.NotEmpty(t, res.Header.Get("ETag"))
+		expected, err := qr.Encode("https://short.com/abc", svgOpts)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, w.Body.Bytes())
+	})
+
+	t.Run("ETag Depends On Options", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.QRCode(w, qrRequest("abc", "?format=svg"))
+		svgETag := w.Result().Header.Get("ETag")
+
+		w = httptest.NewRecorder()
+		app.QRCode(w, qrRequest("abc", ""))
+		assert.NotEqual(t, svgETag, w.Result().Header.Get("ETag"))
+	})
+
+	t.Run("Not Modified", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.QRCode(w, qrRequest("abc", "?size=512&level=H&format=svg"))
+		etag := w.Result().Header.Get("ETag")
+
+		req := qrRequest("abc", "?size=512&level=H&format=svg")
+		req.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		app.QRCode(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotModified, res.StatusCode)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("Invalid Options", func(t *testing.T) {
+		for _, query := range []string{"?size=abc", "?size=5", "?level=Z", "?format=gif"} {
+			w := httptest.NewRecorder()
+			app.QRCode(w, qrRequest("abc", query))
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.QRCode(w, qrRequest("missing", ""))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Gone", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.QRCode(w, qrRequest("deleted", ""))
+		assert.Equal(t, http.StatusGone, w.Code)
+	})
+}
@@ -5,21 +5,37 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/metrics"
 	"github.com/oegegr/shortener/internal/model"
 	"github.com/oegegr/shortener/internal/repository"
 	"github.com/oegegr/shortener/internal/service"
+	pkghttp "github.com/oegegr/shortener/pkg/http"
 )
 
 const (
 	shortenFailure = "failed to get short url"
 )
 
+// domainQueryParam представляет параметр запроса с доменом сокращенного URL-адреса пользователя.
+const domainQueryParam = "domain"
+
+// Ограничения размера страницы URL-адресов пользователя.
+const (
+	// maxUserURLLimit представляет максимальный размер страницы.
+	maxUserURLLimit = 1000
+)
+
 // UserIDProvider провайдер предоставляет доступ к индентификатору пользователя
 type UserIDProvider interface {
 	// Get возвращает идентификатор пользователя из контекста запроса.
@@ -34,6 +50,8 @@ type ShortenerHandler struct {
 	userIDProvider UserIDProvider
 	// logAudit предоставляет менеджер для аудита логов.
 	logAudit service.LogAuditManager
+	// clickTracker предоставляет запись переходов по сокращенным URL-адресам.
+	clickTracker service.ClickTracker
 }
 
 // NewShortenerHandler возвращает новый экземпляр ShortenerHandler.
@@ -41,40 +59,123 @@ func NewShortenerHandler(
 	service service.URLShortener,
 	provider UserIDProvider,
 	logAudit service.LogAuditManager,
+	clickTracker service.ClickTracker,
 ) ShortenerHandler {
 	return ShortenerHandler{
 		URLService:     service,
 		userIDProvider: provider,
 		logAudit:       logAudit,
+		clickTracker:   clickTracker,
 	}
 }
 
 // RedirectToOriginalURL обрабатывает HTTP-запрос на перенаправление на оригинальный URL-адрес.
+// Если код оканчивается на "+" или у URL-адреса включен предпросмотр, вместо перенаправления показывается страница предпросмотра.
+// Для защищенных паролем URL-адресов всегда показывается форма ввода пароля.
 func (app *ShortenerHandler) RedirectToOriginalURL(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	userID, _ := app.userIDProvider.Get(ctx)
 
-	shortURL := chi.URLParam(r, "short_url")
+	shortURL, forcePreview := strings.CutSuffix(chi.URLParam(r, "short_url"), previewSuffix)
 	if shortURL == "" {
 		http.Error(w, "missing short url at params", http.StatusBadRequest)
 		return
 	}
+	if !model.IsShortCode(shortURL) {
+		http.Error(w, "invalid short url", http.StatusBadRequest)
+		return
+	}
+	shortKey := model.ShortKey(service.DomainFromContext(ctx), shortURL)
 
-	originalURL, err := app.URLService.GetOriginalURL(ctx, shortURL)
+	urlItem, err := app.URLService.GetURLItem(ctx, shortKey)
 	if err != nil {
 
-		if errors.Is(err, app_error.ErrServiceURLGone) {
+		if errors.Is(err, app_error.ErrServiceURLGone) || errors.Is(err, app_error.ErrServiceURLExpired) {
+			metrics.RedirectsTotal.WithLabelValues(metrics.RedirectResultGone).Inc()
 			http.Error(w, err.Error(), http.StatusGone)
 			return
 		}
 
+		if errors.Is(err, repository.ErrRepoNotFound) {
+			metrics.RedirectsTotal.WithLabelValues(metrics.RedirectResultMiss).Inc()
+		} else {
+			metrics.RedirectsTotal.WithLabelValues(metrics.RedirectResultError).Inc()
+		}
+
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
+	if urlItem.IsProtected() {
+		metrics.RedirectsTotal.WithLabelValues(metrics.RedirectResultProtected).Inc()
+		writePasswordPage(w, http.StatusUnauthorized, shortURL, "")
+		return
+	}
+
+	if forcePreview || urlItem.Preview {
+		metrics.RedirectsTotal.WithLabelValues(metrics.RedirectResultPreview).Inc()
+		writePreviewPage(w, *urlItem)
+		return
+	}
+
+	metrics.RedirectsTotal.WithLabelValues(metrics.RedirectResultHit).Inc()
+
+	app.logAudit.NotifyAllAuditors(ctx, *model.NewLogAuditItem(urlItem.URL, userID, model.LogActionFollow))
+	app.clickTracker.Track(ctx, shortKey, r.Referer(), r.UserAgent(), pkghttp.ClientIP(r))
+	http.Redirect(w, r, urlItem.URL, http.StatusTemporaryRedirect)
+}
+
+// UnlockURL обрабатывает отправку формы ввода пароля защищенного URL-адреса.
+// При верном пароле выполняется перенаправление на оригинальный URL-адрес, при неверном форма показывается повторно.
+// Неудачные попытки учитываются по сокращенному URL-адресу и IP-адресу клиента из pkghttp.ClientIP.
+func (app *ShortenerHandler) UnlockURL(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, _ := app.userIDProvider.Get(ctx)
+
+	shortURL := chi.URLParam(r, "short_url")
+	if shortURL == "" {
+		http.Error(w, "missing short url at params", http.StatusBadRequest)
+		return
+	}
+	if !model.IsShortCode(shortURL) {
+		http.Error(w, "invalid short url", http.StatusBadRequest)
+		return
+	}
+	shortKey := model.ShortKey(service.DomainFromContext(ctx), shortURL)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUnlockFormSize)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	clientIP := pkghttp.ClientIP(r)
+	originalURL, err := app.URLService.UnlockURL(ctx, shortKey, r.PostFormValue("password"), clientIP)
+	if err != nil {
+		var lockoutErr *service.LockoutError
+		switch {
+		case errors.As(err, &lockoutErr):
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockoutErr.RetryAfter.Seconds()))))
+			writePasswordPage(w, http.StatusTooManyRequests, shortURL, "Too many failed attempts. Try again later.")
+		case errors.Is(err, app_error.ErrServiceWrongPassword):
+			writePasswordPage(w, http.StatusForbidden, shortURL, "Wrong password.")
+		case errors.Is(err, app_error.ErrServiceURLGone) || errors.Is(err, app_error.ErrServiceURLExpired):
+			http.Error(w, err.Error(), http.StatusGone)
+		case errors.Is(err, repository.ErrRepoNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	metrics.RedirectsTotal.WithLabelValues(metrics.RedirectResultHit).Inc()
+
 	app.logAudit.NotifyAllAuditors(ctx, *model.NewLogAuditItem(originalURL, userID, model.LogActionFollow))
-	http.Redirect(w, r, originalURL, http.StatusTemporaryRedirect)
+	app.clickTracker.Track(ctx, shortKey, r.Referer(), r.UserAgent(), clientIP)
+	http.Redirect(w, r, originalURL, http.StatusSeeOther)
 }
 
 // ShortenURL обрабатывает HTTP-запрос на сокращение URL-адреса.
@@ -101,8 +202,11 @@ func (app *ShortenerHandler) ShortenURL(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	shortURL, err := app.URLService.GetShortURL(ctx, url, userID)
+	shortURL, err := app.URLService.GetShortURL(ctx, model.ShortenParams{URL: url}, userID)
 	if err != nil {
+		if writeURLPolicyError(w, err) {
+			return
+		}
 
 		if errors.Is(err, repository.ErrRepoURLAlreadyExists) {
 			w.WriteHeader(http.StatusConflict)
@@ -122,16 +226,27 @@ func (app *ShortenerHandler) ShortenURL(w http.ResponseWriter, r *http.Request)
 }
 
 // APIUserURL обрабатывает HTTP-запрос на получение URL-адресов пользователя.
+// URL-адреса возвращаются постранично, если задан параметр limit; без него возвращается полный список, как до появления страниц.
+// Параметры limit и cursor задают страницу, sort — порядок по времени создания
+// ("-created_at" по умолчанию или "created_at"), search — подстроку оригинального URL-адреса, tag — метку,
+// include_deleted — включение удаленных URL-адресов.
+// Ссылка на следующую страницу передается в заголовке Link.
 func (app *ShortenerHandler) APIUserURL(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	query, err := parseUserURLQuery(r.URL.Query())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	userID, err := app.userIDProvider.Get(ctx)
 	if err != nil {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
-	urlItems, err := app.URLService.GetUserURL(ctx, userID)
+	urlItems, next, err := app.URLService.GetUserURLPage(ctx, userID, query)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -143,6 +258,12 @@ func (app *ShortenerHandler) APIUserURL(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if next != "" {
+		nextQuery := r.URL.Query()
+		nextQuery.Set("cursor", next)
+		w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, nextQuery.Encode()))
+	}
+
 	resp := model.UserURLResponse(urlItems)
 
 	w.Header().Set("Content-Type", "application/json")
@@ -150,6 +271,241 @@ func (app *ShortenerHandler) APIUserURL(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(resp)
 }
 
+// parseUserURLQuery возвращает параметры выборки страницы URL-адресов пользователя из параметров запроса.
+func parseUserURLQuery(values url.Values) (model.UserURLQuery, error) {
+	query := model.UserURLQuery{
+		Order:  model.SortDesc,
+		Search: values.Get("search"),
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxUserURLLimit {
+			return query, fmt.Errorf("invalid limit: must be between 1 and %d", maxUserURLLimit)
+		}
+		query.Limit = n
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		parsed, err := model.ParseURLCursor(cursor)
+		if err != nil {
+			return query, err
+		}
+		query.Cursor = parsed
+	}
+
+	switch values.Get("sort") {
+	case "", "-created_at":
+	case "created_at":
+		query.Order = model.SortAsc
+	default:
+		return query, errors.New(`invalid sort: must be "created_at" or "-created_at"`)
+	}
+
+	if tag := values.Get("tag"); tag != "" {
+		normalized, err := service.NormalizeTag(tag)
+		if err != nil {
+			return query, err
+		}
+		query.Tag = normalized
+	}
+
+	if includeDeleted := values.Get("include_deleted"); includeDeleted != "" {
+		b, err := strconv.ParseBool(includeDeleted)
+		if err != nil {
+			return query, errors.New("invalid include_deleted: must be a boolean")
+		}
+		query.IncludeDeleted = b
+	}
+
+	return query, nil
+}
+
+// APIUserUpdateURL обрабатывает HTTP-запрос на изменение оригинального URL-адреса сокращенного URL-адреса пользователя.
+func (app *ShortenerHandler) APIUserUpdateURL(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req model.UpdateURLRequest
+
+	shortID := chi.URLParam(r, "short_id")
+	if shortID == "" {
+		http.Error(w, "missing short id at params", http.StatusBadRequest)
+		return
+	}
+	shortKey, ok := userShortKey(r, shortID)
+	if !ok {
+		http.Error(w, "invalid short id", http.StatusBadRequest)
+		return
+	}
+
+	if r.Header.Get("Content-type") != "application/json" {
+		http.Error(w, "wrong content-type", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "failed to deserialize body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateURL(req.URL); err != nil {
+		http.Error(w, "invalid url", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := app.userIDProvider.Get(ctx)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	shortURL, edit, err := app.URLService.UpdateURL(ctx, userID, shortKey, req.URL)
+	if err != nil {
+		if writeURLPolicyError(w, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, repository.ErrRepoNotFound):
+			writeJSONError(w, http.StatusNotFound, err)
+		case errors.Is(err, app_error.ErrServiceURLNotOwned):
+			writeJSONError(w, http.StatusForbidden, err)
+		case errors.Is(err, app_error.ErrServiceURLExpired):
+			writeJSONError(w, http.StatusGone, err)
+		case errors.Is(err, repository.ErrRepoURLAlreadyExists):
+			writeJSONError(w, http.StatusConflict, err)
+		default:
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	app.logAudit.NotifyAllAuditors(ctx, *model.NewLogAuditEditItem(*edit))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(model.UserURL{ShortURL: shortURL, URL: req.URL})
+}
+
+// APIUserURLHistory обрабатывает HTTP-запрос на получение истории изменений сокращенного URL-адреса пользователя.
+func (app *ShortenerHandler) APIUserURLHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	shortID := chi.URLParam(r, "short_id")
+	if shortID == "" {
+		http.Error(w, "missing short id at params", http.StatusBadRequest)
+		return
+	}
+	shortKey, ok := userShortKey(r, shortID)
+	if !ok {
+		http.Error(w, "invalid short id", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := app.userIDProvider.Get(ctx)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	edits, err := app.URLService.GetURLEdits(ctx, userID, shortKey)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRepoNotFound):
+			writeJSONError(w, http.StatusNotFound, err)
+		case errors.Is(err, app_error.ErrServiceURLNotOwned):
+			writeJSONError(w, http.StatusForbidden, err)
+		default:
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if edits == nil {
+		edits = []model.URLEdit{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(edits)
+}
+
+// APIUserSetTags обрабатывает HTTP-запрос на замену меток сокращенного URL-адреса пользователя.
+// В ответе возвращаются метки после нормализации.
+func (app *ShortenerHandler) APIUserSetTags(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req model.SetTagsRequest
+
+	shortID := chi.URLParam(r, "short_id")
+	if shortID == "" {
+		http.Error(w, "missing short id at params", http.StatusBadRequest)
+		return
+	}
+	shortKey, ok := userShortKey(r, shortID)
+	if !ok {
+		http.Error(w, "invalid short id", http.StatusBadRequest)
+		return
+	}
+
+	if r.Header.Get("Content-type") != "application/json" {
+		http.Error(w, "wrong content-type", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "failed to deserialize body", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := app.userIDProvider.Get(ctx)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	tags, err := app.URLService.SetURLTags(ctx, userID, shortKey, req.Tags)
+	if err != nil {
+		switch {
+		case errors.Is(err, app_error.ErrServiceInvalidTag):
+			writeJSONError(w, http.StatusBadRequest, err)
+		case errors.Is(err, repository.ErrRepoNotFound):
+			writeJSONError(w, http.StatusNotFound, err)
+		case errors.Is(err, app_error.ErrServiceURLNotOwned):
+			writeJSONError(w, http.StatusForbidden, err)
+		default:
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(model.SetTagsRequest{Tags: tags})
+}
+
+// APIUserTags обрабатывает HTTP-запрос на получение меток пользователя с количеством URL-адресов.
+func (app *ShortenerHandler) APIUserTags(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := app.userIDProvider.Get(ctx)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	counts, err := app.URLService.GetUserTags(ctx, userID)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if counts == nil {
+		counts = []model.TagCount{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(counts)
+}
+
 // APIUserBatchDeleteURL обрабатывает HTTP-запрос на удаление URL-адресов пользователя в пакетном режиме.
 func (app *ShortenerHandler) APIUserBatchDeleteURL(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -164,24 +520,32 @@ func (app *ShortenerHandler) APIUserBatchDeleteURL(w http.ResponseWriter, r *htt
 		return
 	}
 
+	shortKeys := make([]string, 0, len(req))
+	for _, shortID := range req {
+		shortKey, ok := userShortKey(r, shortID)
+		if !ok {
+			http.Error(w, "invalid short id", http.StatusBadRequest)
+			return
+		}
+		shortKeys = append(shortKeys, shortKey)
+	}
+
 	userID, err := app.userIDProvider.Get(ctx)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	err = app.URLService.DeleteUserURL(ctx, userID, req)
+	jobID, err := app.URLService.DeleteUserURL(ctx, userID, shortKeys)
 	if err != nil {
-		if errors.Is(err, service.ErrDeleteQueueIsFull) {
-			http.Error(w, err.Error(), http.StatusTooManyRequests)
-			return
-		}
-
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/user/urls/deletions/"+jobID)
 	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(model.ShortenBatchDeleteResponse{JobID: jobID})
 }
 
 // APIShortenBatchURL обрабатывает HTTP-запрос на сокращение URL-адресов в пакетном режиме.
@@ -198,14 +562,31 @@ func (app *ShortenerHandler) APIShortenBatchURL(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	urls := []string{}
+	params := []model.ShortenParams{}
+	now := time.Now()
 	for _, item := range req {
 		err := validateURL(item.URL)
 		if err != nil {
 			http.Error(w, "invalid url", http.StatusBadRequest)
 			return
 		}
-		urls = append(urls, item.URL)
+
+		expiresAt, err := model.ResolveExpiration(item.TTLSeconds, item.ExpiresAt, now)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		params = append(params, model.ShortenParams{
+			URL:       item.URL,
+			Alias:     item.Alias,
+			ExpiresAt: expiresAt,
+			Title:     item.Title,
+			Preview:   item.Preview,
+			Password:  item.Password,
+			Tags:      item.Tags,
+			Domain:    item.Domain,
+		})
 	}
 
 	userID, err := app.userIDProvider.Get(ctx)
@@ -214,9 +595,25 @@ func (app *ShortenerHandler) APIShortenBatchURL(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	shortURLs, err := app.URLService.GetShortURLBatch(ctx, urls, userID)
+	shortURLs, err := app.URLService.GetShortURLBatch(ctx, params, userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		if writeURLPolicyError(w, err) {
+			return
+		}
+		if writeDomainError(w, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrRepoAliasAlreadyExists) ||
+			errors.Is(err, app_error.ErrServiceURLAlreadyShortened) {
+			writeJSONError(w, http.StatusConflict, err)
+			return
+		}
+		if isShortenParamsError(err) {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		writeJSONError(w, http.StatusBadRequest, errors.New(shortenFailure))
 		return
 	}
 
@@ -226,6 +623,9 @@ func (app *ShortenerHandler) APIShortenBatchURL(w http.ResponseWriter, r *http.R
 			CorrelationID: req[idx].CorrelationID,
 			Result:        shortURL,
 		}
+		if req[idx].QR {
+			item.QR = shortURL + model.QRCodePath
+		}
 		resp = append(resp, item)
 	}
 
@@ -256,19 +656,53 @@ func (app *ShortenerHandler) APIShortenURL(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	expiresAt, err := model.ResolveExpiration(req.TTLSeconds, req.ExpiresAt, time.Now())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	userID, err := app.userIDProvider.Get(ctx)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	shortURL, err := app.URLService.GetShortURL(ctx, req.URL, userID)
+	params := model.ShortenParams{
+		URL:       req.URL,
+		Alias:     req.Alias,
+		ExpiresAt: expiresAt,
+		Title:     req.Title,
+		Preview:   req.Preview,
+		Password:  req.Password,
+		Tags:      req.Tags,
+		Domain:    req.Domain,
+	}
+	shortURL, err := app.URLService.GetShortURL(ctx, params, userID)
 	if err != nil {
+		if writeURLPolicyError(w, err) {
+			return
+		}
+
+		if writeDomainError(w, err) {
+			return
+		}
 
 		if errors.Is(err, repository.ErrRepoURLAlreadyExists) {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusConflict)
-			json.NewEncoder(w).Encode(model.ShortenResponse{Result: shortURL})
+			json.NewEncoder(w).Encode(newShortenResponse(shortURL, req.QR))
+			return
+		}
+
+		if errors.Is(err, repository.ErrRepoAliasAlreadyExists) ||
+			errors.Is(err, app_error.ErrServiceURLAlreadyShortened) {
+			writeJSONError(w, http.StatusConflict, err)
+			return
+		}
+
+		if isShortenParamsError(err) {
+			writeJSONError(w, http.StatusBadRequest, err)
 			return
 		}
 
@@ -279,8 +713,79 @@ func (app *ShortenerHandler) APIShortenURL(w http.ResponseWriter, r *http.Reques
 	app.logAudit.NotifyAllAuditors(ctx, *model.NewLogAuditItem(req.URL, userID, model.LogActionShorten))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(model.ShortenResponse{Result: shortURL})
+	json.NewEncoder(w).Encode(newShortenResponse(shortURL, req.QR))
+
+}
+
+// isShortenParamsError проверяет, является ли err ошибкой недопустимых параметров сокращения URL-адреса.
+func isShortenParamsError(err error) bool {
+	return errors.Is(err, app_error.ErrServiceInvalidAlias) ||
+		errors.Is(err, app_error.ErrServiceInvalidTitle) ||
+		errors.Is(err, app_error.ErrServiceInvalidPassword) ||
+		errors.Is(err, app_error.ErrServiceInvalidTag)
+}
+
+// newShortenResponse возвращает ответ на запрос на сокращение URL-адреса со ссылкой на QR-код, если она запрошена.
+func newShortenResponse(shortURL string, withQR bool) model.ShortenResponse {
+	resp := model.ShortenResponse{Result: shortURL}
+	if withQR {
+		resp.QR = shortURL + model.QRCodePath
+	}
+	return resp
+}
+
+// writeJSONError записывает в ответ ошибку в формате model.ErrorResponse с заданным статусом.
+func writeJSONError(w http.ResponseWriter, statusCode int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(model.ErrorResponse{Error: err.Error()})
+}
 
+// writeURLPolicyError записывает в ответ ошибку проверки URL-адреса в формате model.URLPolicyErrorResponse,
+// если err является ошибкой политики, и возвращает true в этом случае.
+func writeURLPolicyError(w http.ResponseWriter, err error) bool {
+	var policyErr *service.URLPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(model.URLPolicyErrorResponse{
+		Error: policyErr.Error(),
+		Rule:  policyErr.Rule,
+		URL:   policyErr.URL,
+	})
+	return true
+}
+
+// writeDomainError записывает в ответ ошибку выбора домена сокращенного URL-адреса в формате model.ErrorResponse,
+// если err является такой ошибкой, и возвращает true в этом случае.
+func writeDomainError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, app_error.ErrServiceUnknownDomain):
+		writeJSONError(w, http.StatusBadRequest, err)
+	case errors.Is(err, app_error.ErrServiceDomainNotAllowed):
+		writeJSONError(w, http.StatusForbidden, err)
+	default:
+		return false
+	}
+	return true
+}
+
+// userShortKey возвращает ключ сокращенного URL-адреса пользователя по коду из запроса к API.
+// Домен берется из параметра запроса domain, а если он не задан — из хоста запроса, как при переходе по ссылке.
+// Эта функция возвращает false, если значение не является кодом.
+func userShortKey(r *http.Request, code string) (string, bool) {
+	if !model.IsShortCode(code) {
+		return "", false
+	}
+
+	domain := strings.ToLower(strings.TrimSpace(r.URL.Query().Get(domainQueryParam)))
+	if domain == "" {
+		domain = service.DomainFromContext(r.Context())
+	}
+	return model.ShortKey(domain, code), true
 }
 
 // validateURL проверяет корректность URL-адреса.
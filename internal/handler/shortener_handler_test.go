@@ -10,9 +10,13 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	app_error "github.com/oegegr/shortener/internal/error"
 	"github.com/oegegr/shortener/internal/handler"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
 	"github.com/oegegr/shortener/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -29,9 +33,10 @@ func (m *MockUserIDProvider) Get(ctx context.Context) (string, error) {
 
 func TestRedirectToOriginalUrl(t *testing.T) {
 	logAudit := new(service.MockLogAuditManager)
+	clickTracker := new(service.MockClickTracker)
 	service := new(service.MockURLService)
 	userIDProvider := new(MockUserIDProvider)
-	app := handler.NewShortenerHandler(service, userIDProvider, logAudit)
+	app := handler.NewShortenerHandler(service, userIDProvider, logAudit, clickTracker)
 
 	userIDProvider.On("Get", mock.Anything).Return("user", nil)
 
@@ -54,7 +59,7 @@ func TestRedirectToOriginalUrl(t *testing.T) {
 	})
 
 	t.Run("Service Error", func(t *testing.T) {
-		service.On("GetOriginalURL", "abc").Return("", errors.New("error"))
+		service.On("GetURLItem", "abc").Return(nil, errors.New("error"))
 
 		req := httptest.NewRequest(http.MethodGet, "/abc", nil)
 		w := httptest.NewRecorder()
@@ -67,7 +72,7 @@ func TestRedirectToOriginalUrl(t *testing.T) {
 
 	t.Run("Valid Redirect", func(t *testing.T) {
 
-		service.On("GetOriginalURL", mock.Anything, "xyz").Return("https://google.com", nil)
+		service.On("GetURLItem", mock.Anything, "xyz").Return(&model.URLItem{ShortID: "xyz", URL: "https://google.com"}, nil)
 
 		req := httptest.NewRequest(http.MethodGet, "/xyz", nil)
 
@@ -85,11 +90,197 @@ func TestRedirectToOriginalUrl(t *testing.T) {
 	})
 }
 
+func TestRedirectToOriginalUrl_Expired(t *testing.T) {
+	logAudit := new(service.MockLogAuditManager)
+	clickTracker := new(service.MockClickTracker)
+	svc := new(service.MockURLService)
+	userIDProvider := new(MockUserIDProvider)
+	app := handler.NewShortenerHandler(svc, userIDProvider, logAudit, clickTracker)
+
+	userIDProvider.On("Get", mock.Anything).Return("user", nil)
+	svc.On("GetURLItem", mock.Anything, "old").Return(nil, app_error.ErrServiceURLExpired)
+
+	req := httptest.NewRequest(http.MethodGet, "/old", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("short_url", "old")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	w := httptest.NewRecorder()
+	app.RedirectToOriginalURL(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusGone, res.StatusCode)
+}
+
+func TestRedirectToOriginalUrl_Domain(t *testing.T) {
+	logAudit := new(service.MockLogAuditManager)
+	clickTracker := new(service.MockClickTracker)
+	svc := new(service.MockURLService)
+	userIDProvider := new(MockUserIDProvider)
+	app := handler.NewShortenerHandler(svc, userIDProvider, logAudit, clickTracker)
+
+	userIDProvider.On("Get", mock.Anything).Return("user", nil)
+	svc.On("GetURLItem", mock.Anything, "abc@brand.link").Return(&model.URLItem{
+		ShortID: "abc@brand.link",
+		Domain:  "brand.link",
+		URL:     "https://brand.example.com",
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("short_url", "abc")
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	req = req.WithContext(service.ContextWithDomain(ctx, "brand.link"))
+
+	w := httptest.NewRecorder()
+	app.RedirectToOriginalURL(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
+	assert.Equal(t, "https://brand.example.com", res.Header.Get("Location"))
+	svc.AssertExpectations(t)
+
+	// Ключ другого домена в пути не открывает его ссылку через домен по умолчанию.
+	req = httptest.NewRequest(http.MethodGet, "/abc@brand.link", nil)
+	rctx = chi.NewRouteContext()
+	rctx.URLParams.Add("short_url", "abc@brand.link")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	w = httptest.NewRecorder()
+	app.RedirectToOriginalURL(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	svc.AssertNumberOfCalls(t, "GetURLItem", 1)
+}
+
+func TestRedirectToOriginalUrl_Preview(t *testing.T) {
+	logAudit := new(service.MockLogAuditManager)
+	clickTracker := new(service.MockClickTracker)
+	svc := new(service.MockURLService)
+	userIDProvider := new(MockUserIDProvider)
+	app := handler.NewShortenerHandler(svc, userIDProvider, logAudit, clickTracker)
+
+	createdAt := time.Date(2025, 3, 14, 9, 26, 0, 0, time.UTC)
+	userIDProvider.On("Get", mock.Anything).Return("user", nil)
+	svc.On("GetURLItem", mock.Anything, "plain").Return(&model.URLItem{
+		ShortID: "plain", URL: "https://example.com/?a=1&b=2", Title: "<b>Docs</b>", CreatedAt: createdAt,
+	}, nil)
+	svc.On("GetURLItem", mock.Anything, "flagged").Return(&model.URLItem{
+		ShortID: "flagged", URL: "javascript:alert(1)", Preview: true,
+	}, nil)
+
+	send := func(code string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, "/"+code, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("short_url", code)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		app.RedirectToOriginalURL(w, req)
+		return w.Result()
+	}
+
+	t.Run("Plus Suffix", func(t *testing.T) {
+		res := send("plain+")
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/html; charset=utf-8", res.Header.Get("Content-Type"))
+		assert.Contains(t, string(body), "https://example.com/?a=1&amp;b=2")
+		assert.Contains(t, string(body), "&lt;b&gt;Docs&lt;/b&gt;")
+		assert.Contains(t, string(body), "2025-03-14 09:26 UTC")
+		clickTracker.AssertNotCalled(t, "Track", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Preview Flag", func(t *testing.T) {
+		res := send("flagged")
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.NotContains(t, string(body), `href="javascript:`)
+		assert.NotContains(t, string(body), "Created")
+	})
+}
+
+func TestRedirectToOriginalUrl_PasswordProtected(t *testing.T) {
+	logAudit := new(service.MockLogAuditManager)
+	clickTracker := new(service.MockClickTracker)
+	svc := new(service.MockURLService)
+	userIDProvider := new(MockUserIDProvider)
+	app := handler.NewShortenerHandler(svc, userIDProvider, logAudit, clickTracker)
+
+	userIDProvider.On("Get", mock.Anything).Return("user", nil)
+	logAudit.On("NotifyAllAuditors", mock.Anything, mock.Anything).Return()
+	svc.On("GetURLItem", mock.Anything, "locked").Return(&model.URLItem{
+		ShortID: "locked", URL: "https://secret.example.com", PasswordHash: "hash", Preview: true,
+	}, nil)
+
+	withRoute := func(req *http.Request, code string) *http.Request {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("short_url", code)
+		return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	}
+	unlock := func(password string) *http.Response {
+		form := strings.NewReader("password=" + password)
+		req := withRoute(httptest.NewRequest(http.MethodPost, "/locked"+model.UnlockPath, form), "locked")
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		app.UnlockURL(w, req)
+		return w.Result()
+	}
+
+	t.Run("Form Hides Destination", func(t *testing.T) {
+		for _, code := range []string{"locked", "locked+"} {
+			w := httptest.NewRecorder()
+			app.RedirectToOriginalURL(w, withRoute(httptest.NewRequest(http.MethodGet, "/"+code, nil), code))
+			res := w.Result()
+			body, _ := io.ReadAll(res.Body)
+			res.Body.Close()
+
+			assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+			assert.Contains(t, string(body), `action="/locked/unlock"`)
+			assert.NotContains(t, string(body), "secret.example.com")
+		}
+	})
+
+	t.Run("Wrong Password", func(t *testing.T) {
+		svc.On("UnlockURL", mock.Anything, "locked", "bad", mock.Anything).Return("", app_error.ErrServiceWrongPassword).Once()
+		res := unlock("bad")
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		assert.Contains(t, string(body), "Wrong password.")
+	})
+
+	t.Run("Locked Out", func(t *testing.T) {
+		svc.On("UnlockURL", mock.Anything, "locked", "bad", mock.Anything).
+			Return("", &service.LockoutError{RetryAfter: 90 * time.Second}).Once()
+		res := unlock("bad")
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+		assert.Equal(t, "90", res.Header.Get("Retry-After"))
+	})
+
+	t.Run("Correct Password", func(t *testing.T) {
+		svc.On("UnlockURL", mock.Anything, "locked", "good", mock.Anything).Return("https://secret.example.com", nil).Once()
+		res := unlock("good")
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusSeeOther, res.StatusCode)
+		assert.Equal(t, "https://secret.example.com", res.Header.Get("Location"))
+	})
+}
+
 func TestShortenUrl(t *testing.T) {
 	logAudit := new(service.MockLogAuditManager)
+	clickTracker := new(service.MockClickTracker)
 	service := new(service.MockURLService)
 	userIDProvider := new(MockUserIDProvider)
-	app := handler.NewShortenerHandler(service, userIDProvider, logAudit)
+	app := handler.NewShortenerHandler(service, userIDProvider, logAudit, clickTracker)
 
 	userIDProvider.On("Get", mock.Anything).Return("user", nil)
 
@@ -99,7 +290,7 @@ func TestShortenUrl(t *testing.T) {
 		req.Header.Set("Content-Type", "text/plain")
 		w := httptest.NewRecorder()
 
-		service.On("GetShortURL", mock.Anything, "https://google.com", "user").Return("abc123", nil).Once()
+		service.On("GetShortURL", mock.Anything, model.ShortenParams{URL: "https://google.com"}, "user").Return("abc123", nil).Once()
 		app.ShortenURL(w, req)
 
 		res := w.Result()
@@ -139,7 +330,7 @@ func TestShortenUrl(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/", body)
 		w := httptest.NewRecorder()
 
-		service.On("GetShortURL", mock.Anything, "https://google.com", "user").Return("", errors.New("error")).Once()
+		service.On("GetShortURL", mock.Anything, model.ShortenParams{URL: "https://google.com"}, "user").Return("", errors.New("error")).Once()
 		app.ShortenURL(w, req)
 
 		res := w.Result()
@@ -162,9 +353,10 @@ func TestShortenUrl(t *testing.T) {
 
 func TestApiShortenUrl(t *testing.T) {
 	logAudit := new(service.MockLogAuditManager)
+	clickTracker := new(service.MockClickTracker)
 	service := new(service.MockURLService)
 	userIDProvider := new(MockUserIDProvider)
-	app := handler.NewShortenerHandler(service, userIDProvider, logAudit)
+	app := handler.NewShortenerHandler(service, userIDProvider, logAudit, clickTracker)
 
 	userIDProvider.On("Get", mock.Anything).Return("user", nil)
 
@@ -175,7 +367,7 @@ func TestApiShortenUrl(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
 
-		service.On("GetShortURL", mock.Anything, "https://google.com", "user").Return("abc123", nil).Once()
+		service.On("GetShortURL", mock.Anything, model.ShortenParams{URL: "https://google.com"}, "user").Return("abc123", nil).Once()
 		app.APIShortenURL(w, req)
 
 		res := w.Result()
@@ -241,3 +433,411 @@ type errReader int
 func (errReader) Read(p []byte) (n int, err error) {
 	return 0, errors.New("test error")
 }
+
+func TestApiShortenUrl_Alias(t *testing.T) {
+	logAudit := new(service.MockLogAuditManager)
+	clickTracker := new(service.MockClickTracker)
+	svc := new(service.MockURLService)
+	userIDProvider := new(MockUserIDProvider)
+	app := handler.NewShortenerHandler(svc, userIDProvider, logAudit, clickTracker)
+
+	userIDProvider.On("Get", mock.Anything).Return("user", nil)
+
+	t.Run("Alias Conflict", func(t *testing.T) {
+		body := `{"url": "https://google.com", "alias": "taken"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		params := model.ShortenParams{URL: "https://google.com", Alias: "taken"}
+		svc.On("GetShortURL", mock.Anything, params, "user").Return("", repository.ErrRepoAliasAlreadyExists).Once()
+		app.APIShortenURL(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+		assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	})
+
+	t.Run("Invalid Alias", func(t *testing.T) {
+		body := `{"url": "https://google.com", "alias": "api"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		params := model.ShortenParams{URL: "https://google.com", Alias: "api"}
+		svc.On("GetShortURL", mock.Anything, params, "user").Return("", app_error.ErrServiceInvalidAlias).Once()
+		app.APIShortenURL(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("URL Already Shortened", func(t *testing.T) {
+		body := `{"url": "https://google.com", "alias": "fresh"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		params := model.ShortenParams{URL: "https://google.com", Alias: "fresh"}
+		svc.On("GetShortURL", mock.Anything, params, "user").Return("", app_error.ErrServiceURLAlreadyShortened).Once()
+		app.APIShortenURL(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+		var resp model.ErrorResponse
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+		assert.Equal(t, app_error.ErrServiceURLAlreadyShortened.Error(), resp.Error)
+	})
+
+	t.Run("Batch Invalid Alias", func(t *testing.T) {
+		body := `[{"correlation_id": "1", "original_url": "https://google.com", "alias": "api"}]`
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		svc.On("GetShortURLBatch", mock.Anything, mock.Anything, "user").Return([]string(nil), app_error.ErrServiceInvalidAlias).Once()
+		app.APIShortenBatchURL(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+		var resp model.ErrorResponse
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+		assert.Equal(t, app_error.ErrServiceInvalidAlias.Error(), resp.Error)
+	})
+
+	t.Run("Batch Invalid Tag", func(t *testing.T) {
+		body := `[{"correlation_id": "1", "original_url": "https://google.com", "tags": ["bad tag"]}]`
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		svc.On("GetShortURLBatch", mock.Anything, mock.Anything, "user").Return([]string(nil), app_error.ErrServiceInvalidTag).Once()
+		app.APIShortenBatchURL(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		var resp model.ErrorResponse
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+		assert.Equal(t, app_error.ErrServiceInvalidTag.Error(), resp.Error)
+	})
+}
+
+func TestApiShortenUrl_PolicyViolation(t *testing.T) {
+	logAudit := new(service.MockLogAuditManager)
+	clickTracker := new(service.MockClickTracker)
+	svc := new(service.MockURLService)
+	userIDProvider := new(MockUserIDProvider)
+	app := handler.NewShortenerHandler(svc, userIDProvider, logAudit, clickTracker)
+
+	userIDProvider.On("Get", mock.Anything).Return("user", nil)
+
+	body := `{"url": "http://169.254.169.254/latest"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	policyErr := &service.URLPolicyError{Rule: service.URLRulePrivateHost, URL: "http://169.254.169.254/latest", Reason: "url points to internal address 169.254.169.254"}
+	svc.On("GetShortURL", mock.Anything, model.ShortenParams{URL: "http://169.254.169.254/latest"}, "user").Return("", policyErr).Once()
+	app.APIShortenURL(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	var resp model.URLPolicyErrorResponse
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+	assert.Equal(t, service.URLRulePrivateHost, resp.Rule)
+	assert.Equal(t, "http://169.254.169.254/latest", resp.URL)
+	assert.Contains(t, resp.Error, "internal address")
+}
+
+func TestApiShortenUrl_QR(t *testing.T) {
+	logAudit := new(service.MockLogAuditManager)
+	clickTracker := new(service.MockClickTracker)
+	svc := new(service.MockURLService)
+	userIDProvider := new(MockUserIDProvider)
+	app := handler.NewShortenerHandler(svc, userIDProvider, logAudit, clickTracker)
+
+	userIDProvider.On("Get", mock.Anything).Return("user", nil)
+	logAudit.On("NotifyAllAuditors", mock.Anything, mock.Anything).Return()
+
+	body := `{"url": "https://google.com", "qr": true}`
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	svc.On("GetShortURL", mock.Anything, model.ShortenParams{URL: "https://google.com"}, "user").Return("https://short.com/abc123", nil).Once()
+	app.APIShortenURL(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	var resp model.ShortenResponse
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+	assert.Equal(t, "https://short.com/abc123", resp.Result)
+	assert.Equal(t, "https://short.com/abc123/qr", resp.QR)
+}
+
+func TestApiUserUpdateUrl(t *testing.T) {
+	logAudit := new(service.MockLogAuditManager)
+	clickTracker := new(service.MockClickTracker)
+	svc := new(service.MockURLService)
+	userIDProvider := new(MockUserIDProvider)
+	app := handler.NewShortenerHandler(svc, userIDProvider, logAudit, clickTracker)
+
+	userIDProvider.On("Get", mock.Anything).Return("user", nil)
+
+	send := func(shortID string, body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPatch, "/api/user/urls/"+shortID, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("short_id", shortID)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		app.APIUserUpdateURL(w, req)
+		return w.Result()
+	}
+
+	t.Run("Updated", func(t *testing.T) {
+		edit := &model.URLEdit{ShortID: "abc", UserID: "user", OldURL: "https://old.com", NewURL: "https://new.com"}
+		svc.On("UpdateURL", mock.Anything, "user", "abc", "https://new.com").Return("http://localhost/abc", edit, nil).Once()
+		res := send("abc", `{"url": "https://new.com"}`)
+		defer res.Body.Close()
+
+		var resp model.UserURL
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+		assert.Equal(t, model.UserURL{ShortURL: "http://localhost/abc", URL: "https://new.com"}, resp)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		cases := []struct {
+			err        error
+			statusCode int
+		}{
+			{repository.ErrRepoNotFound, http.StatusNotFound},
+			{app_error.ErrServiceURLNotOwned, http.StatusForbidden},
+			{app_error.ErrServiceURLExpired, http.StatusGone},
+			{repository.ErrRepoURLAlreadyExists, http.StatusConflict},
+		}
+		for _, tc := range cases {
+			svc.On("UpdateURL", mock.Anything, "user", "abc", "https://taken.com").Return("", nil, tc.err).Once()
+			res := send("abc", `{"url": "https://taken.com"}`)
+			res.Body.Close()
+			assert.Equal(t, tc.statusCode, res.StatusCode, tc.err.Error())
+		}
+	})
+
+	t.Run("Invalid URL", func(t *testing.T) {
+		res := send("abc", `{"url": "not a url"}`)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestApiUserUrl_Pagination(t *testing.T) {
+	logAudit := new(service.MockLogAuditManager)
+	clickTracker := new(service.MockClickTracker)
+	svc := new(service.MockURLService)
+	userIDProvider := new(MockUserIDProvider)
+	app := handler.NewShortenerHandler(svc, userIDProvider, logAudit, clickTracker)
+
+	userIDProvider.On("Get", mock.Anything).Return("user", nil)
+
+	send := func(target string) *http.Response {
+		w := httptest.NewRecorder()
+		app.APIUserURL(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w.Result()
+	}
+
+	t.Run("Next Page Link", func(t *testing.T) {
+		cursor := model.URLCursor{CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), ShortID: "abc"}
+		expected := model.UserURLQuery{Limit: 2, Cursor: &cursor, Order: model.SortAsc, Search: "docs", IncludeDeleted: true}
+		svc.On("GetUserURLPage", mock.Anything, "user", expected).
+			Return([]model.UserURL{{ShortURL: "http://localhost/abd", URL: "https://docs.example.com"}}, "next", nil).Once()
+
+		res := send("/api/user/urls?limit=2&sort=created_at&search=docs&include_deleted=true&cursor=" + cursor.Encode())
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, `</api/user/urls?cursor=next&include_deleted=true&limit=2&search=docs&sort=created_at>; rel="next"`, res.Header.Get("Link"))
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		svc.On("GetUserURLPage", mock.Anything, "user", model.UserURLQuery{Order: model.SortDesc}).
+			Return([]model.UserURL{}, "", nil).Once()
+
+		res := send("/api/user/urls")
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		assert.Empty(t, res.Header.Get("Link"))
+	})
+
+	t.Run("Tag Filter", func(t *testing.T) {
+		svc.On("GetUserURLPage", mock.Anything, "user", model.UserURLQuery{Order: model.SortDesc, Tag: "docs"}).
+			Return([]model.UserURL{{ShortURL: "http://localhost/abc", URL: "https://docs.example.com", Tags: []string{"docs"}}}, "", nil).Once()
+
+		res := send("/api/user/urls?tag=Docs")
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("Invalid Params", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "limit=1001", "cursor=%21%21", "sort=title", "include_deleted=maybe", "tag=two+words"} {
+			res := send("/api/user/urls?" + query)
+			res.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+		}
+	})
+}
+
+func TestApiUserSetTags(t *testing.T) {
+	logAudit := new(service.MockLogAuditManager)
+	clickTracker := new(service.MockClickTracker)
+	svc := new(service.MockURLService)
+	userIDProvider := new(MockUserIDProvider)
+	app := handler.NewShortenerHandler(svc, userIDProvider, logAudit, clickTracker)
+
+	userIDProvider.On("Get", mock.Anything).Return("user", nil)
+
+	send := func(shortID string, body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPut, "/api/user/urls/"+shortID+"/tags", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("short_id", shortID)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		app.APIUserSetTags(w, req)
+		return w.Result()
+	}
+
+	t.Run("Updated", func(t *testing.T) {
+		svc.On("SetURLTags", mock.Anything, "user", "abc", []string{"Team", "docs"}).Return([]string{"docs", "team"}, nil).Once()
+		res := send("abc", `{"tags": ["Team", "docs"]}`)
+		defer res.Body.Close()
+
+		var resp model.SetTagsRequest
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+		assert.Equal(t, []string{"docs", "team"}, resp.Tags)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		cases := []struct {
+			err        error
+			statusCode int
+		}{
+			{app_error.ErrServiceInvalidTag, http.StatusBadRequest},
+			{repository.ErrRepoNotFound, http.StatusNotFound},
+			{app_error.ErrServiceURLNotOwned, http.StatusForbidden},
+		}
+		for _, tc := range cases {
+			svc.On("SetURLTags", mock.Anything, "user", "abc", []string{"x"}).Return(nil, tc.err).Once()
+			res := send("abc", `{"tags": ["x"]}`)
+			res.Body.Close()
+			assert.Equal(t, tc.statusCode, res.StatusCode, tc.err.Error())
+		}
+	})
+}
+
+func TestApiUserTags(t *testing.T) {
+	logAudit := new(service.MockLogAuditManager)
+	clickTracker := new(service.MockClickTracker)
+	svc := new(service.MockURLService)
+	userIDProvider := new(MockUserIDProvider)
+	app := handler.NewShortenerHandler(svc, userIDProvider, logAudit, clickTracker)
+
+	userIDProvider.On("Get", mock.Anything).Return("user", nil)
+	counts := []model.TagCount{{Tag: "team", Count: 2}, {Tag: "docs", Count: 1}}
+	svc.On("GetUserTags", mock.Anything, "user").Return(counts, nil).Once()
+
+	w := httptest.NewRecorder()
+	app.APIUserTags(w, httptest.NewRequest(http.MethodGet, "/api/user/tags", nil))
+	res := w.Result()
+	defer res.Body.Close()
+
+	var resp []model.TagCount
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+	assert.Equal(t, counts, resp)
+}
+
+func TestApiUserURL_BrandedLink(t *testing.T) {
+	logAudit := new(service.MockLogAuditManager)
+	clickTracker := new(service.MockClickTracker)
+	svc := new(service.MockURLService)
+	userIDProvider := new(MockUserIDProvider)
+	app := handler.NewShortenerHandler(svc, userIDProvider, logAudit, clickTracker)
+
+	userIDProvider.On("Get", mock.Anything).Return("user", nil)
+
+	// request возвращает запрос к API с параметром маршрута short_id и доменом запроса.
+	request := func(method string, target string, body string, shortID string, domain string) *http.Request {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("short_id", shortID)
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		return req.WithContext(service.ContextWithDomain(ctx, domain))
+	}
+
+	t.Run("Update By Domain Param", func(t *testing.T) {
+		edit := &model.URLEdit{ShortID: "abc@brand.link", UserID: "user", NewURL: "https://new.com"}
+		svc.On("UpdateURL", mock.Anything, "user", "abc@brand.link", "https://new.com").Return("https://brand.link/abc", edit, nil).Once()
+
+		w := httptest.NewRecorder()
+		app.APIUserUpdateURL(w, request(http.MethodPatch, "/api/user/urls/abc?domain=Brand.Link", `{"url": "https://new.com"}`, "abc", ""))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("History By Request Host", func(t *testing.T) {
+		svc.On("GetURLEdits", mock.Anything, "user", "abc@brand.link").Return([]model.URLEdit{}, nil).Once()
+
+		w := httptest.NewRecorder()
+		app.APIUserURLHistory(w, request(http.MethodGet, "/api/user/urls/abc/history", "", "abc", "brand.link"))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Set Tags", func(t *testing.T) {
+		svc.On("SetURLTags", mock.Anything, "user", "abc@brand.link", []string{"docs"}).Return([]string{"docs"}, nil).Once()
+
+		w := httptest.NewRecorder()
+		app.APIUserSetTags(w, request(http.MethodPut, "/api/user/urls/abc/tags?domain=brand.link", `{"tags": ["docs"]}`, "abc", ""))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Batch Delete", func(t *testing.T) {
+		svc.On("DeleteUserURL", mock.Anything, "user", []string{"abc@brand.link", "def@brand.link"}).Return("job", nil).Once()
+
+		w := httptest.NewRecorder()
+		app.APIUserBatchDeleteURL(w, request(http.MethodDelete, "/api/user/urls?domain=brand.link", `["abc", "def"]`, "", ""))
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+
+	t.Run("Key In Path Rejected", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.APIUserURLHistory(w, request(http.MethodGet, "/api/user/urls/abc@brand.link/history", "", "abc@brand.link", ""))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = httptest.NewRecorder()
+		app.APIUserBatchDeleteURL(w, request(http.MethodDelete, "/api/user/urls", `["abc@brand.link"]`, "", ""))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	svc.AssertExpectations(t)
+}
@@ -0,0 +1,71 @@
+// Package handler содержит обработчик HTTP-запросов статистики переходов.
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+)
+
+// StatsHandler обрабатывает HTTP-запросы статистики переходов по сокращенным URL-адресам.
+type StatsHandler struct {
+	// statsProvider предоставляет сервис статистики переходов.
+	statsProvider service.URLStatsProvider
+	// userIDProvider предоставляет провайдер для получения идентификатора пользователя.
+	userIDProvider UserIDProvider
+}
+
+// NewStatsHandler возвращает новый экземпляр StatsHandler.
+func NewStatsHandler(statsProvider service.URLStatsProvider, provider UserIDProvider) StatsHandler {
+	return StatsHandler{
+		statsProvider:  statsProvider,
+		userIDProvider: provider,
+	}
+}
+
+// APIUserURLStats обрабатывает HTTP-запрос на получение статистики переходов по URL-адресу пользователя.
+func (h *StatsHandler) APIUserURLStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	shortID := chi.URLParam(r, "short_id")
+	if shortID == "" {
+		http.Error(w, "missing short id at params", http.StatusBadRequest)
+		return
+	}
+	shortKey, ok := userShortKey(r, shortID)
+	if !ok {
+		http.Error(w, "invalid short id", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := h.userIDProvider.Get(ctx)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	stats, err := h.statsProvider.GetURLStats(ctx, userID, shortKey)
+	if err != nil {
+		if errors.Is(err, repository.ErrRepoNotFound) {
+			writeJSONError(w, http.StatusNotFound, err)
+			return
+		}
+
+		if errors.Is(err, app_error.ErrServiceURLNotOwned) {
+			writeJSONError(w, http.StatusForbidden, err)
+			return
+		}
+
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
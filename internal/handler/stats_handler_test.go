@@ -0,0 +1,113 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oegegr/shortener/internal/handler"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// statsRequest возвращает запрос статистики с параметром маршрута short_id.
+func statsRequest(shortID string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/user/urls/"+shortID+"/stats", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("short_id", shortID)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestAPIUserURLStats(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	urlRepo, err := repository.NewInMemoryURLRepository("", repository.DefaultJournalConfig(), *logger)
+	require.NoError(t, err)
+	require.NoError(t, urlRepo.CreateURL(ctx, []model.URLItem{
+		*model.NewURLItem("https://owned.com", "owned", "user", false),
+		*model.NewURLItem("https://foreign.com", "foreign", "other", false),
+		*model.NewURLItem("https://branded.com", model.ShortKey("brand.link", "owned"), "user", false),
+	}))
+
+	clickRepo := repository.NewInMemoryClickRepository()
+	ts := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, clickRepo.SaveClicks(ctx, []model.Click{
+		{ShortID: "owned", TS: ts, Referrer: "https://ref.com", UserAgent: "agent"},
+		{ShortID: "owned", TS: ts.Add(time.Hour)},
+		{ShortID: "owned", TS: ts.AddDate(0, 0, 1)},
+		{ShortID: model.ShortKey("brand.link", "owned"), TS: ts},
+	}))
+
+	userIDProvider := new(MockUserIDProvider)
+	userIDProvider.On("Get", mock.Anything).Return("user", nil)
+	app := handler.NewStatsHandler(service.NewURLStatsService(urlRepo, clickRepo), userIDProvider)
+
+	t.Run("Owned URL", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.APIUserURLStats(w, statsRequest("owned"))
+
+		res := w.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+
+		var stats model.URLStats
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&stats))
+		assert.Equal(t, int64(3), stats.Total)
+		assert.Equal(t, []model.DailyClicks{{Date: "2026-01-02", Count: 2}, {Date: "2026-01-03", Count: 1}}, stats.Daily)
+	})
+
+	t.Run("Branded URL", func(t *testing.T) {
+		req := statsRequest("owned")
+		req.URL.RawQuery = "domain=brand.link"
+
+		w := httptest.NewRecorder()
+		app.APIUserURLStats(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var stats model.URLStats
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&stats))
+		assert.Equal(t, int64(1), stats.Total)
+	})
+
+	t.Run("Foreign URL", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.APIUserURLStats(w, statsRequest("foreign"))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.APIUserURLStats(w, statsRequest("missing"))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Missing Short ID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		app.APIUserURLStats(w, httptest.NewRequest(http.MethodGet, "/api/user/urls//stats", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		anonymous := new(MockUserIDProvider)
+		anonymous.On("Get", mock.Anything).Return("", errors.New("no user"))
+		app := handler.NewStatsHandler(service.NewURLStatsService(urlRepo, clickRepo), anonymous)
+
+		w := httptest.NewRecorder()
+		app.APIUserURLStats(w, statsRequest("owned"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
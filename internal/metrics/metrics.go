@@ -0,0 +1,134 @@
+// Package metrics содержит метрики Prometheus, которые публикует сервис сокращения URL-адресов.
+// Метрики не регистрируются в глобальном реестре: приложение создает собственный реестр через NewRegistry,
+// поэтому его можно собрать несколько раз в одном процессе, например в тестах.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// namespace представляет общий префикс имен метрик сервиса.
+const namespace = "shortener"
+
+// Результаты перенаправления по сокращенному URL-адресу.
+const (
+	// RedirectResultHit означает успешное перенаправление.
+	RedirectResultHit = "hit"
+	// RedirectResultMiss означает, что сокращенный URL-адрес не найден.
+	RedirectResultMiss = "miss"
+	// RedirectResultGone означает, что сокращенный URL-адрес удален или истек.
+	RedirectResultGone = "gone"
+	// RedirectResultError означает ошибку при поиске сокращенного URL-адреса.
+	RedirectResultError = "error"
+	// RedirectResultPreview означает, что вместо перенаправления показана страница предпросмотра.
+	RedirectResultPreview = "preview"
+	// RedirectResultProtected означает, что вместо перенаправления показана форма ввода пароля.
+	RedirectResultProtected = "protected"
+)
+
+// HTTPRequestDuration представляет гистограмму длительности HTTP-запросов по шаблону маршрута.
+var HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "http_request_duration_seconds",
+	Help:      "Duration of HTTP requests by route pattern.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "route", "status"})
+
+// RedirectsTotal представляет счетчик перенаправлений по результату.
+var RedirectsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "redirects_total",
+	Help:      "Number of short URL redirects by result.",
+}, []string{"result"})
+
+// ShortCodeCollisionsTotal представляет счетчик повторных попыток генерации сокращенного идентификатора из-за коллизий.
+var ShortCodeCollisionsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "short_code_collisions_total",
+	Help:      "Number of short code generation retries caused by collisions.",
+})
+
+// ShortCodeLength представляет текущую длину генерируемых сокращенных идентификаторов.
+var ShortCodeLength = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "short_code_length",
+	Help:      "Current length of generated short codes.",
+})
+
+// DeletionQueueDepth представляет количество невыполненных задач удаления.
+var DeletionQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "deletion_queue_depth",
+	Help:      "Number of unfinished deletion jobs.",
+})
+
+// DeletionJobsFailedTotal представляет счетчик задач удаления, не выполненных после всех попыток.
+var DeletionJobsFailedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "deletion_jobs_failed_total",
+	Help:      "Number of deletion jobs that failed after all retry attempts.",
+})
+
+// AuditDeliveryFailuresTotal представляет счетчик ошибок доставки аудит-логов по типу аудитора.
+var AuditDeliveryFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "audit_delivery_failures_total",
+	Help:      "Number of failed audit log deliveries by auditor.",
+}, []string{"auditor"})
+
+// RateLimitedTotal представляет счетчик запросов, отклоненных ограничителем частоты, по группе маршрутов.
+var RateLimitedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "rate_limited_requests_total",
+	Help:      "Number of requests rejected by the rate limiter.",
+}, []string{"scope"})
+
+// NewRegistry возвращает новый реестр с метриками сервиса, среды выполнения Go и процесса.
+func NewRegistry() (*prometheus.Registry, error) {
+	registry := prometheus.NewRegistry()
+	for _, collector := range []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		RedirectsTotal,
+		ShortCodeCollisionsTotal,
+		ShortCodeLength,
+		DeletionQueueDepth,
+		DeletionJobsFailedTotal,
+		AuditDeliveryFailuresTotal,
+		RateLimitedTotal,
+	} {
+		if err := registry.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// RegisterURLCacheStats регистрирует в реестре счетчики обращений к кэшу URL-адресов.
+// Эта функция принимает реестр и функции, возвращающие количество попаданий, попаданий в отметки об отсутствии и промахов.
+func RegisterURLCacheStats(reg prometheus.Registerer, hits func() float64, negativeHits func() float64, misses func() float64) error {
+	for _, collector := range []prometheus.Collector{
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "url_cache_hits_total",
+			Help:      "Number of short URL lookups served from cache.",
+		}, hits),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "url_cache_negative_hits_total",
+			Help:      "Number of unknown short URL lookups served from cache.",
+		}, negativeHits),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "url_cache_misses_total",
+			Help:      "Number of short URL lookups passed to the repository.",
+		}, misses),
+	} {
+		if err := reg.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/oegegr/shortener/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRegistry_Repeated(t *testing.T) {
+	counter := func() float64 { return 1 }
+
+	// Приложение можно собрать несколько раз в одном процессе: у каждой сборки свой реестр.
+	for range 2 {
+		registry, err := metrics.NewRegistry()
+		require.NoError(t, err)
+		require.NoError(t, metrics.RegisterURLCacheStats(registry, counter, counter, counter))
+
+		families, err := registry.Gather()
+		require.NoError(t, err)
+
+		names := make([]string, 0, len(families))
+		for _, family := range families {
+			names = append(names, family.GetName())
+		}
+		assert.Contains(t, names, "shortener_url_cache_hits_total")
+	}
+}
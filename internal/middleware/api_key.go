@@ -0,0 +1,70 @@
+// Package middleware содержит middleware-функции для аутентификации по API-ключам и проверки их областей доступа.
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/service"
+)
+
+// apiKeyContextKey представляет ключ API-ключа, которым аутентифицирован запрос, в контексте запроса.
+const apiKeyContextKey contextKey = "apiKey"
+
+// Заголовки и схема авторизации для передачи API-ключа.
+const (
+	// apiKeyHeader представляет заголовок с API-ключом.
+	apiKeyHeader = "X-API-Key"
+	// bearerScheme представляет префикс значения заголовка Authorization со схемой Bearer.
+	bearerScheme = "Bearer "
+)
+
+// APIKeyFromContext возвращает API-ключ, которым аутентифицирован запрос, или nil для запросов с cookie или JWT-токеном.
+func APIKeyFromContext(ctx context.Context) *model.APIKey {
+	key, _ := ctx.Value(apiKeyContextKey).(*model.APIKey)
+	return key
+}
+
+// RequireAPIKeyScope возвращает middleware-функцию, отклоняющую со статусом 403 запросы,
+// аутентифицированные API-ключом без заданной области доступа.
+// Запросы с cookie или JWT-токеном пропускаются без ограничений. Middleware должна подключаться после AuthMiddleware.
+func RequireAPIKeyScope(scope model.APIKeyScope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key := APIKeyFromContext(r.Context()); key != nil && !key.HasScope(scope) {
+				http.Error(w, "api key lacks scope "+string(scope), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RejectAPIKey возвращает middleware-функцию, отклоняющую со статусом 403 запросы, аутентифицированные API-ключом.
+// Используется для управления самими ключами, чтобы утекший ключ нельзя было использовать для выпуска новых.
+func RejectAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if APIKeyFromContext(r.Context()) != nil {
+			http.Error(w, "api keys cannot manage api keys", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tryAPIKey пытается получить API-ключ из заголовка X-API-Key или заголовка Authorization со схемой Bearer.
+// Значение Authorization без префикса API-ключа считается JWT-токеном и здесь не обрабатывается.
+func tryAPIKey(r *http.Request, apiKeys service.APIKeyAuthenticator) (*model.APIKey, error) {
+	rawKey := strings.TrimSpace(r.Header.Get(apiKeyHeader))
+	if rawKey == "" {
+		token, ok := strings.CutPrefix(r.Header.Get(authorizationHeader), bearerScheme)
+		if !ok || !strings.HasPrefix(token, model.APIKeyPrefix) {
+			return nil, nil
+		}
+		rawKey = strings.TrimSpace(token)
+	}
+
+	return apiKeys.Authenticate(r.Context(), rawKey)
+}
@@ -0,0 +1,82 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oegegr/shortener/internal/middleware"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestAuthMiddleware_APIKey(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	jwtParser := service.NewJWTParser("secret", time.Hour, *logger)
+	apiKeyRepo, err := repository.NewInMemoryAPIKeyRepository("", *logger)
+	require.NoError(t, err)
+	apiKeys := service.NewAPIKeyService(apiKeyRepo)
+
+	_, rawKey, err := apiKeys.CreateKey(ctx, "bot-owner", "ci", []model.APIKeyScope{model.APIKeyScopeRead})
+	require.NoError(t, err)
+	revoked, revokedKey, err := apiKeys.CreateKey(ctx, "bot-owner", "old", []model.APIKeyScope{model.APIKeyScopeRead})
+	require.NoError(t, err)
+	require.NoError(t, apiKeys.RevokeKey(ctx, "bot-owner", revoked.ID))
+
+	var userID string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ = (&middleware.AuthContextUserIDPovider{}).Get(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	router := http.NewServeMux()
+	auth := middleware.AuthMiddleware(*logger, jwtParser, apiKeys)
+	router.Handle("GET /read", auth(middleware.RequireAPIKeyScope(model.APIKeyScopeRead)(next)))
+	router.Handle("DELETE /delete", auth(middleware.RequireAPIKeyScope(model.APIKeyScopeDelete)(next)))
+	router.Handle("GET /keys", auth(middleware.RejectAPIKey(next)))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		header     string
+		value      string
+		wantStatus int
+		wantUser   string
+	}{
+		{name: "X-API-Key header", method: http.MethodGet, path: "/read", header: "X-API-Key", value: rawKey, wantStatus: http.StatusOK, wantUser: "bot-owner"},
+		{name: "bearer api key", method: http.MethodGet, path: "/read", header: "Authorization", value: "Bearer " + rawKey, wantStatus: http.StatusOK, wantUser: "bot-owner"},
+		{name: "unknown api key", method: http.MethodGet, path: "/read", header: "X-API-Key", value: "sk_unknown", wantStatus: http.StatusUnauthorized},
+		{name: "revoked api key", method: http.MethodGet, path: "/read", header: "X-API-Key", value: revokedKey, wantStatus: http.StatusUnauthorized},
+		{name: "missing scope", method: http.MethodDelete, path: "/delete", header: "X-API-Key", value: rawKey, wantStatus: http.StatusForbidden},
+		{name: "key management rejected", method: http.MethodGet, path: "/keys", header: "X-API-Key", value: rawKey, wantStatus: http.StatusForbidden},
+		{name: "anonymous request unrestricted", method: http.MethodDelete, path: "/delete", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID = ""
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantUser != "" {
+				assert.Equal(t, tt.wantUser, userID)
+				// Запросы с API-ключом не получают новую cookie и JWT-токен.
+				assert.Empty(t, w.Header().Get("Set-Cookie"))
+				assert.Empty(t, w.Header().Get("Authorization"))
+			}
+		})
+	}
+}
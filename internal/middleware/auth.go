@@ -11,15 +11,18 @@ import (
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
+	app_error "github.com/oegegr/shortener/internal/error"
 	"github.com/oegegr/shortener/internal/service"
 )
 
 // contextKey представляет ключ для контекста запроса.
 type contextKey string
 
-// userIDKey представляет ключ для идентификатора пользователя в контексте запроса.
+// userIDKey представляет ключ для идентификатора пользователя в контексте запроса,
+// authenticatedKey — ключ признака того, что идентификатор получен из переданного клиентом токена.
 const (
 	userIDKey           contextKey = "userID"
+	authenticatedKey    contextKey = "authenticated"
 	cookieName          string     = "auth"
 	authorizationHeader string     = "Authorization"
 )
@@ -42,13 +45,33 @@ func (a *AuthContextUserIDPovider) Get(ctx context.Context) (string, error) {
 }
 
 // AuthMiddleware возвращает middleware-функцию для аутентификации пользователей.
-func AuthMiddleware(logger zap.SugaredLogger, jwt service.JWTParser) func(http.Handler) http.Handler {
+// Запрос с API-ключом (заголовок X-API-Key или Authorization: Bearer sk_...) выполняется от имени владельца ключа
+// без выдачи cookie и JWT-токена; неизвестный или отозванный ключ отклоняется со статусом 401.
+func AuthMiddleware(logger zap.SugaredLogger, jwt service.JWTParser, apiKeys service.APIKeyAuthenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 
 		authHandler := func(w http.ResponseWriter, r *http.Request) {
 
+			apiKey, err := tryAPIKey(r, apiKeys)
+			if err != nil {
+				if errors.Is(err, app_error.ErrServiceInvalidAPIKey) {
+					http.Error(w, "invalid api key", http.StatusUnauthorized)
+					return
+				}
+				logger.Errorf("Failed to check api key: %v", err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			if apiKey != nil {
+				ctx := context.WithValue(r.Context(), userIDKey, apiKey.UserID)
+				ctx = context.WithValue(ctx, authenticatedKey, true)
+				ctx = context.WithValue(ctx, apiKeyContextKey, apiKey)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			var userID string
-			var err error
 
 			userID, err = tryAuthorization(r, jwt)
 
@@ -64,7 +87,8 @@ func AuthMiddleware(logger zap.SugaredLogger, jwt service.JWTParser) func(http.H
 				}
 			}
 
-			if userID == "" {
+			authenticated := userID != ""
+			if !authenticated {
 				userID = generateUserID()
 				logger.Info("new userID has been created")
 			}
@@ -76,11 +100,12 @@ func AuthMiddleware(logger zap.SugaredLogger, jwt service.JWTParser) func(http.H
 				http.Error(w, "", http.StatusInternalServerError)
 			}
 
-			setAuthCookie(w, token)
+			setAuthCookie(w, token, jwt.Lifetime())
 
 			setAuthorizationHeader(w, token)
 
 			ctx := context.WithValue(r.Context(), userIDKey, userID)
+			ctx = context.WithValue(ctx, authenticatedKey, authenticated)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		}
 
@@ -98,14 +123,14 @@ func setAuthorizationHeader(w http.ResponseWriter, token string) {
 	w.Header().Set(authorizationHeader, token)
 }
 
-// setAuthCookie устанавливает cookie с именем auth в HTTP-ответе.
-func setAuthCookie(w http.ResponseWriter, token string) {
+// setAuthCookie устанавливает cookie с именем auth в HTTP-ответе на время жизни токена.
+func setAuthCookie(w http.ResponseWriter, token string, lifetime time.Duration) {
 	cookie := &http.Cookie{
 		Name:     cookieName,
 		Value:    token,
 		Path:     "/",
 		HttpOnly: true,
-		Expires:  time.Now().Add(24 * time.Hour),
+		Expires:  time.Now().Add(lifetime),
 	}
 	http.SetCookie(w, cookie)
 }
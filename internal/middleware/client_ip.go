@@ -0,0 +1,20 @@
+// Package middleware содержит middleware-функцию для определения IP-адреса клиента.
+package middleware
+
+import (
+	"net/http"
+
+	pkghttp "github.com/oegegr/shortener/pkg/http"
+)
+
+// ClientIPMiddleware возвращает middleware-функцию, сохраняющую в контексте запроса IP-адрес клиента,
+// определенный через pkghttp.TrustedProxies.ResolveClientIP; обработчики получают адрес через pkghttp.ClientIP.
+// Middleware должна подключаться первой.
+func ClientIPMiddleware(proxies pkghttp.TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := proxies.ResolveClientIP(r)
+			next.ServeHTTP(w, r.WithContext(pkghttp.ContextWithClientIP(r.Context(), ip)))
+		})
+	}
+}
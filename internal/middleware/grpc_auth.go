@@ -0,0 +1,100 @@
+// Package middleware содержит перехватчики gRPC-запросов.
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/service"
+	pb "github.com/oegegr/shortener/pkg/api/shortener"
+)
+
+// authorizationMetadata представляет ключ метаданных gRPC с JWT-токеном или API-ключом со схемой Bearer.
+const authorizationMetadata = "authorization"
+
+// grpcMethodScopes представляет области доступа API-ключа, необходимые для вызова методов gRPC.
+// Пустая область означает, что метод доступен любому действующему ключу;
+// методы, отсутствующие в карте, API-ключам недоступны.
+var grpcMethodScopes = map[string]model.APIKeyScope{
+	pb.Shortener_Shorten_FullMethodName:        model.APIKeyScopeShorten,
+	pb.Shortener_ShortenBatch_FullMethodName:   model.APIKeyScopeShorten,
+	pb.Shortener_Expand_FullMethodName:         "",
+	pb.Shortener_ListUserURLs_FullMethodName:   model.APIKeyScopeRead,
+	pb.Shortener_DeleteUserURLs_FullMethodName: model.APIKeyScopeDelete,
+	pb.Shortener_Ping_FullMethodName:           "",
+}
+
+// AuthUnaryInterceptor возвращает gRPC-перехватчик для аутентификации пользователей.
+// Перехватчик повторяет поведение AuthMiddleware: читает JWT-токен из метаданных authorization,
+// создает нового пользователя при отсутствии токена и возвращает обновленный токен в заголовке ответа.
+// Вызов с API-ключом (authorization: Bearer sk_...) выполняется от имени владельца ключа без выдачи JWT-токена;
+// неизвестный или отозванный ключ отклоняется с кодом Unauthenticated, ключ без нужной области доступа — с кодом PermissionDenied.
+func AuthUnaryInterceptor(logger zap.SugaredLogger, jwt service.JWTParser, apiKeys service.APIKeyAuthenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var authorization string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(authorizationMetadata); len(values) > 0 {
+				authorization = values[0]
+			}
+		}
+
+		if rawKey, ok := strings.CutPrefix(authorization, bearerScheme); ok && strings.HasPrefix(rawKey, model.APIKeyPrefix) {
+			apiKey, err := apiKeys.Authenticate(ctx, strings.TrimSpace(rawKey))
+			if errors.Is(err, app_error.ErrServiceInvalidAPIKey) {
+				return nil, status.Error(codes.Unauthenticated, "invalid api key")
+			}
+			if err != nil {
+				logger.Errorf("Failed to check api key: %v", err)
+				return nil, status.Error(codes.Internal, "failed to check api key")
+			}
+
+			scope, ok := grpcMethodScopes[info.FullMethod]
+			if !ok || (scope != "" && !apiKey.HasScope(scope)) {
+				return nil, status.Errorf(codes.PermissionDenied, "api key lacks scope for %s", info.FullMethod)
+			}
+
+			ctx = context.WithValue(ctx, userIDKey, apiKey.UserID)
+			ctx = context.WithValue(ctx, authenticatedKey, true)
+			ctx = context.WithValue(ctx, apiKeyContextKey, apiKey)
+			return handler(ctx, req)
+		}
+
+		var userID string
+		if authorization != "" {
+			var err error
+			userID, err = jwt.UserFromJWTToken(authorization)
+			if err != nil {
+				return nil, status.Error(codes.Unauthenticated, "bad authorization metadata")
+			}
+		}
+
+		authenticated := userID != ""
+		if !authenticated {
+			userID = generateUserID()
+			logger.Info("new userID has been created")
+		}
+
+		token, err := jwt.CreateNewJWTToken(userID)
+		if err != nil {
+			logger.Errorf("Failed to create jwt token with userID %s: %v", userID, err)
+			return nil, status.Error(codes.Internal, "failed to create token")
+		}
+
+		if err := grpc.SetHeader(ctx, metadata.Pairs(authorizationMetadata, token)); err != nil {
+			logger.Debugf("failed to set authorization header: %v", err)
+		}
+
+		ctx = context.WithValue(ctx, userIDKey, userID)
+		ctx = context.WithValue(ctx, authenticatedKey, authenticated)
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,94 @@
+// Package middleware содержит перехватчик gRPC-запросов для ограничения частоты запросов.
+package middleware
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/oegegr/shortener/internal/metrics"
+	pb "github.com/oegegr/shortener/pkg/api/shortener"
+	"github.com/oegegr/shortener/pkg/ratelimit"
+)
+
+// retryAfterMetadata представляет ключ метаданных ответа gRPC со временем ожидания в секундах.
+const retryAfterMetadata = "retry-after"
+
+// grpcRateLimit представляет ограничение, применяемое к вызову метода gRPC.
+type grpcRateLimit struct {
+	// scope представляет название группы, совпадающее с группой маршрутов HTTP.
+	scope string
+	// limit представляет ограничение группы.
+	limit ratelimit.Limit
+	// cost представляет количество токенов, которое расходует вызов.
+	cost int
+}
+
+// RateLimitUnaryInterceptor возвращает gRPC-перехватчик, применяющий к методам gRPC те же ограничения,
+// что RateLimitMiddleware и BatchSizeMiddleware к соответствующим маршрутам HTTP: Shorten и ShortenBatch учитываются
+// в группе shorten, ShortenBatch дополнительно в группе batch по количеству элементов, Expand — в группе redirect.
+// Корзины общие с HTTP, поэтому переход на gRPC не снимает ограничение. Перехватчик должен подключаться после AuthUnaryInterceptor.
+// Пакет больше BatchMaxSize отклоняется с кодом InvalidArgument, превышение частоты — с кодом ResourceExhausted.
+func RateLimitUnaryInterceptor(logger zap.SugaredLogger, store ratelimit.Store, limits RateLimits) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var checks []grpcRateLimit
+		switch info.FullMethod {
+		case pb.Shortener_Shorten_FullMethodName:
+			checks = []grpcRateLimit{{scope: "shorten", limit: limits.Shorten, cost: 1}}
+		case pb.Shortener_ShortenBatch_FullMethodName:
+			size := len(req.(*pb.ShortenBatchRequest).GetItems())
+			if limits.BatchMaxSize > 0 && size > limits.BatchMaxSize {
+				return nil, status.Errorf(codes.InvalidArgument, "batch exceeds max size %d", limits.BatchMaxSize)
+			}
+			checks = []grpcRateLimit{
+				{scope: "shorten", limit: limits.Shorten, cost: 1},
+				{scope: "batch", limit: limits.Batch, cost: max(size, 1)},
+			}
+		case pb.Shortener_Expand_FullMethodName:
+			checks = []grpcRateLimit{{scope: "redirect", limit: limits.Redirect, cost: 1}}
+		}
+
+		for _, check := range checks {
+			if !check.limit.Enabled() {
+				continue
+			}
+
+			keys := rateLimitKeys(ctx, check.scope, peerIP(ctx))
+			result, err := store.Take(ctx, keys, check.limit, min(check.cost, check.limit.Burst))
+			if err != nil {
+				logger.Errorf("failed to check rate limit: %v", err)
+				continue
+			}
+			if !result.Allowed {
+				metrics.RateLimitedTotal.WithLabelValues(check.scope).Inc()
+				retryAfter := strconv.Itoa(ceilSeconds(result.RetryAfter))
+				if err := grpc.SetHeader(ctx, metadata.Pairs(retryAfterMetadata, retryAfter)); err != nil {
+					logger.Debugf("failed to set retry-after header: %v", err)
+				}
+				return nil, status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %s seconds", retryAfter)
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// peerIP возвращает IP-адрес клиента gRPC без порта.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -3,7 +3,6 @@ package middleware
 
 import (
 	"net/http"
-	"time"
 
 	"go.uber.org/zap"
 )
@@ -35,20 +34,18 @@ func (r *responseRecorder) Write(b []byte) (int, error) {
 
 // ZapLogger возвращает middleware-функцию для логирования HTTP-запросов с помощью Zap.
 // Эта функция принимает экземпляр логгера Zap и возвращает middleware-функцию.
+// Длительность запросов не логируется: она публикуется в метриках middleware Metrics.
 func ZapLogger(sugar zap.SugaredLogger) func(http.Handler) http.Handler {
 	return func(nextHandler http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			method := r.Method
 			uri := r.RequestURI
-			startTime := time.Now()
 			recorderedWriter := &responseRecorder{ResponseWriter: w}
 			nextHandler.ServeHTTP(recorderedWriter, r)
-			duration := time.Since(startTime)
 			sugar.Infoln(
 				"request",
 				"uri", uri,
 				"method", method,
-				"duration", duration,
 				"status code", recorderedWriter.status,
 				"response size", recorderedWriter.size,
 			)
@@ -0,0 +1,38 @@
+// Package middleware содержит middleware-функцию для сбора метрик HTTP-запросов.
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oegegr/shortener/internal/metrics"
+)
+
+// unmatchedRoute представляет метку маршрута для запросов, не сопоставленных ни одному маршруту.
+const unmatchedRoute = "unmatched"
+
+// Metrics возвращает middleware-функцию, измеряющую длительность HTTP-запросов.
+// Запросы группируются по шаблону маршрута chi, а не по URI, чтобы количество меток оставалось ограниченным.
+func Metrics(nextHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		recorderedWriter := &responseRecorder{ResponseWriter: w}
+		nextHandler.ServeHTTP(recorderedWriter, r)
+
+		route := unmatchedRoute
+		if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil && routeCtx.RoutePattern() != "" {
+			route = routeCtx.RoutePattern()
+		}
+
+		status := recorderedWriter.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		metrics.HTTPRequestDuration.
+			WithLabelValues(r.Method, route, strconv.Itoa(status)).
+			Observe(time.Since(startTime).Seconds())
+	})
+}
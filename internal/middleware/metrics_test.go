@@ -0,0 +1,32 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oegegr/shortener/internal/metrics"
+	"github.com/oegegr/shortener/internal/middleware"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_RoutePattern(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(middleware.Metrics)
+	router.Get("/{short_url}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	})
+
+	before := testutil.CollectAndCount(metrics.HTTPRequestDuration)
+
+	for _, code := range []string{"/abc", "/def", "/ghi"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, code, nil))
+		assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	}
+
+	// Запросы с разными кодами попадают в одну серию с шаблоном маршрута.
+	assert.Equal(t, before+1, testutil.CollectAndCount(metrics.HTTPRequestDuration))
+}
@@ -0,0 +1,172 @@
+// Package middleware содержит middleware-функцию для ограничения частоты запросов.
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/oegegr/shortener/internal/metrics"
+	pkghttp "github.com/oegegr/shortener/pkg/http"
+	"github.com/oegegr/shortener/pkg/ratelimit"
+	"go.uber.org/zap"
+)
+
+// Заголовки ограничения частоты запросов.
+const (
+	rateLimitLimitHeader     = "RateLimit-Limit"
+	rateLimitRemainingHeader = "RateLimit-Remaining"
+	rateLimitResetHeader     = "RateLimit-Reset"
+	retryAfterHeader         = "Retry-After"
+)
+
+// RateLimits представляет ограничения частоты запросов для групп маршрутов.
+type RateLimits struct {
+	// Shorten представляет ограничение запросов на сокращение URL-адресов.
+	Shorten ratelimit.Limit
+	// Batch представляет ограничение количества URL-адресов в пакетных запросах на сокращение.
+	Batch ratelimit.Limit
+	// BatchMaxSize представляет максимальное количество URL-адресов в одном пакетном запросе;
+	// нулевое или отрицательное значение снимает ограничение.
+	BatchMaxSize int
+	// Redirect представляет ограничение переходов по сокращенным URL-адресам.
+	Redirect ratelimit.Limit
+}
+
+// RequestCost представляет функцию, возвращающую количество токенов, которое расходует запрос.
+type RequestCost func(r *http.Request) int
+
+// SingleRequestCost возвращает стоимость запроса в один токен.
+func SingleRequestCost(r *http.Request) int {
+	return 1
+}
+
+// maxBatchBodySize представляет максимальный размер тела пакетного запроса после распаковки в байтах.
+const maxBatchBodySize = 8 << 20
+
+// batchSizeKey представляет ключ количества элементов пакетного запроса в контексте запроса.
+const batchSizeKey contextKey = "batchSize"
+
+// BatchRequestCost возвращает стоимость пакетного запроса, равную количеству элементов JSON-массива в теле запроса.
+// Количество берется из контекста, если его уже подсчитала BatchSizeMiddleware, иначе тело читается здесь.
+// Если тело не является массивом, стоимость равна одному токену, а ошибку формата вернет обработчик.
+func BatchRequestCost(r *http.Request) int {
+	if size, ok := r.Context().Value(batchSizeKey).(int); ok {
+		return max(size, 1)
+	}
+	size, _ := batchSize(nil, r)
+	return max(size, 1)
+}
+
+// BatchSizeMiddleware возвращает middleware-функцию, подсчитывающую элементы пакетного запроса для BatchRequestCost
+// и отклоняющую со статусом 413 запросы с телом больше maxBatchBodySize или с количеством элементов больше maxSize.
+// Нулевое или отрицательное значение maxSize снимает ограничение количества элементов. Middleware должна подключаться
+// перед RateLimitMiddleware, чтобы тело запроса читалось один раз.
+func BatchSizeMiddleware(maxSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			size, err := batchSize(w, r)
+			if err != nil {
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			if maxSize > 0 && size > maxSize {
+				http.Error(w, "batch exceeds max size "+strconv.Itoa(maxSize), http.StatusRequestEntityTooLarge)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), batchSizeKey, size)))
+		})
+	}
+}
+
+// batchSize возвращает количество элементов JSON-массива в теле запроса или ноль, если тело не является массивом.
+// Тело читается не больше maxBatchBodySize байт и восстанавливается для следующего обработчика;
+// для тела большего размера возвращается ошибка.
+func batchSize(w http.ResponseWriter, r *http.Request) (int, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchBodySize))
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return 0, err
+		}
+		return 0, nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return 0, nil
+	}
+	return len(items), nil
+}
+
+// RateLimitMiddleware возвращает middleware-функцию, ограничивающую частоту запросов по алгоритму token bucket.
+// Эта функция принимает логгер, хранилище корзин, название группы маршрутов, ограничение и функцию стоимости запроса.
+// Каждый запрос учитывается в корзине IP-адреса клиента, так как новый идентификатор пользователя можно получить,
+// просто не передав cookie. Запросы пользователей, переданных клиентом в токене или API-ключе, дополнительно учитываются
+// в корзине пользователя, чтобы смена IP-адреса не снимала ограничение; токены списываются, только если их хватает
+// в обеих корзинах. Стоимость запроса больше емкости корзины
+// расходует корзину целиком. Middleware должна подключаться после AuthMiddleware.
+// Если хранилище недоступно, запрос пропускается, чтобы сбой ограничителя не останавливал сервис.
+func RateLimitMiddleware(
+	logger zap.SugaredLogger,
+	store ratelimit.Store,
+	scope string,
+	limit ratelimit.Limit,
+	cost RequestCost,
+) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !limit.Enabled() {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestCost := min(cost(r), limit.Burst)
+
+			result, err := store.Take(r.Context(), rateLimitKeys(r.Context(), scope, pkghttp.ClientIP(r)), limit, requestCost)
+			if err != nil {
+				logger.Errorf("failed to check rate limit: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set(rateLimitLimitHeader, strconv.Itoa(limit.Burst))
+			w.Header().Set(rateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+			w.Header().Set(rateLimitResetHeader, strconv.Itoa(ceilSeconds(result.Reset)))
+
+			if !result.Allowed {
+				metrics.RateLimitedTotal.WithLabelValues(scope).Inc()
+				w.Header().Set(retryAfterHeader, strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitKeys возвращает ключи корзин клиента в группе маршрутов scope: IP-адрес и,
+// если идентификатор пользователя не создан в этом запросе, идентификатор пользователя.
+func rateLimitKeys(ctx context.Context, scope string, clientIP string) []string {
+	keys := []string{scope + ":ip:" + clientIP}
+	if authenticated, _ := ctx.Value(authenticatedKey).(bool); authenticated {
+		userIDProvider := AuthContextUserIDPovider{}
+		if userID, err := userIDProvider.Get(ctx); err == nil {
+			keys = append(keys, scope+":user:"+userID)
+		}
+	}
+	return keys
+}
+
+// ceilSeconds возвращает длительность в целых секундах с округлением вверх.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,170 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oegegr/shortener/internal/middleware"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/oegegr/shortener/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRateLimitMiddleware_ByIP(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	store := ratelimit.NewInMemoryStore(time.Minute)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) })
+	handler := middleware.RateLimitMiddleware(*logger, store, "shorten", ratelimit.PerMinute(60, 2), middleware.SingleRequestCost)(next)
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Real-IP", "10.0.0.9")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("10.0.0.1:1000")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+
+	// Новое соединение с того же IP-адреса и поддельный заголовок X-Real-IP не дают новой корзины.
+	assert.Equal(t, http.StatusCreated, send("10.0.0.1:1001").Code)
+
+	w = send("10.0.0.1:1002")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Reset"))
+
+	assert.Equal(t, http.StatusCreated, send("10.0.0.2:1000").Code)
+}
+
+func TestRateLimitMiddleware_ByUser(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	jwtParser := service.NewJWTParser("secret", time.Hour, *logger)
+	store := ratelimit.NewInMemoryStore(time.Minute)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	apiKeyRepo, err := repository.NewInMemoryAPIKeyRepository("", *logger)
+	require.NoError(t, err)
+	handler := middleware.AuthMiddleware(*logger, jwtParser, service.NewAPIKeyService(apiKeyRepo))(
+		middleware.RateLimitMiddleware(*logger, store, "redirect", ratelimit.PerMinute(60, 1), middleware.SingleRequestCost)(next),
+	)
+
+	token, err := jwtParser.CreateNewJWTToken("user")
+	require.NoError(t, err)
+	freshToken, err := jwtParser.CreateNewJWTToken("fresh")
+	require.NoError(t, err)
+
+	send := func(ip string, token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+		req.RemoteAddr = ip + ":1234"
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Аутентифицированный пользователь учитывается по идентификатору независимо от IP-адреса.
+	assert.Equal(t, http.StatusOK, send("10.0.0.1", token))
+	assert.Equal(t, http.StatusTooManyRequests, send("10.0.0.2", token))
+	// Отказ по корзине пользователя не расходует токены корзины IP-адреса.
+	assert.Equal(t, http.StatusOK, send("10.0.0.2", ""))
+
+	// Анонимные клиенты учитываются по IP-адресу, хотя в каждом запросе получают новый идентификатор.
+	assert.Equal(t, http.StatusOK, send("10.0.0.3", ""))
+	assert.Equal(t, http.StatusTooManyRequests, send("10.0.0.3", ""))
+
+	// Новый идентификатор не дает новой корзины: запрос с ним учитывается и по IP-адресу.
+	assert.Equal(t, http.StatusTooManyRequests, send("10.0.0.3", freshToken))
+}
+
+func TestRateLimitMiddleware_BatchCost(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	store := ratelimit.NewInMemoryStore(time.Minute)
+	var body string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		body = string(data)
+		w.WriteHeader(http.StatusCreated)
+	})
+	handler := middleware.RateLimitMiddleware(*logger, store, "batch", ratelimit.PerMinute(60, 3), middleware.BatchRequestCost)(next)
+
+	send := func(payload string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", strings.NewReader(payload))
+		req.RemoteAddr = "10.0.0.1:1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	payload := `[{"original_url":"https://a.com"},{"original_url":"https://b.com"}]`
+	w := send(payload)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	// Тело запроса доступно следующему обработчику.
+	assert.Equal(t, payload, body)
+
+	assert.Equal(t, http.StatusTooManyRequests, send(payload).Code)
+
+	// Пакет больше емкости корзины расходует полную корзину целиком.
+	store = ratelimit.NewInMemoryStore(time.Minute)
+	handler = middleware.RateLimitMiddleware(*logger, store, "batch", ratelimit.PerMinute(60, 3), middleware.BatchRequestCost)(next)
+	w = send(`[{"original_url":"https://a.com"},{"original_url":"https://b.com"},{"original_url":"https://c.com"},{"original_url":"https://d.com"}]`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+}
+
+func TestBatchSizeMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, data)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	send := func(maxSize int, payload string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", strings.NewReader(payload))
+		w := httptest.NewRecorder()
+		middleware.BatchSizeMiddleware(maxSize)(next).ServeHTTP(w, req)
+		return w.Code
+	}
+
+	payload := `[{"original_url":"https://a.com"},{"original_url":"https://b.com"},{"original_url":"https://c.com"}]`
+	assert.Equal(t, http.StatusCreated, send(3, payload))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, send(2, payload))
+	assert.Equal(t, http.StatusCreated, send(0, payload))
+	assert.Equal(t, http.StatusCreated, send(2, "not json"))
+
+	// Тело больше предельного размера отклоняется независимо от количества элементов.
+	huge := `[{"original_url":"https://a.com/` + strings.Repeat("a", 8<<20) + `"}]`
+	assert.Equal(t, http.StatusRequestEntityTooLarge, send(0, huge))
+}
+
+func TestBatchSizeMiddleware_SharesCount(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	store := ratelimit.NewInMemoryStore(time.Minute)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) })
+	rateLimit := middleware.RateLimitMiddleware(*logger, store, "batch", ratelimit.PerMinute(60, 5), middleware.BatchRequestCost)
+	handler := middleware.BatchSizeMiddleware(0)(rateLimit(next))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", strings.NewReader(`[{"original_url":"https://a.com"},{"original_url":"https://b.com"}]`))
+	req.RemoteAddr = "10.0.0.1:1234"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "3", w.Header().Get("RateLimit-Remaining"))
+}
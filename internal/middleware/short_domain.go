@@ -0,0 +1,40 @@
+// Package middleware содержит middleware-функцию для определения домена сокращенных URL-адресов.
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/oegegr/shortener/internal/service"
+)
+
+// ShortDomainMiddleware возвращает middleware-функцию, сохраняющую в контексте запроса домен сокращенных URL-адресов,
+// определенный по заголовку Host. Запросы к неизвестным хостам обслуживаются как запросы к домену по умолчанию.
+func ShortDomainMiddleware(domains *service.DomainRegistry) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			domain := domains.ResolveHost(r.Host)
+			next.ServeHTTP(w, r.WithContext(service.ContextWithDomain(r.Context(), domain)))
+		})
+	}
+}
+
+// authorityMetadata представляет ключ метаданных gRPC с хостом, к которому обратился клиент.
+const authorityMetadata = ":authority"
+
+// ShortDomainUnaryInterceptor возвращает gRPC-перехватчик, сохраняющий в контексте вызова домен сокращенных URL-адресов,
+// определенный по хосту из метаданных :authority, так же как ShortDomainMiddleware определяет его по заголовку Host.
+func ShortDomainUnaryInterceptor(domains *service.DomainRegistry) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var domain string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(authorityMetadata); len(values) > 0 {
+				domain = domains.ResolveHost(values[0])
+			}
+		}
+		return handler(service.ContextWithDomain(ctx, domain), req)
+	}
+}
@@ -0,0 +1,41 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oegegr/shortener/internal/middleware"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShortDomainMiddleware(t *testing.T) {
+	domains := service.NewDomainRegistry("https://short.com", service.ShortDomain{BaseURL: "https://brand.link"})
+
+	tests := []struct {
+		name string
+		host string
+		want string
+	}{
+		{name: "configured domain", host: "brand.link", want: "brand.link"},
+		{name: "configured domain with port", host: "brand.link:8080", want: "brand.link"},
+		{name: "default domain", host: "short.com", want: ""},
+		{name: "unknown host", host: "127.0.0.1:8080", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = service.DomainFromContext(r.Context())
+			})
+			req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+			req.Host = tt.host
+
+			middleware.ShortDomainMiddleware(domains)(next).ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
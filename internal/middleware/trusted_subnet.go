@@ -0,0 +1,25 @@
+// Package middleware содержит middleware-функцию для ограничения доступа по доверенной подсети.
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	pkghttp "github.com/oegegr/shortener/pkg/http"
+)
+
+// TrustedSubnetMiddleware возвращает middleware-функцию, пропускающую только запросы из доверенной подсети.
+// IP-адрес клиента определяется через pkghttp.ClientIP.
+// Если подсеть не задана (nil), все запросы отклоняются со статусом 403.
+func TrustedSubnetMiddleware(trustedSubnet *net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := net.ParseIP(pkghttp.ClientIP(r))
+			if trustedSubnet == nil || ip == nil || !trustedSubnet.Contains(ip) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package middleware_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oegegr/shortener/internal/middleware"
+	pkghttp "github.com/oegegr/shortener/pkg/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustedSubnetMiddleware(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("192.168.1.0/24")
+	proxies, err := pkghttp.ParseTrustedProxies("10.0.0.1/32")
+	require.NoError(t, err)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	tests := []struct {
+		name       string
+		subnet     *net.IPNet
+		realIP     string
+		remoteAddr string
+		want       int
+	}{
+		{name: "X-Real-IP in subnet from trusted proxy", subnet: subnet, realIP: "192.168.1.10", remoteAddr: "10.0.0.1:1234", want: http.StatusOK},
+		{name: "X-Real-IP in subnet from untrusted client", subnet: subnet, realIP: "192.168.1.10", remoteAddr: "10.0.0.2:1234", want: http.StatusForbidden},
+		{name: "X-Real-IP outside subnet from trusted proxy", subnet: subnet, realIP: "10.0.0.5", remoteAddr: "10.0.0.1:1234", want: http.StatusForbidden},
+		{name: "remote address in subnet", subnet: subnet, remoteAddr: "192.168.1.20:1234", want: http.StatusOK},
+		{name: "no trusted subnet", subnet: nil, realIP: "192.168.1.10", remoteAddr: "10.0.0.1:1234", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			w := httptest.NewRecorder()
+
+			handler := middleware.ClientIPMiddleware(proxies)(middleware.TrustedSubnetMiddleware(tt.subnet)(next))
+			handler.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.want, res.StatusCode)
+		})
+	}
+}
@@ -2,7 +2,12 @@
 package model
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,16 +17,39 @@ var ErrInvalidURL = errors.New("invalid URL format")
 // ErrEmptyCode представляет ошибку, которая возникает при пустом коде сокращения URL-адреса.
 var ErrEmptyCode = errors.New("short code cannot be empty")
 
+// ErrInvalidExpiration представляет ошибку, которая возникает при неверно заданном сроке действия URL-адреса.
+var ErrInvalidExpiration = errors.New("invalid expiration")
+
 // ShortenRequest представляет запрос на сокращение URL-адреса.
 type ShortenRequest struct {
 	// URL представляет URL-адрес, который необходимо сократить.
 	URL string `json:"url"`
+	// Alias представляет пользовательский сокращенный идентификатор (необязательно).
+	Alias string `json:"alias,omitempty"`
+	// TTLSeconds представляет время жизни сокращенного URL-адреса в секундах (необязательно).
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"`
+	// ExpiresAt представляет момент истечения срока действия сокращенного URL-адреса (необязательно).
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Title представляет заголовок, показываемый на странице предпросмотра (необязательно).
+	Title string `json:"title,omitempty"`
+	// Preview представляет признак того, что при переходе нужно показывать страницу предпросмотра (необязательно).
+	Preview bool `json:"preview,omitempty"`
+	// Password представляет пароль, который нужно ввести перед переходом (необязательно).
+	Password string `json:"password,omitempty"`
+	// Tags представляет метки URL-адреса (необязательно).
+	Tags []string `json:"tags,omitempty"`
+	// Domain представляет домен сокращенного URL-адреса из списка настроенных доменов (необязательно).
+	Domain string `json:"domain,omitempty"`
+	// QR представляет признак того, что в ответ нужно добавить ссылку на QR-код (необязательно).
+	QR bool `json:"qr,omitempty"`
 }
 
 // ShortenResponse представляет ответ на запрос на сокращение URL-адреса.
 type ShortenResponse struct {
 	// Result представляет сокращенный URL-адрес.
 	Result string `json:"result"`
+	// QR представляет ссылку на QR-код сокращенного URL-адреса, если он был запрошен.
+	QR string `json:"qr,omitempty"`
 }
 
 // UserURLResponse представляет список URL-адресов пользователя.
@@ -33,6 +61,147 @@ type UserURL struct {
 	ShortURL string `json:"short_url"`
 	// URL представляет оригинальный URL-адрес.
 	URL string `json:"original_url"`
+	// CreatedAt представляет момент создания URL-адреса, если он известен.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	// UpdatedAt представляет момент последнего изменения URL-адреса, если он известен.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	// LastAccessedAt представляет момент последнего перехода по URL-адресу; отсутствует, если переходов не было.
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	// Title представляет заголовок URL-адреса.
+	Title string `json:"title,omitempty"`
+	// Tags представляет метки URL-адреса.
+	Tags []string `json:"tags,omitempty"`
+	// IsDeleted представляет признак удаленного URL-адреса; заполняется при запросе с удаленными URL-адресами.
+	IsDeleted bool `json:"is_deleted,omitempty"`
+}
+
+// ErrInvalidCursor представляет ошибку, которая возникает при неверном курсоре страницы.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SortOrder представляет порядок сортировки URL-адресов по времени создания.
+type SortOrder string
+
+// SortAsc представляет сортировку от старых URL-адресов к новым.
+const SortAsc SortOrder = "asc"
+
+// SortDesc представляет сортировку от новых URL-адресов к старым.
+const SortDesc SortOrder = "desc"
+
+// URLCursor представляет позицию последнего URL-адреса страницы в порядке сортировки.
+type URLCursor struct {
+	// CreatedAt представляет момент создания последнего URL-адреса страницы.
+	CreatedAt time.Time
+	// ShortID представляет сокращенный идентификатор последнего URL-адреса страницы; упорядочивает URL-адреса с одинаковым временем создания.
+	ShortID string
+}
+
+// NewURLCursor возвращает курсор, указывающий на заданный URL-адрес.
+func NewURLCursor(item URLItem) *URLCursor {
+	return &URLCursor{CreatedAt: item.CreatedAt, ShortID: item.ShortID}
+}
+
+// Encode возвращает непрозрачное строковое представление курсора для передачи клиенту.
+// Неизвестное (нулевое) время создания кодируется пустой строкой, так как не представимо в наносекундах Unix.
+func (c URLCursor) Encode() string {
+	var nanos string
+	if !c.CreatedAt.IsZero() {
+		nanos = strconv.FormatInt(c.CreatedAt.UnixNano(), 10)
+	}
+	raw := nanos + ":" + c.ShortID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// After проверяет, следует ли URL-адрес за курсором в заданном порядке сортировки.
+func (c URLCursor) After(item URLItem, order SortOrder) bool {
+	cmp := item.CreatedAt.Compare(c.CreatedAt)
+	if cmp == 0 {
+		cmp = strings.Compare(item.ShortID, c.ShortID)
+	}
+	if order == SortAsc {
+		return cmp > 0
+	}
+	return cmp < 0
+}
+
+// ParseURLCursor возвращает курсор из его строкового представления.
+func ParseURLCursor(value string) (*URLCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	nanos, shortID, ok := strings.Cut(string(raw), ":")
+	if !ok || shortID == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	if nanos == "" {
+		return &URLCursor{ShortID: shortID}, nil
+	}
+
+	ts, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &URLCursor{CreatedAt: time.Unix(0, ts).UTC(), ShortID: shortID}, nil
+}
+
+// UserURLQuery представляет параметры выборки страницы URL-адресов пользователя.
+type UserURLQuery struct {
+	// Limit представляет максимальное количество URL-адресов на странице; 0 означает выборку без ограничения.
+	Limit int
+	// Cursor представляет позицию, после которой начинается страница; nil означает первую страницу.
+	Cursor *URLCursor
+	// Order представляет порядок сортировки по времени создания.
+	Order SortOrder
+	// Search представляет подстроку оригинального URL-адреса без учета регистра; пустая строка отключает поиск.
+	Search string
+	// IncludeDeleted представляет признак включения удаленных URL-адресов.
+	IncludeDeleted bool
+	// Tag представляет метку, которая должна быть у URL-адресов; пустая строка отключает фильтр.
+	Tag string
+}
+
+// UserURLPage представляет страницу URL-адресов пользователя.
+type UserURLPage struct {
+	// Items представляет URL-адреса страницы.
+	Items []URLItem
+	// Next представляет курсор следующей страницы; nil означает последнюю страницу.
+	Next *URLCursor
+}
+
+// UpdateURLRequest представляет запрос на изменение оригинального URL-адреса сокращенного URL-адреса.
+type UpdateURLRequest struct {
+	// URL представляет новый оригинальный URL-адрес.
+	URL string `json:"url"`
+}
+
+// SetTagsRequest представляет запрос на замену меток URL-адреса.
+type SetTagsRequest struct {
+	// Tags представляет новый список меток; пустой список удаляет все метки.
+	Tags []string `json:"tags"`
+}
+
+// TagCount представляет метку и количество URL-адресов пользователя с этой меткой.
+type TagCount struct {
+	// Tag представляет метку.
+	Tag string `json:"tag"`
+	// Count представляет количество не удаленных URL-адресов с меткой.
+	Count int64 `json:"count"`
+}
+
+// URLEdit представляет запись истории изменений оригинального URL-адреса.
+type URLEdit struct {
+	// ShortID представляет сокращенный идентификатор измененного URL-адреса.
+	ShortID string `json:"short_id"`
+	// UserID представляет идентификатор пользователя, изменившего URL-адрес.
+	UserID string `json:"user_id"`
+	// OldURL представляет оригинальный URL-адрес до изменения.
+	OldURL string `json:"old_url"`
+	// NewURL представляет оригинальный URL-адрес после изменения.
+	NewURL string `json:"new_url"`
+	// EditedAt представляет момент изменения.
+	EditedAt time.Time `json:"edited_at"`
 }
 
 // ShortenBatchDeleteRequest представляет запрос на удаление нескольких URL-адресов.
@@ -47,6 +216,99 @@ type BatchRequest struct {
 	URL string `json:"original_url"`
 	// CorrelationID представляет корреляционный идентификатор запроса.
 	CorrelationID string `json:"correlation_id"`
+	// Alias представляет пользовательский сокращенный идентификатор (необязательно).
+	Alias string `json:"alias,omitempty"`
+	// TTLSeconds представляет время жизни сокращенного URL-адреса в секундах (необязательно).
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"`
+	// ExpiresAt представляет момент истечения срока действия сокращенного URL-адреса (необязательно).
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Title представляет заголовок, показываемый на странице предпросмотра (необязательно).
+	Title string `json:"title,omitempty"`
+	// Preview представляет признак того, что при переходе нужно показывать страницу предпросмотра (необязательно).
+	Preview bool `json:"preview,omitempty"`
+	// Password представляет пароль, который нужно ввести перед переходом (необязательно).
+	Password string `json:"password,omitempty"`
+	// Tags представляет метки URL-адреса (необязательно).
+	Tags []string `json:"tags,omitempty"`
+	// Domain представляет домен сокращенного URL-адреса из списка настроенных доменов (необязательно).
+	Domain string `json:"domain,omitempty"`
+	// QR представляет признак того, что в ответ нужно добавить ссылку на QR-код (необязательно).
+	QR bool `json:"qr,omitempty"`
+}
+
+// ShortenParams представляет параметры сокращения одного URL-адреса.
+type ShortenParams struct {
+	// URL представляет URL-адрес, который необходимо сократить.
+	URL string
+	// Alias представляет пользовательский сокращенный идентификатор; если пуст, идентификатор генерируется.
+	Alias string
+	// ExpiresAt представляет момент истечения срока действия; nil означает бессрочный URL-адрес.
+	ExpiresAt *time.Time
+	// Title представляет заголовок URL-адреса.
+	Title string
+	// Preview представляет признак показа страницы предпросмотра вместо перенаправления.
+	Preview bool
+	// Password представляет пароль URL-адреса; пустая строка означает URL-адрес без пароля.
+	Password string
+	// Tags представляет метки URL-адреса.
+	Tags []string
+	// Domain представляет домен сокращенного URL-адреса; пустая строка означает домен по умолчанию.
+	Domain string
+}
+
+// ResolveExpiration возвращает момент истечения срока действия URL-адреса.
+// Эта функция принимает время жизни в секундах, абсолютный момент истечения и текущее время.
+// Допускается указать только один из параметров; если не указан ни один, возвращается nil.
+func ResolveExpiration(ttlSeconds int64, expiresAt *time.Time, now time.Time) (*time.Time, error) {
+	if ttlSeconds != 0 && expiresAt != nil {
+		return nil, fmt.Errorf("%w: ttl_seconds and expires_at are mutually exclusive", ErrInvalidExpiration)
+	}
+
+	if ttlSeconds < 0 {
+		return nil, fmt.Errorf("%w: ttl_seconds must be positive", ErrInvalidExpiration)
+	}
+
+	if ttlSeconds > 0 {
+		expires := now.Add(time.Duration(ttlSeconds) * time.Second)
+		return &expires, nil
+	}
+
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidExpiration)
+	}
+
+	return expiresAt, nil
+}
+
+// QRCodePath представляет суффикс пути QR-кода сокращенного URL-адреса.
+const QRCodePath = "/qr"
+
+// UnlockPath представляет суффикс пути ввода пароля защищенного сокращенного URL-адреса.
+const UnlockPath = "/unlock"
+
+// shortKeySeparator представляет разделитель кода и домена в ключе сокращенного URL-адреса.
+// Символ не допускается ни в кодах, ни в именах хостов и не требует экранирования в пути URL-адреса.
+const shortKeySeparator = "@"
+
+// ShortKey возвращает ключ сокращенного URL-адреса, уникальный среди всех доменов:
+// для домена по умолчанию ключ совпадает с кодом, для остальных доменов имеет вид "code@host".
+func ShortKey(domain string, code string) string {
+	if domain == "" {
+		return code
+	}
+	return code + shortKeySeparator + domain
+}
+
+// IsShortCode проверяет, что значение из пути запроса является кодом, а не ключом сокращенного URL-адреса:
+// ключ вида "code@host" позволил бы открыть ссылку другого домена через домен запроса.
+func IsShortCode(value string) bool {
+	return value != "" && !strings.Contains(value, shortKeySeparator)
+}
+
+// SplitShortKey возвращает код и домен ключа сокращенного URL-адреса.
+func SplitShortKey(key string) (code string, domain string) {
+	code, domain, _ = strings.Cut(key, shortKeySeparator)
+	return code, domain
 }
 
 // ShortenBatchResponse представляет ответ на запрос на сокращение нескольких URL-адресов.
@@ -58,6 +320,8 @@ type BatchResponse struct {
 	Result string `json:"short_url"`
 	// CorrelationID представляет корреляционный идентификатор запроса.
 	CorrelationID string `json:"correlation_id"`
+	// QR представляет ссылку на QR-код сокращенного URL-адреса, если он был запрошен.
+	QR string `json:"qr,omitempty"`
 }
 
 // ErrorResponse представляет ответ с ошибкой.
@@ -66,16 +330,63 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// URLPolicyErrorResponse представляет ответ с ошибкой проверки URL-адреса.
+type URLPolicyErrorResponse struct {
+	// Error представляет текст ошибки.
+	Error string `json:"error"`
+	// Rule представляет название сработавшего правила.
+	Rule string `json:"rule"`
+	// URL представляет отклоненный URL-адрес.
+	URL string `json:"url"`
+}
+
 // URLItem представляет элемент URL-адреса.
 type URLItem struct {
-	// ShortID представляет сокращенный идентификатор URL-адреса.
+	// ShortID представляет сокращенный идентификатор URL-адреса: код для домена по умолчанию
+	// и ключ вида "code@host" для остальных доменов (см. ShortKey).
 	ShortID string `json:"short_id"`
+	// Domain представляет домен сокращенного URL-адреса; пустая строка означает домен по умолчанию.
+	Domain string `json:"domain,omitempty"`
 	// URL представляет оригинальный URL-адрес.
 	URL string `json:"original_url"`
 	// UserID представляет идентификатор пользователя.
 	UserID string `json:"user_id"`
 	// IsDeleted представляет флаг, указывающий, удален ли URL-адрес.
 	IsDeleted bool `json:"id_deleted"`
+	// IsCustom представляет флаг, указывающий, что сокращенный идентификатор задан пользователем.
+	IsCustom bool `json:"is_custom,omitempty"`
+	// ExpiresAt представляет момент истечения срока действия URL-адреса; nil означает бессрочный URL-адрес.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Title представляет заголовок, заданный владельцем URL-адреса.
+	Title string `json:"title,omitempty"`
+	// Preview представляет флаг, указывающий, что вместо перенаправления показывается страница предпросмотра.
+	Preview bool `json:"preview,omitempty"`
+	// CreatedAt представляет момент создания URL-адреса; нулевое значение у URL-адресов, созданных до появления поля.
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	// PasswordHash представляет bcrypt-хэш пароля URL-адреса; пустая строка означает URL-адрес без пароля.
+	PasswordHash string `json:"password_hash,omitempty"`
+	// UpdatedAt представляет момент последнего изменения URL-адреса.
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	// LastAccessedAt представляет момент последнего перехода по URL-адресу; nil означает, что переходов не было.
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	// Tags представляет метки URL-адреса, заданные владельцем.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Code возвращает код сокращенного URL-адреса без домена.
+func (i URLItem) Code() string {
+	code, _ := SplitShortKey(i.ShortID)
+	return code
+}
+
+// IsExpired проверяет, истек ли срок действия URL-адреса к заданному моменту.
+func (i URLItem) IsExpired(now time.Time) bool {
+	return i.ExpiresAt != nil && !now.Before(*i.ExpiresAt)
+}
+
+// IsProtected проверяет, защищен ли URL-адрес паролем.
+func (i URLItem) IsProtected() bool {
+	return i.PasswordHash != ""
 }
 
 // NewURLItem возвращает новый элемент URL-адреса.
@@ -89,6 +400,262 @@ func NewURLItem(url string, id string, userID string, isDeleted bool) *URLItem {
 	}
 }
 
+// DeleteStatus представляет результат удаления URL-адреса.
+type DeleteStatus string
+
+// DeleteStatusDeleted означает, что URL-адрес помечен как удаленный.
+const DeleteStatusDeleted DeleteStatus = "deleted"
+
+// DeleteStatusNotOwned означает, что URL-адрес принадлежит другому пользователю и не был удален.
+const DeleteStatusNotOwned DeleteStatus = "not_owned"
+
+// DeleteStatusNotFound означает, что URL-адрес с заданным идентификатором не найден.
+const DeleteStatusNotFound DeleteStatus = "not_found"
+
+// DeleteResult представляет результат удаления одного URL-адреса.
+type DeleteResult struct {
+	// ShortID представляет сокращенный идентификатор URL-адреса.
+	ShortID string `json:"short_id"`
+	// Status представляет результат удаления.
+	Status DeleteStatus `json:"status"`
+}
+
+// DeletionJobStatus представляет состояние задачи удаления URL-адресов.
+type DeletionJobStatus string
+
+// DeletionJobPending означает, что задача ожидает выполнения.
+const DeletionJobPending DeletionJobStatus = "pending"
+
+// DeletionJobRunning означает, что задача выполняется.
+const DeletionJobRunning DeletionJobStatus = "running"
+
+// DeletionJobDone означает, что задача выполнена.
+const DeletionJobDone DeletionJobStatus = "done"
+
+// DeletionJobFailed означает, что задача не выполнена после всех попыток.
+const DeletionJobFailed DeletionJobStatus = "failed"
+
+// DeletionJob представляет задачу удаления URL-адресов пользователя.
+type DeletionJob struct {
+	// ID представляет идентификатор задачи.
+	ID string `json:"id"`
+	// UserID представляет идентификатор пользователя, запросившего удаление.
+	UserID string `json:"user_id"`
+	// ShortIDs представляет список идентификаторов URL-адресов для удаления.
+	ShortIDs []string `json:"short_ids"`
+	// Status представляет состояние задачи.
+	Status DeletionJobStatus `json:"status"`
+	// Attempts представляет количество выполненных попыток.
+	Attempts int `json:"attempts"`
+	// Results представляет результаты удаления по каждому идентификатору.
+	Results []DeleteResult `json:"results,omitempty"`
+	// Error представляет текст последней ошибки выполнения.
+	Error string `json:"error,omitempty"`
+	// CreatedAt представляет время создания задачи.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt представляет время последнего изменения задачи.
+	UpdatedAt time.Time `json:"updated_at"`
+	// NextAttemptAt представляет время, не раньше которого будет выполнена следующая попытка.
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+}
+
+// NewDeletionJob возвращает новую задачу удаления URL-адресов в состоянии DeletionJobPending.
+// Эта функция принимает идентификатор задачи, идентификатор пользователя, список идентификаторов URL-адресов и время создания.
+func NewDeletionJob(id string, userID string, shortIDs []string, now time.Time) *DeletionJob {
+	return &DeletionJob{
+		ID:            id,
+		UserID:        userID,
+		ShortIDs:      shortIDs,
+		Status:        DeletionJobPending,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		NextAttemptAt: now,
+	}
+}
+
+// ShortenBatchDeleteResponse представляет ответ на запрос удаления нескольких URL-адресов.
+type ShortenBatchDeleteResponse struct {
+	// JobID представляет идентификатор задачи удаления.
+	JobID string `json:"job_id"`
+}
+
+// DeletionJobResponse представляет ответ с состоянием задачи удаления URL-адресов.
+type DeletionJobResponse struct {
+	// JobID представляет идентификатор задачи удаления.
+	JobID string `json:"job_id"`
+	// Status представляет состояние задачи.
+	Status DeletionJobStatus `json:"status"`
+	// Total представляет количество URL-адресов в задаче.
+	Total int `json:"total"`
+	// Processed представляет количество обработанных URL-адресов.
+	Processed int `json:"processed"`
+	// Attempts представляет количество выполненных попыток.
+	Attempts int `json:"attempts"`
+	// Results представляет результаты удаления по каждому идентификатору.
+	Results []DeleteResult `json:"results"`
+	// Error представляет текст последней ошибки выполнения.
+	Error string `json:"error,omitempty"`
+	// CreatedAt представляет время создания задачи.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt представляет время последнего изменения задачи.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// APIKeyScope представляет область доступа API-ключа.
+type APIKeyScope string
+
+// APIKeyScopeShorten разрешает сокращать URL-адреса и изменять URL-адреса пользователя.
+const APIKeyScopeShorten APIKeyScope = "shorten"
+
+// APIKeyScopeRead разрешает читать URL-адреса пользователя, их историю, метки и статистику.
+const APIKeyScopeRead APIKeyScope = "read"
+
+// APIKeyScopeDelete разрешает удалять URL-адреса пользователя.
+const APIKeyScopeDelete APIKeyScope = "delete"
+
+// APIKeyPrefix представляет префикс API-ключей, по которому они отличаются от JWT-токенов.
+const APIKeyPrefix = "sk_"
+
+// APIKey представляет API-ключ пользователя для машинных клиентов.
+// Сам ключ не хранится: по нему вычисляется хеш, а для отображения сохраняется начало ключа.
+type APIKey struct {
+	// ID представляет идентификатор API-ключа.
+	ID string `json:"id"`
+	// UserID представляет идентификатор пользователя, от имени которого действует ключ.
+	UserID string `json:"user_id"`
+	// Name представляет название ключа, заданное пользователем.
+	Name string `json:"name"`
+	// Prefix представляет начало ключа для отображения в списке ключей.
+	Prefix string `json:"prefix"`
+	// KeyHash представляет хеш ключа.
+	KeyHash string `json:"key_hash"`
+	// Scopes представляет области доступа ключа.
+	Scopes []APIKeyScope `json:"scopes"`
+	// CreatedAt представляет время создания ключа.
+	CreatedAt time.Time `json:"created_at"`
+	// RevokedAt представляет время отзыва ключа; nil, если ключ действует.
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope проверяет, разрешена ли ключу область доступа.
+func (k APIKey) HasScope(scope APIKeyScope) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// IsRevoked проверяет, отозван ли ключ.
+func (k APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// CreateAPIKeyRequest представляет запрос на создание API-ключа.
+type CreateAPIKeyRequest struct {
+	// Name представляет название ключа.
+	Name string `json:"name"`
+	// Scopes представляет области доступа ключа.
+	Scopes []APIKeyScope `json:"scopes"`
+}
+
+// APIKeyResponse представляет API-ключ в ответе.
+type APIKeyResponse struct {
+	// ID представляет идентификатор API-ключа.
+	ID string `json:"id"`
+	// Name представляет название ключа.
+	Name string `json:"name"`
+	// Prefix представляет начало ключа.
+	Prefix string `json:"prefix"`
+	// Scopes представляет области доступа ключа.
+	Scopes []APIKeyScope `json:"scopes"`
+	// CreatedAt представляет время создания ключа.
+	CreatedAt time.Time `json:"created_at"`
+	// RevokedAt представляет время отзыва ключа, если он отозван.
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	// Key представляет сам ключ; передается только в ответе на создание ключа.
+	Key string `json:"key,omitempty"`
+}
+
+// NewAPIKeyResponse возвращает представление API-ключа для ответа без хеша ключа.
+func NewAPIKeyResponse(key APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
+
+// JSONWebKey представляет открытый ключ проверки JWT-токенов в формате JSON Web Key (RFC 7517).
+type JSONWebKey struct {
+	// KeyType представляет тип ключа: RSA или OKP.
+	KeyType string `json:"kty"`
+	// KeyID представляет идентификатор ключа, совпадающий с заголовком kid токенов.
+	KeyID string `json:"kid"`
+	// Use представляет назначение ключа.
+	Use string `json:"use"`
+	// Algorithm представляет алгоритм подписи.
+	Algorithm string `json:"alg"`
+	// Modulus представляет модуль ключа RSA.
+	Modulus string `json:"n,omitempty"`
+	// Exponent представляет открытую экспоненту ключа RSA.
+	Exponent string `json:"e,omitempty"`
+	// Curve представляет кривую ключа OKP.
+	Curve string `json:"crv,omitempty"`
+	// X представляет открытый ключ OKP.
+	X string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet представляет набор открытых ключей проверки JWT-токенов.
+type JSONWebKeySet struct {
+	// Keys представляет ключи набора.
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// Click представляет переход по сокращенному URL-адресу.
+type Click struct {
+	// ShortID представляет сокращенный идентификатор URL-адреса.
+	ShortID string `json:"short_id"`
+	// TS представляет момент перехода.
+	TS time.Time `json:"ts"`
+	// Referrer представляет значение заголовка Referer.
+	Referrer string `json:"referrer,omitempty"`
+	// UserAgent представляет значение заголовка User-Agent.
+	UserAgent string `json:"user_agent,omitempty"`
+	// IPHash представляет хеш IP-адреса клиента.
+	IPHash string `json:"ip_hash,omitempty"`
+}
+
+// URLStats представляет статистику переходов по сокращенному URL-адресу.
+type URLStats struct {
+	// ShortID представляет сокращенный идентификатор URL-адреса.
+	ShortID string `json:"short_id"`
+	// Total представляет общее количество переходов.
+	Total int64 `json:"total"`
+	// Daily представляет количество переходов по дням (UTC) в порядке возрастания даты.
+	Daily []DailyClicks `json:"daily"`
+}
+
+// DailyClicks представляет количество переходов за один день.
+type DailyClicks struct {
+	// Date представляет дату в формате YYYY-MM-DD.
+	Date string `json:"date"`
+	// Count представляет количество переходов.
+	Count int64 `json:"count"`
+}
+
+// ClickDateLayout представляет формат даты в статистике переходов.
+const ClickDateLayout = "2006-01-02"
+
+// InternalStats представляет агрегированную статистику сервиса.
+type InternalStats struct {
+	// URLs представляет количество сокращенных URL-адресов.
+	URLs int64 `json:"urls"`
+	// Users представляет количество пользователей, сокративших хотя бы один URL-адрес.
+	Users int64 `json:"users"`
+	// ShortCodeLength представляет текущую длину генерируемых сокращенных идентификаторов.
+	ShortCodeLength int `json:"short_code_length"`
+}
+
 // LogAuditItem представляет элемент аудита логов.
 type LogAuditItem struct {
 	// TS представляет метку времени аудита.
@@ -99,6 +666,10 @@ type LogAuditItem struct {
 	UserID *string `json:"user_id,omitempty"`
 	// URL представляет URL-адрес, связанный с аудитом.
 	URL string `json:"url"`
+	// ShortID представляет сокращенный идентификатор измененного URL-адреса; заполняется для действия изменения.
+	ShortID string `json:"short_id,omitempty"`
+	// OldURL представляет оригинальный URL-адрес до изменения; заполняется для действия изменения.
+	OldURL string `json:"old_url,omitempty"`
 }
 
 // NewLogAuditItem возвращает новый элемент аудита логов.
@@ -116,6 +687,15 @@ func NewLogAuditItem(url string, userID string, action LogAction) *LogAuditItem
 	}
 }
 
+// NewLogAuditEditItem возвращает новый элемент аудита изменения оригинального URL-адреса.
+// URL-адресом аудита является новый оригинальный URL-адрес; прежний сохраняется вместе с сокращенным идентификатором.
+func NewLogAuditEditItem(edit URLEdit) *LogAuditItem {
+	item := NewLogAuditItem(edit.NewURL, edit.UserID, LogActionEdit)
+	item.ShortID = edit.ShortID
+	item.OldURL = edit.OldURL
+	return item
+}
+
 // LogAction представляет тип действия аудита.
 type LogAction string
 
@@ -124,3 +704,6 @@ const LogActionShorten LogAction = "shorten"
 
 // LogActionFollow представляет действие перехода по URL-адресу.
 const LogActionFollow LogAction = "follow"
+
+// LogActionEdit представляет действие изменения оригинального URL-адреса.
+const LogActionEdit LogAction = "edit"
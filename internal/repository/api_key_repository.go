@@ -0,0 +1,23 @@
+// Package repository содержит интерфейс для работы с хранилищем API-ключей.
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+)
+
+// APIKeyRepository представляет интерфейс для работы с хранилищем API-ключей.
+type APIKeyRepository interface {
+	// CreateKey сохраняет новый API-ключ. Эта функция возвращает ErrRepoTooManyAPIKeys,
+	// если у пользователя уже есть maxActive действующих ключей; проверка и сохранение выполняются атомарно.
+	CreateKey(ctx context.Context, key model.APIKey, maxActive int) error
+	// FindKeyByHash находит API-ключ по хешу ключа.
+	FindKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error)
+	// FindKeysByUser возвращает API-ключи пользователя в порядке создания, включая отозванные.
+	FindKeysByUser(ctx context.Context, userID string) ([]model.APIKey, error)
+	// RevokeKey отзывает API-ключ пользователя. Эта функция возвращает ErrRepoNotFound,
+	// если ключ не найден, принадлежит другому пользователю или уже отозван.
+	RevokeKey(ctx context.Context, userID string, id string, revokedAt time.Time) error
+}
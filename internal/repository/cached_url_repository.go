@@ -0,0 +1,219 @@
+// Package repository содержит реализацию кэширующего декоратора репозитория URL-адресов.
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/pkg/cache"
+	"go.uber.org/zap"
+)
+
+// negativeCacheValue представляет значение кэша для неизвестного сокращенного идентификатора.
+var negativeCacheValue = []byte("null")
+
+// CacheStats представляет счетчики обращений к кэшу.
+type CacheStats struct {
+	// Hits представляет количество найденных в кэше URL-адресов.
+	Hits int64
+	// NegativeHits представляет количество найденных в кэше отметок об отсутствии URL-адреса.
+	NegativeHits int64
+	// Misses представляет количество обращений, которые были переданы в репозиторий.
+	Misses int64
+}
+
+// CachedURLRepository представляет декоратор репозитория URL-адресов, кэширующий поиск по сокращенному идентификатору.
+// Отсутствующие идентификаторы также кэшируются на более короткое время.
+type CachedURLRepository struct {
+	// URLRepository представляет декорируемый репозиторий.
+	URLRepository
+	// cache представляет хранилище кэша.
+	cache cache.Cache
+	// ttl представляет время жизни найденного URL-адреса в кэше.
+	ttl time.Duration
+	// negativeTTL представляет время жизни отметки об отсутствии URL-адреса в кэше.
+	negativeTTL time.Duration
+	// logger представляет логгер для записи сообщений.
+	logger zap.SugaredLogger
+	// hits представляет количество найденных в кэше URL-адресов.
+	hits atomic.Int64
+	// negativeHits представляет количество найденных в кэше отметок об отсутствии URL-адреса.
+	negativeHits atomic.Int64
+	// misses представляет количество обращений, которые были переданы в репозиторий.
+	misses atomic.Int64
+}
+
+// NewCachedURLRepository возвращает новый экземпляр CachedURLRepository.
+// Эта функция принимает декорируемый репозиторий, хранилище кэша, время жизни найденных и отсутствующих URL-адресов и логгер.
+func NewCachedURLRepository(
+	repo URLRepository,
+	c cache.Cache,
+	ttl time.Duration,
+	negativeTTL time.Duration,
+	logger zap.SugaredLogger,
+) *CachedURLRepository {
+	return &CachedURLRepository{
+		URLRepository: repo,
+		cache:         c,
+		ttl:           ttl,
+		negativeTTL:   negativeTTL,
+		logger:        logger,
+	}
+}
+
+// CreateURL создает новые URL-адреса и сбрасывает отметки об их отсутствии в кэше.
+func (r *CachedURLRepository) CreateURL(ctx context.Context, items []model.URLItem) error {
+	if err := r.URLRepository.CreateURL(ctx, items); err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ShortID)
+	}
+	r.invalidate(ctx, ids)
+	return nil
+}
+
+// DeleteURL помечает удаленными URL-адреса пользователя и удаляет их из кэша.
+func (r *CachedURLRepository) DeleteURL(ctx context.Context, userID string, ids []string) ([]model.DeleteResult, error) {
+	results, err := r.URLRepository.DeleteURL(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := make([]string, 0, len(results))
+	for _, result := range results {
+		if result.Status == model.DeleteStatusDeleted {
+			deleted = append(deleted, result.ShortID)
+		}
+	}
+	r.invalidate(ctx, deleted)
+	return results, nil
+}
+
+// UpdateURL изменяет оригинальный URL-адрес и удаляет прежнее значение из кэша.
+func (r *CachedURLRepository) UpdateURL(ctx context.Context, edit model.URLEdit) (*model.URLEdit, error) {
+	result, err := r.URLRepository.UpdateURL(ctx, edit)
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidate(ctx, []string{edit.ShortID})
+	return result, nil
+}
+
+// SetTags заменяет метки URL-адреса и удаляет прежнее значение из кэша.
+func (r *CachedURLRepository) SetTags(ctx context.Context, userID string, shortID string, tags []string, updatedAt time.Time) error {
+	if err := r.URLRepository.SetTags(ctx, userID, shortID, tags, updatedAt); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, []string{shortID})
+	return nil
+}
+
+// PurgeExpiredURL физически удаляет URL-адреса с истекшим сроком действия и удаляет их из кэша.
+func (r *CachedURLRepository) PurgeExpiredURL(ctx context.Context, ids []string, before time.Time) (int64, error) {
+	purged, err := r.URLRepository.PurgeExpiredURL(ctx, ids, before)
+	if err != nil {
+		return 0, err
+	}
+	r.invalidate(ctx, ids)
+	return purged, nil
+}
+
+// FindURLByID находит URL-адрес по идентификатору сначала в кэше, затем в репозитории.
+// Найденный URL-адрес кэшируется не дольше срока его действия.
+func (r *CachedURLRepository) FindURLByID(ctx context.Context, id string) (*model.URLItem, error) {
+	value, err := r.cache.Get(ctx, id)
+	switch {
+	case err == nil:
+		item, decodeErr := decodeCachedURLItem(value)
+		if decodeErr == nil {
+			if item == nil {
+				r.negativeHits.Add(1)
+				return nil, ErrRepoNotFound
+			}
+			r.hits.Add(1)
+			return item, nil
+		}
+		r.logger.Warnf("failed to decode cached url %s: %v", id, decodeErr)
+	case !errors.Is(err, cache.ErrCacheMiss):
+		r.logger.Warnf("failed to read url %s from cache: %v", id, err)
+	}
+
+	r.misses.Add(1)
+	item, err := r.URLRepository.FindURLByID(ctx, id)
+	if err != nil && !errors.Is(err, ErrRepoNotFound) {
+		return nil, err
+	}
+
+	if item == nil {
+		r.store(ctx, id, negativeCacheValue, r.negativeTTL)
+		return nil, ErrRepoNotFound
+	}
+
+	ttl := r.ttl
+	if item.ExpiresAt != nil {
+		ttl = min(ttl, time.Until(*item.ExpiresAt))
+	}
+	if ttl > 0 {
+		if value, err := json.Marshal(item); err == nil {
+			r.store(ctx, id, value, ttl)
+		}
+	}
+	return item, nil
+}
+
+// Stats возвращает счетчики обращений к кэшу.
+func (r *CachedURLRepository) Stats() CacheStats {
+	return CacheStats{
+		Hits:         r.hits.Load(),
+		NegativeHits: r.negativeHits.Load(),
+		Misses:       r.misses.Load(),
+	}
+}
+
+// Close закрывает декорируемый репозиторий и хранилище кэша, если они требуют закрытия.
+func (r *CachedURLRepository) Close() error {
+	var errs []error
+	if closer, ok := r.URLRepository.(io.Closer); ok {
+		errs = append(errs, closer.Close())
+	}
+	if closer, ok := r.cache.(io.Closer); ok {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// store сохраняет значение в кэше; ошибка кэша не является ошибкой операции.
+func (r *CachedURLRepository) store(ctx context.Context, id string, value []byte, ttl time.Duration) {
+	if err := r.cache.Set(ctx, id, value, ttl); err != nil {
+		r.logger.Warnf("failed to write url %s to cache: %v", id, err)
+	}
+}
+
+// invalidate удаляет значения из кэша; ошибка кэша не является ошибкой операции.
+func (r *CachedURLRepository) invalidate(ctx context.Context, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	if err := r.cache.Delete(ctx, ids...); err != nil {
+		r.logger.Warnf("failed to invalidate urls %v in cache: %v", ids, err)
+	}
+}
+
+// decodeCachedURLItem декодирует значение кэша; nil означает отметку об отсутствии URL-адреса.
+func decodeCachedURLItem(value []byte) (*model.URLItem, error) {
+	var item *model.URLItem
+	if err := json.Unmarshal(value, &item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
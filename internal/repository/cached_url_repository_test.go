@@ -0,0 +1,85 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCachedURLRepository_FindURLByID(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	mockRepo := new(repository.MockURLRepository)
+	repo := repository.NewCachedURLRepository(mockRepo, cache.NewLRUCache(10), time.Minute, time.Minute, *logger)
+
+	item := model.NewURLItem("https://example.com", "abc", "user", false)
+	mockRepo.On("FindURLByID", mock.Anything, "abc").Return(item, nil).Once()
+	mockRepo.On("FindURLByID", mock.Anything, "missing").Return((*model.URLItem)(nil), repository.ErrRepoNotFound).Once()
+
+	for range 3 {
+		found, err := repo.FindURLByID(ctx, "abc")
+		require.NoError(t, err)
+		assert.Equal(t, item, found)
+
+		_, err = repo.FindURLByID(ctx, "missing")
+		assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+	}
+
+	mockRepo.AssertExpectations(t)
+	assert.Equal(t, repository.CacheStats{Hits: 2, NegativeHits: 2, Misses: 2}, repo.Stats())
+}
+
+func TestCachedURLRepository_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	mockRepo := new(repository.MockURLRepository)
+	repo := repository.NewCachedURLRepository(mockRepo, cache.NewLRUCache(10), time.Minute, time.Minute, *logger)
+
+	// Отметка об отсутствии сбрасывается при создании URL-адреса с тем же идентификатором.
+	mockRepo.On("FindURLByID", mock.Anything, "abc").Return((*model.URLItem)(nil), repository.ErrRepoNotFound).Once()
+	_, err := repo.FindURLByID(ctx, "abc")
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+
+	item := model.NewURLItem("https://example.com", "abc", "user", false)
+	mockRepo.On("CreateURL", mock.Anything, []model.URLItem{*item}).Return(nil).Once()
+	require.NoError(t, repo.CreateURL(ctx, []model.URLItem{*item}))
+
+	mockRepo.On("FindURLByID", mock.Anything, "abc").Return(item, nil).Once()
+	found, err := repo.FindURLByID(ctx, "abc")
+	require.NoError(t, err)
+	assert.False(t, found.IsDeleted)
+
+	// Удаленный URL-адрес перечитывается из репозитория.
+	mockRepo.On("DeleteURL", mock.Anything, "user", []string{"abc"}).
+		Return([]model.DeleteResult{{ShortID: "abc", Status: model.DeleteStatusDeleted}}, nil).Once()
+	_, err = repo.DeleteURL(ctx, "user", []string{"abc"})
+	require.NoError(t, err)
+
+	deleted := model.NewURLItem("https://example.com", "abc", "user", true)
+	mockRepo.On("FindURLByID", mock.Anything, "abc").Return(deleted, nil).Once()
+	found, err = repo.FindURLByID(ctx, "abc")
+	require.NoError(t, err)
+	assert.True(t, found.IsDeleted)
+
+	// Измененный URL-адрес перечитывается из репозитория.
+	edit := model.URLEdit{ShortID: "abc", UserID: "user", NewURL: "https://edited.com"}
+	mockRepo.On("UpdateURL", mock.Anything, edit).Return(&edit, nil).Once()
+	_, err = repo.UpdateURL(ctx, edit)
+	require.NoError(t, err)
+
+	edited := model.NewURLItem("https://edited.com", "abc", "user", false)
+	mockRepo.On("FindURLByID", mock.Anything, "abc").Return(edited, nil).Once()
+	found, err = repo.FindURLByID(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "https://edited.com", found.URL)
+
+	mockRepo.AssertExpectations(t)
+}
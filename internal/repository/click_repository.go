@@ -0,0 +1,18 @@
+// Package repository содержит интерфейс репозитория переходов по сокращенным URL-адресам.
+package repository
+
+import (
+	"context"
+
+	"github.com/oegegr/shortener/internal/model"
+)
+
+// ClickRepository представляет интерфейс для работы с репозиторием переходов по URL-адресам.
+type ClickRepository interface {
+	// SaveClicks сохраняет переходы по URL-адресам.
+	SaveClicks(ctx context.Context, clicks []model.Click) error
+	// GetClickStats возвращает статистику переходов по сокращенному идентификатору URL-адреса.
+	GetClickStats(ctx context.Context, shortID string) (*model.URLStats, error)
+	// DeleteClicks удаляет переходы по заданным сокращенным идентификаторам URL-адресов.
+	DeleteClicks(ctx context.Context, shortIDs []string) error
+}
@@ -0,0 +1,160 @@
+// Package repository содержит реализацию хранилища API-ключей в базе данных.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"go.uber.org/zap"
+)
+
+// apiKeyColumns представляет столбцы таблицы api_key в порядке сканирования.
+const apiKeyColumns = "id, user_id, name, prefix, key_hash, scopes, created_at, revoked_at"
+
+// DBAPIKeyRepository представляет хранилище API-ключей в базе данных.
+type DBAPIKeyRepository struct {
+	// db представляет подключение к базе данных.
+	db *sql.DB
+	// logger представляет логгер для записи сообщений.
+	logger zap.SugaredLogger
+}
+
+// NewDBAPIKeyRepository возвращает новый экземпляр DBAPIKeyRepository.
+// Эта функция принимает подключение к базе данных и логгер.
+func NewDBAPIKeyRepository(db *sql.DB, logger zap.SugaredLogger) *DBAPIKeyRepository {
+	return &DBAPIKeyRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateKey сохраняет новый API-ключ в базе данных, если у пользователя меньше maxActive действующих ключей.
+// Транзакционная advisory-блокировка по идентификатору пользователя сериализует параллельное создание ключей одного пользователя,
+// поэтому подсчет действующих ключей и вставка не могут превысить предел.
+func (r *DBAPIKeyRepository) CreateKey(ctx context.Context, key model.APIKey, maxActive int) error {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('api_key:' || $1))", key.UserID); err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+		return err
+	}
+
+	var active int
+	err = tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM api_key WHERE user_id = $1 AND revoked_at IS NULL",
+		key.UserID,
+	).Scan(&active)
+	if err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+		return err
+	}
+	if active >= maxActive {
+		return ErrRepoTooManyAPIKeys
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO api_key ("+apiKeyColumns+") VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7, $8)",
+		key.ID, key.UserID, key.Name, key.Prefix, key.KeyHash, string(scopes), key.CreatedAt, key.RevokedAt,
+	)
+	if err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindKeyByHash находит API-ключ в базе данных по хешу ключа.
+func (r *DBAPIKeyRepository) FindKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_key WHERE key_hash = $1", keyHash)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRepoNotFound
+	}
+	if err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+		return nil, err
+	}
+	return key, nil
+}
+
+// FindKeysByUser возвращает API-ключи пользователя из базы данных в порядке создания.
+func (r *DBAPIKeyRepository) FindKeysByUser(ctx context.Context, userID string) ([]model.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+apiKeyColumns+" FROM api_key WHERE user_id = $1 ORDER BY created_at, id",
+		userID,
+	)
+	if err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row deserialization error %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeKey отзывает API-ключ пользователя в базе данных.
+func (r *DBAPIKeyRepository) RevokeKey(ctx context.Context, userID string, id string, revokedAt time.Time) error {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE api_key SET revoked_at = $3 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL",
+		id, userID, revokedAt,
+	)
+	if err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrRepoNotFound
+	}
+	return nil
+}
+
+// scanAPIKey читает API-ключ из строки результата запроса.
+func scanAPIKey(row rowScanner) (*model.APIKey, error) {
+	var key model.APIKey
+	var scopes []byte
+	var revokedAt sql.NullTime
+
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, &scopes, &key.CreatedAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(scopes, &key.Scopes); err != nil {
+		return nil, fmt.Errorf("failed to decode scopes of api key %s: %w", key.ID, err)
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return &key, nil
+}
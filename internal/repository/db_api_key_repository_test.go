@@ -0,0 +1,89 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestDBAPIKeyRepository(t *testing.T) {
+	db := openTestDB(t, "api_key")
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	repo := repository.NewDBAPIKeyRepository(db, *logger)
+
+	createdAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.CreateKey(ctx, model.APIKey{ID: "first", UserID: "user", Name: "ci", Prefix: "sk_first", KeyHash: "hash-1", Scopes: []model.APIKeyScope{model.APIKeyScopeRead}, CreatedAt: createdAt}, 10))
+	require.NoError(t, repo.CreateKey(ctx, model.APIKey{ID: "second", UserID: "user", KeyHash: "hash-2", Scopes: []model.APIKeyScope{model.APIKeyScopeDelete}, CreatedAt: createdAt.Add(time.Minute)}, 10))
+	require.NoError(t, repo.CreateKey(ctx, model.APIKey{ID: "foreign", UserID: "stranger", KeyHash: "hash-3", Scopes: []model.APIKeyScope{model.APIKeyScopeRead}, CreatedAt: createdAt}, 10))
+
+	key, err := repo.FindKeyByHash(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, "first", key.ID)
+	assert.Equal(t, "ci", key.Name)
+	assert.Equal(t, "sk_first", key.Prefix)
+	assert.Equal(t, []model.APIKeyScope{model.APIKeyScopeRead}, key.Scopes)
+	assert.Nil(t, key.RevokedAt)
+
+	_, err = repo.FindKeyByHash(ctx, "missing")
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+
+	revokedAt := createdAt.Add(time.Hour)
+	assert.ErrorIs(t, repo.RevokeKey(ctx, "user", "foreign", revokedAt), repository.ErrRepoNotFound)
+	require.NoError(t, repo.RevokeKey(ctx, "user", "first", revokedAt))
+	assert.ErrorIs(t, repo.RevokeKey(ctx, "user", "first", revokedAt), repository.ErrRepoNotFound)
+
+	keys, err := repo.FindKeysByUser(ctx, "user")
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "first", keys[0].ID)
+	require.NotNil(t, keys[0].RevokedAt)
+	assert.True(t, revokedAt.Equal(*keys[0].RevokedAt))
+	assert.Equal(t, []model.APIKeyScope{model.APIKeyScopeDelete}, keys[1].Scopes)
+}
+
+func TestDBAPIKeyRepository_Limit(t *testing.T) {
+	db := openTestDB(t, "api_key")
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	repo := repository.NewDBAPIKeyRepository(db, *logger)
+
+	const limit = 3
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := model.APIKey{ID: fmt.Sprintf("key-%d", i), UserID: "user", KeyHash: fmt.Sprintf("hash-%d", i), Scopes: []model.APIKeyScope{model.APIKeyScopeRead}, CreatedAt: time.Now()}
+			errs[i] = repo.CreateKey(ctx, key, limit)
+		}()
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		assert.ErrorIs(t, err, repository.ErrRepoTooManyAPIKeys)
+	}
+	// Параллельные запросы не превышают предел действующих ключей.
+	assert.Equal(t, limit, created)
+
+	// Отозванные ключи не учитываются.
+	keys, err := repo.FindKeysByUser(ctx, "user")
+	require.NoError(t, err)
+	require.Len(t, keys, limit)
+	require.NoError(t, repo.RevokeKey(ctx, "user", keys[0].ID, time.Now()))
+	require.NoError(t, repo.CreateKey(ctx, model.APIKey{ID: "replacement", UserID: "user", KeyHash: "hash-replacement", Scopes: []model.APIKeyScope{model.APIKeyScopeRead}, CreatedAt: time.Now()}, limit))
+}
@@ -0,0 +1,94 @@
+// Package repository содержит реализацию репозитория переходов по URL-адресам в базе данных.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"go.uber.org/zap"
+)
+
+// DBClickRepository представляет репозиторий для работы с переходами по URL-адресам в базе данных.
+type DBClickRepository struct {
+	// db представляет подключение к базе данных.
+	db *sql.DB
+	// logger представляет логгер для записи сообщений.
+	logger zap.SugaredLogger
+}
+
+// NewDBClickRepository возвращает новый экземпляр DBClickRepository.
+// Эта функция принимает подключение к базе данных и логгер.
+func NewDBClickRepository(db *sql.DB, logger zap.SugaredLogger) *DBClickRepository {
+	return &DBClickRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveClicks сохраняет переходы по URL-адресам в базе данных.
+// Эта функция принимает список переходов для сохранения.
+func (r *DBClickRepository) SaveClicks(ctx context.Context, clicks []model.Click) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO click (short_id, ts, referrer, user_agent, ip_hash) VALUES ($1, $2, $3, $4, $5)")
+	if err != nil {
+		r.logger.Errorf("sql request validation error: %v", err)
+		return err
+	}
+	defer stmt.Close()
+
+	for _, click := range clicks {
+		_, err = stmt.ExecContext(ctx, click.ShortID, click.TS, click.Referrer, click.UserAgent, click.IPHash)
+		if err != nil {
+			r.logger.Errorf("sql request execution error: %v", err)
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteClicks удаляет из базы данных переходы по заданным сокращенным идентификаторам URL-адресов.
+func (r *DBClickRepository) DeleteClicks(ctx context.Context, shortIDs []string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM click WHERE short_id = ANY($1)", shortIDs); err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetClickStats возвращает статистику переходов по сокращенному идентификатору URL-адреса из базы данных.
+func (r *DBClickRepository) GetClickStats(ctx context.Context, shortID string) (*model.URLStats, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT (ts AT TIME ZONE 'UTC')::date AS day, count(*) FROM click WHERE short_id = $1 GROUP BY day ORDER BY day",
+		shortID,
+	)
+	if err != nil {
+		r.logger.Errorf("sql execution error: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := &model.URLStats{ShortID: shortID, Daily: []model.DailyClicks{}}
+	for rows.Next() {
+		var day time.Time
+		var count int64
+		if err := rows.Scan(&day, &count); err != nil {
+			return nil, err
+		}
+		stats.Total += count
+		stats.Daily = append(stats.Daily, model.DailyClicks{Date: day.Format(model.ClickDateLayout), Count: count})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row deserialization error %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,175 @@
+// Package repository содержит реализацию хранилища задач удаления URL-адресов в базе данных.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"go.uber.org/zap"
+)
+
+// deletionJobColumns представляет столбцы таблицы deletion_job в порядке сканирования.
+const deletionJobColumns = "id, user_id, short_ids, status, attempts, results, error, created_at, updated_at, next_attempt_at"
+
+// DBDeletionJobRepository представляет хранилище задач удаления URL-адресов в базе данных.
+type DBDeletionJobRepository struct {
+	// db представляет подключение к базе данных.
+	db *sql.DB
+	// logger представляет логгер для записи сообщений.
+	logger zap.SugaredLogger
+}
+
+// NewDBDeletionJobRepository возвращает новый экземпляр DBDeletionJobRepository.
+// Эта функция принимает подключение к базе данных и логгер.
+func NewDBDeletionJobRepository(db *sql.DB, logger zap.SugaredLogger) *DBDeletionJobRepository {
+	return &DBDeletionJobRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateJob сохраняет новую задачу удаления в базе данных.
+func (r *DBDeletionJobRepository) CreateJob(ctx context.Context, job model.DeletionJob) error {
+	shortIDs, err := json.Marshal(job.ShortIDs)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		"INSERT INTO deletion_job ("+deletionJobColumns+") VALUES ($1, $2, $3::jsonb, $4, $5, NULL, $6, $7, $8, $9)",
+		job.ID, job.UserID, string(shortIDs), job.Status, job.Attempts, job.Error, job.CreatedAt, job.UpdatedAt, job.NextAttemptAt,
+	)
+	if err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+	}
+	return err
+}
+
+// ClaimJobs переводит готовые к выполнению задачи в состояние model.DeletionJobRunning и возвращает их.
+// Блокировка строк с SKIP LOCKED позволяет нескольким экземплярам сервиса разбирать задачи без пересечений.
+func (r *DBDeletionJobRepository) ClaimJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.DeletionJob, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE deletion_job SET status = $1, updated_at = $2
+		WHERE id IN (
+			SELECT id FROM deletion_job
+			WHERE (status = $3 AND next_attempt_at <= $2) OR (status = $1 AND updated_at <= $4)
+			ORDER BY created_at
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+deletionJobColumns,
+		model.DeletionJobRunning, now, model.DeletionJobPending, now.Add(-lease), limit,
+	)
+	if err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []model.DeletionJob
+	for rows.Next() {
+		job, err := scanDeletionJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row deserialization error %w", err)
+	}
+	return jobs, nil
+}
+
+// UpdateJob сохраняет состояние задачи удаления в базе данных.
+func (r *DBDeletionJobRepository) UpdateJob(ctx context.Context, job model.DeletionJob) error {
+	var results any
+	if job.Results != nil {
+		data, err := json.Marshal(job.Results)
+		if err != nil {
+			return err
+		}
+		results = string(data)
+	}
+
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE deletion_job SET status = $2, attempts = $3, results = $4::jsonb, error = $5, updated_at = $6, next_attempt_at = $7 WHERE id = $1",
+		job.ID, job.Status, job.Attempts, results, job.Error, job.UpdatedAt, job.NextAttemptAt,
+	)
+	if err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+	}
+	return err
+}
+
+// FindJob находит задачу удаления в базе данных по идентификатору.
+func (r *DBDeletionJobRepository) FindJob(ctx context.Context, id string) (*model.DeletionJob, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+deletionJobColumns+" FROM deletion_job WHERE id = $1", id)
+	job, err := scanDeletionJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRepoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// CountUnfinishedJobs возвращает количество ожидающих и выполняемых задач удаления в базе данных.
+func (r *DBDeletionJobRepository) CountUnfinishedJobs(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx,
+		"SELECT count(*) FROM deletion_job WHERE status IN ($1, $2)",
+		model.DeletionJobPending, model.DeletionJobRunning,
+	).Scan(&count)
+	if err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+	}
+	return count, err
+}
+
+// PurgeFinishedJobs удаляет из базы данных выполненные и невыполненные задачи, завершенные не позднее заданного момента.
+func (r *DBDeletionJobRepository) PurgeFinishedJobs(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM deletion_job WHERE status IN ($1, $2) AND updated_at <= $3",
+		model.DeletionJobDone, model.DeletionJobFailed, before,
+	)
+	if err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// rowScanner представляет общий интерфейс sql.Row и sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanDeletionJob читает задачу удаления из строки результата запроса.
+func scanDeletionJob(row rowScanner) (*model.DeletionJob, error) {
+	var job model.DeletionJob
+	var shortIDs []byte
+	var results []byte
+
+	err := row.Scan(&job.ID, &job.UserID, &shortIDs, &job.Status, &job.Attempts, &results, &job.Error,
+		&job.CreatedAt, &job.UpdatedAt, &job.NextAttemptAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(shortIDs, &job.ShortIDs); err != nil {
+		return nil, fmt.Errorf("failed to decode short ids of deletion job %s: %w", job.ID, err)
+	}
+	if results != nil {
+		if err := json.Unmarshal(results, &job.Results); err != nil {
+			return nil, fmt.Errorf("failed to decode results of deletion job %s: %w", job.ID, err)
+		}
+	}
+	return &job, nil
+}
@@ -0,0 +1,79 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestDBDeletionJobRepository(t *testing.T) {
+	db := openTestDB(t, "deletion_job")
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	repo := repository.NewDBDeletionJobRepository(db, *logger)
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	require.NoError(t, repo.CreateJob(ctx, *model.NewDeletionJob("11111111-1111-1111-1111-111111111111", "user", []string{"a", "b"}, now)))
+	delayed := model.NewDeletionJob("22222222-2222-2222-2222-222222222222", "user", []string{"c"}, now)
+	delayed.NextAttemptAt = now.Add(time.Hour)
+	require.NoError(t, repo.CreateJob(ctx, *delayed))
+
+	count, err := repo.CountUnfinishedJobs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	jobs, err := repo.ClaimJobs(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	job := jobs[0]
+	assert.Equal(t, "11111111-1111-1111-1111-111111111111", job.ID)
+	assert.Equal(t, model.DeletionJobRunning, job.Status)
+	assert.Equal(t, []string{"a", "b"}, job.ShortIDs)
+
+	// Выполняемая задача не выбирается повторно, пока не истекла аренда.
+	jobs, err = repo.ClaimJobs(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	job.Status = model.DeletionJobDone
+	job.Attempts = 1
+	job.UpdatedAt = now
+	job.Results = []model.DeleteResult{
+		{ShortID: "a", Status: model.DeleteStatusDeleted},
+		{ShortID: "b", Status: model.DeleteStatusNotFound},
+	}
+	require.NoError(t, repo.UpdateJob(ctx, job))
+
+	found, err := repo.FindJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.DeletionJobDone, found.Status)
+	assert.Equal(t, 1, found.Attempts)
+	assert.Equal(t, job.Results, found.Results)
+	assert.True(t, now.Equal(found.CreatedAt))
+
+	count, err = repo.CountUnfinishedJobs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	_, err = repo.FindJob(ctx, "33333333-3333-3333-3333-333333333333")
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+
+	purged, err := repo.PurgeFinishedJobs(ctx, now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	purged, err = repo.PurgeFinishedJobs(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	_, err = repo.FindJob(ctx, job.ID)
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+	_, err = repo.FindJob(ctx, delayed.ID)
+	assert.NoError(t, err)
+}
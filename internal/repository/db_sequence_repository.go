@@ -0,0 +1,37 @@
+// Package repository содержит реализацию счетчика сокращенных идентификаторов на основе последовательности базы данных.
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go.uber.org/zap"
+)
+
+// DBSequenceRepository представляет счетчик сокращенных идентификаторов на основе последовательности url_short_code_seq.
+type DBSequenceRepository struct {
+	// db представляет подключение к базе данных.
+	db *sql.DB
+	// logger представляет логгер для записи сообщений.
+	logger zap.SugaredLogger
+}
+
+// NewDBSequenceRepository возвращает новый экземпляр DBSequenceRepository.
+// Эта функция принимает подключение к базе данных и логгер.
+func NewDBSequenceRepository(db *sql.DB, logger zap.SugaredLogger) *DBSequenceRepository {
+	return &DBSequenceRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// NextSequence возвращает следующее значение последовательности url_short_code_seq.
+// Значения, выданные в откаченных транзакциях, не возвращаются повторно.
+func (r *DBSequenceRepository) NextSequence(ctx context.Context) (uint64, error) {
+	var next int64
+	if err := r.db.QueryRowContext(ctx, "SELECT nextval('url_short_code_seq')").Scan(&next); err != nil {
+		r.logger.Errorf("sql execution error: %v", err)
+		return 0, err
+	}
+	return uint64(next), nil
+}
@@ -0,0 +1,56 @@
+// Package repository содержит реализацию хранилища длины сокращенных идентификаторов в базе данных.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+// DBShortCodeLengthRepository представляет хранилище длины сокращенных идентификаторов в таблице short_code_length.
+// Таблица содержит не более одной строки, общей для всех экземпляров сервиса.
+type DBShortCodeLengthRepository struct {
+	// db представляет подключение к базе данных.
+	db *sql.DB
+	// logger представляет логгер для записи сообщений.
+	logger zap.SugaredLogger
+}
+
+// NewDBShortCodeLengthRepository возвращает новый экземпляр DBShortCodeLengthRepository.
+// Эта функция принимает подключение к базе данных и логгер.
+func NewDBShortCodeLengthRepository(db *sql.DB, logger zap.SugaredLogger) *DBShortCodeLengthRepository {
+	return &DBShortCodeLengthRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// LoadShortCodeLength возвращает сохраненную длину; 0 означает, что длина еще не сохранялась.
+func (r *DBShortCodeLengthRepository) LoadShortCodeLength(ctx context.Context) (int, error) {
+	var length int
+	err := r.db.QueryRowContext(ctx, "SELECT length FROM short_code_length").Scan(&length)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		r.logger.Errorf("sql execution error: %v", err)
+		return 0, err
+	}
+	return length, nil
+}
+
+// SaveShortCodeLength сохраняет длину; меньшая длина не заменяет уже сохраненную большую,
+// поэтому одновременное увеличение длины несколькими экземплярами сервиса не уменьшает ее.
+func (r *DBShortCodeLengthRepository) SaveShortCodeLength(ctx context.Context, length int) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO short_code_length (length) VALUES ($1)
+		ON CONFLICT (id) DO UPDATE SET length = GREATEST(short_code_length.length, EXCLUDED.length)`,
+		length,
+	)
+	if err != nil {
+		r.logger.Errorf("sql execution error: %v", err)
+	}
+	return err
+}
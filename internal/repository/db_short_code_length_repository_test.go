@@ -0,0 +1,30 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestDBShortCodeLengthRepository(t *testing.T) {
+	db := openTestDB(t, "short_code_length")
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	repo := repository.NewDBShortCodeLengthRepository(db, *logger)
+
+	length, err := repo.LoadShortCodeLength(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, length)
+
+	require.NoError(t, repo.SaveShortCodeLength(ctx, 7))
+	// Меньшая длина, сохраненная другим экземпляром, не уменьшает сохраненную.
+	require.NoError(t, repo.SaveShortCodeLength(ctx, 6))
+
+	length, err = repo.LoadShortCodeLength(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 7, length)
+}
@@ -0,0 +1,42 @@
+package repository_test
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+// openTestDB открывает тестовую базу данных PostgreSQL из переменной окружения TEST_DATABASE_DSN,
+// применяет миграции и очищает заданные таблицы. Без переменной окружения тест пропускается.
+func openTestDB(t *testing.T, tables ...string) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	m, err := migrate.New("file://../../migrations", dsn)
+	require.NoError(t, err)
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		require.NoError(t, err)
+	}
+	m.Close()
+
+	db, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	for _, table := range tables {
+		_, err := db.Exec("TRUNCATE " + table + " CASCADE")
+		require.NoError(t, err)
+	}
+	return db
+}
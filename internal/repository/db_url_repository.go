@@ -4,27 +4,55 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/oegegr/shortener/internal/model"
 	"go.uber.org/zap"
 )
 
+// uniqueViolationCode представляет код ошибки PostgreSQL при нарушении уникального индекса.
+const uniqueViolationCode = "23505"
+
+// Имена уникальных индексов таблицы url.
+const (
+	uniqueURLIndex     = "idx_unique_domain_url"
+	uniqueShortIDIndex = "idx_unique_short_id"
+)
+
+// likeEscaper экранирует специальные символы шаблона LIKE, чтобы строка поиска сравнивалась буквально.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // DBURLRepository представляет репозиторий для работы с URL-адресами в базе данных.
 type DBURLRepository struct {
 	// db представляет подключение к базе данных.
 	db *sql.DB
+	// typeMap представляет преобразователь типов PostgreSQL для чтения массивов.
+	typeMap *pgtype.Map
 	// logger представляет логгер для записи сообщений.
 	logger zap.SugaredLogger
 }
 
+// urlItemColumns представляет столбцы, из которых читается model.URLItem; порядок соответствует scanURLItem.
+const urlItemColumns = "url, short_id, COALESCE(user_id::text, ''), COALESCE(is_deleted, false), is_custom, expires_at, " +
+	"title, preview, created_at, password_hash, updated_at, last_accessed_at, domain, " +
+	"ARRAY(SELECT tag FROM url_tag WHERE url_tag.url_id = url.id ORDER BY tag)"
+
+// urlCreatedAtKey представляет выражение времени создания для сортировки и выборки страниц по индексу idx_url_user_created.
+// URL-адреса, созданные до появления столбца created_at, имеют NULL и упорядочиваются по нулевому времени, как model.URLItem.
+const urlCreatedAtKey = "COALESCE(created_at, '0001-01-01 00:00:00+00'::timestamptz)"
+
 // NewDBURLRepository возвращает новый экземпляр DBURLRepository.
 // Эта функция принимает подключение к базе данных и логгер.
 func NewDBURLRepository(db *sql.DB, logger zap.SugaredLogger) (*DBURLRepository, error) {
 	return &DBURLRepository{
-		db:     db,
-		logger: logger,
+		db:      db,
+		typeMap: pgtype.NewMap(),
+		logger:  logger,
 	}, nil
 }
 
@@ -40,66 +68,296 @@ func (r *DBURLRepository) CreateURL(ctx context.Context, urlItem []model.URLItem
 	if err != nil {
 		return err
 	}
-	stmt, err := tx.Prepare("INSERT INTO url (url, short_id, user_id, is_deleted) VALUES ($1, $2, $3, $4)")
+	stmt, err := tx.Prepare("INSERT INTO url (url, short_id, user_id, is_deleted, is_custom, expires_at, title, preview, created_at, password_hash, updated_at, last_accessed_at, domain) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id")
 	if err != nil {
 		r.logger.Errorf("sql request validation error: %v", err)
+		tx.Rollback()
 		return err
 	}
 	defer stmt.Close()
 
 	for _, item := range urlItem {
 
-		_, err = stmt.Exec(item.URL, item.ShortID, item.UserID, item.IsDeleted)
+		var id int64
+		err = stmt.QueryRow(item.URL, item.ShortID, item.UserID, item.IsDeleted, item.IsCustom, item.ExpiresAt, item.Title, item.Preview, nullTime(item.CreatedAt), item.PasswordHash, item.UpdatedAt, item.LastAccessedAt, item.Domain).Scan(&id)
+		if err == nil && len(item.Tags) > 0 {
+			_, err = tx.Exec("INSERT INTO url_tag (url_id, tag) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING", id, item.Tags)
+		}
 
 		if err != nil {
-			if strings.Contains(err.Error(), "23505") {
-				return ErrRepoURLAlreadyExists
+			tx.Rollback()
+
+			if conflict := uniqueViolationError(err, item); conflict != nil {
+				return conflict
 			}
 
 			r.logger.Errorf("sql request execution error: %v", err)
-			tx.Rollback()
 			return err
 		}
 	}
 	return tx.Commit()
 }
 
-// DeleteURL удаляет URL-адреса из базы данных.
-// Эта функция принимает список идентификаторов URL-адресов для удаления.
-func (r *DBURLRepository) DeleteURL(ctx context.Context, ids []string) error {
-	tx, err := r.db.Begin()
+// scanURLItem читает model.URLItem из строки, выбранной по столбцам urlItemColumns.
+func (r *DBURLRepository) scanURLItem(row rowScanner) (*model.URLItem, error) {
+	var item model.URLItem
+	var createdAt sql.NullTime
+	err := row.Scan(
+		&item.URL, &item.ShortID, &item.UserID, &item.IsDeleted, &item.IsCustom, &item.ExpiresAt,
+		&item.Title, &item.Preview, &createdAt, &item.PasswordHash, &item.UpdatedAt, &item.LastAccessedAt, &item.Domain,
+		r.typeMap.SQLScanner(&item.Tags),
+	)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	stmt, err := tx.Prepare("UPDATE url SET is_deleted = true WHERE short_id = ANY($1)")
+	if createdAt.Valid {
+		item.CreatedAt = createdAt.Time
+	}
+	return &item, nil
+}
+
+// nullTime возвращает значение столбца времени; нулевое время записывается как NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// uniqueViolationError преобразует ошибку нарушения уникального индекса в ошибку репозитория.
+// Если ошибка не связана с уникальными индексами таблицы url, функция возвращает nil.
+func uniqueViolationError(err error, item model.URLItem) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolationCode {
+		return nil
+	}
+
+	switch pgErr.ConstraintName {
+	case uniqueShortIDIndex:
+		if item.IsCustom {
+			return ErrRepoAliasAlreadyExists
+		}
+		return ErrRepoShortIDAlreadyExists
+	case uniqueURLIndex:
+		return ErrRepoURLAlreadyExists
+	default:
+		return nil
+	}
+}
+
+// DeleteURL помечает удаленными URL-адреса пользователя в базе данных.
+// Эта функция принимает идентификатор пользователя и список идентификаторов URL-адресов для удаления.
+// URL-адреса других пользователей не изменяются и попадают в результат со статусом DeleteStatusNotOwned.
+func (r *DBURLRepository) DeleteURL(ctx context.Context, userID string, ids []string) ([]model.DeleteResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		r.logger.Errorf("sql request validation error: %v", err)
-		return err
+		return nil, err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	_, err = stmt.Exec(ids)
+	deleted, err := queryShortIDs(ctx, tx, "UPDATE url SET is_deleted = true WHERE short_id = ANY($1) AND user_id = $2 RETURNING short_id", ids, userID)
+	if err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+		return nil, err
+	}
 
+	existing, err := queryShortIDs(ctx, tx, "SELECT short_id FROM url WHERE short_id = ANY($1)", ids)
 	if err != nil {
 		r.logger.Errorf("sql request execution error: %v", err)
-		tx.Rollback()
-		return err
+		return nil, err
 	}
-	return tx.Commit()
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	results := make([]model.DeleteResult, 0, len(ids))
+	for _, id := range ids {
+		status := model.DeleteStatusNotFound
+		if deleted[id] {
+			status = model.DeleteStatusDeleted
+		} else if existing[id] {
+			status = model.DeleteStatusNotOwned
+		}
+		results = append(results, model.DeleteResult{ShortID: id, Status: status})
+	}
+	return results, nil
 }
 
-// FindURLByURL находит URL-адрес в базе данных по оригинальному URL-адресу.
-// Эта функция принимает оригинальный URL-адрес для поиска.
-func (r *DBURLRepository) FindURLByURL(ctx context.Context, url string) (*model.URLItem, error) {
-	stmt, err := r.db.Prepare("SELECT url, short_id , user_id FROM url WHERE url = $1")
+// UpdateURL изменяет оригинальный URL-адрес пользователя в базе данных и сохраняет запись в таблице url_edit.
+// Эта функция возвращает ErrRepoNotFound для отсутствующего или удаленного URL-адреса, ErrRepoNotOwned для URL-адреса
+// другого пользователя и ErrRepoURLAlreadyExists, если новый URL-адрес уже сокращен в том же домене (индекс idx_unique_domain_url).
+// Если URL-адрес не изменился, запись в истории не создается.
+func (r *DBURLRepository) UpdateURL(ctx context.Context, edit model.URLEdit) (*model.URLEdit, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var item model.URLItem
+	err = tx.QueryRowContext(ctx,
+		"SELECT url, COALESCE(user_id::text, ''), COALESCE(is_deleted, false), expires_at FROM url WHERE short_id = $1 FOR UPDATE",
+		edit.ShortID,
+	).Scan(&edit.OldURL, &item.UserID, &item.IsDeleted, &item.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRepoNotFound
+		}
+		r.logger.Errorf("sql execution error: %v", err)
+		return nil, err
+	}
+
+	if item.IsDeleted {
+		return nil, ErrRepoNotFound
+	}
+	if item.UserID != edit.UserID {
+		return nil, ErrRepoNotOwned
+	}
+	if item.IsExpired(edit.EditedAt) {
+		return nil, ErrRepoURLExpired
+	}
+	if edit.OldURL == edit.NewURL {
+		return &edit, nil
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE url SET url = $1, updated_at = $2 WHERE short_id = $3", edit.NewURL, edit.EditedAt, edit.ShortID)
+	if err != nil {
+		if conflict := uniqueViolationError(err, model.URLItem{URL: edit.NewURL, ShortID: edit.ShortID}); conflict != nil {
+			return nil, conflict
+		}
+		r.logger.Errorf("sql execution error: %v", err)
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO url_edit (short_id, user_id, old_url, new_url, edited_at) VALUES ($1, $2, $3, $4, $5)",
+		edit.ShortID, edit.UserID, edit.OldURL, edit.NewURL, edit.EditedAt,
+	)
+	if err != nil {
+		r.logger.Errorf("sql execution error: %v", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &edit, nil
+}
+
+// FindURLEdits возвращает историю изменений URL-адреса из таблицы url_edit в хронологическом порядке.
+func (r *DBURLRepository) FindURLEdits(ctx context.Context, shortID string) ([]model.URLEdit, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT short_id, user_id::text, old_url, new_url, edited_at FROM url_edit WHERE short_id = $1 ORDER BY edited_at, id",
+		shortID,
+	)
+	if err != nil {
+		r.logger.Errorf("sql execution error: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edits []model.URLEdit
+	for rows.Next() {
+		var edit model.URLEdit
+		if err := rows.Scan(&edit.ShortID, &edit.UserID, &edit.OldURL, &edit.NewURL, &edit.EditedAt); err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row deserialization error %w", err)
+	}
+	return edits, nil
+}
+
+// queryShortIDs выполняет запрос, возвращающий столбец short_id, и возвращает множество найденных идентификаторов.
+func queryShortIDs(ctx context.Context, tx *sql.Tx, query string, args ...any) (map[string]bool, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row deserialization error %w", err)
+	}
+	return ids, nil
+}
+
+// FindExpiredURL возвращает сокращенные идентификаторы URL-адресов в базе данных, срок действия которых истек к заданному моменту.
+func (r *DBURLRepository) FindExpiredURL(ctx context.Context, before time.Time) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT short_id FROM url WHERE expires_at <= $1", before)
+	if err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row deserialization error %w", err)
+	}
+	return ids, nil
+}
+
+// PurgeExpiredURL физически удаляет из базы данных URL-адреса из заданного списка, срок действия которых истек к заданному моменту.
+// История изменений удаляется в той же транзакции, метки удаляются каскадно.
+// Эта функция возвращает количество удаленных URL-адресов.
+func (r *DBURLRepository) PurgeExpiredURL(ctx context.Context, ids []string, before time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	purged, err := queryShortIDs(ctx, tx, "DELETE FROM url WHERE short_id = ANY($1) AND expires_at <= $2 RETURNING short_id", ids, before)
+	if err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+		return 0, err
+	}
+
+	purgedIDs := make([]string, 0, len(purged))
+	for id := range purged {
+		purgedIDs = append(purgedIDs, id)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM url_edit WHERE short_id = ANY($1)", purgedIDs); err != nil {
+		r.logger.Errorf("sql request execution error: %v", err)
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int64(len(purgedIDs)), nil
+}
+
+// FindURLByURL находит URL-адрес в базе данных по домену и оригинальному URL-адресу.
+// Эта функция принимает домен и оригинальный URL-адрес для поиска.
+func (r *DBURLRepository) FindURLByURL(ctx context.Context, domain string, url string) (*model.URLItem, error) {
+	stmt, err := r.db.Prepare("SELECT " + urlItemColumns + " FROM url WHERE domain = $1 AND url = $2")
 	if err != nil {
 		r.logger.Errorf("sql validation error: %v", err)
 		return nil, err
 	}
 	defer stmt.Close()
 
-	var urlItem model.URLItem
-	err = stmt.QueryRow(url).Scan(&urlItem.URL, &urlItem.ShortID, &urlItem.UserID)
+	urlItem, err := r.scanURLItem(stmt.QueryRow(domain, url))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			r.logger.Debugf("url not found %s", url)
@@ -109,13 +367,13 @@ func (r *DBURLRepository) FindURLByURL(ctx context.Context, url string) (*model.
 		return nil, err
 	}
 
-	return &urlItem, nil
+	return urlItem, nil
 }
 
 // FindURLByUser находит URL-адреса в базе данных по идентификатору пользователя.
 // Эта функция принимает идентификатор пользователя для поиска.
 func (r *DBURLRepository) FindURLByUser(ctx context.Context, userID string) ([]model.URLItem, error) {
-	stmt, err := r.db.Prepare("SELECT url, short_id, user_id FROM url WHERE user_id = $1")
+	stmt, err := r.db.Prepare("SELECT " + urlItemColumns + " FROM url WHERE user_id = $1")
 	if err != nil {
 		r.logger.Errorf("sql validation error: %v", err)
 		return nil, err
@@ -133,11 +391,14 @@ func (r *DBURLRepository) FindURLByUser(ctx context.Context, userID string) ([]m
 		r.logger.Errorf("sql execution error: %v", err)
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		var item model.URLItem
-		rows.Scan(&item.URL, &item.ShortID, &item.UserID)
-		items = append(items, item)
+		item, err := r.scanURLItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -147,18 +408,79 @@ func (r *DBURLRepository) FindURLByUser(ctx context.Context, userID string) ([]m
 	return items, nil
 }
 
+// FindURLPageByUser возвращает страницу URL-адресов пользователя, отсортированных по времени создания и сокращенному идентификатору.
+// Страница выбирается по ключу (created_at, short_id), поэтому использует индекс idx_url_user_created
+// и не зависит от количества пропущенных URL-адресов; поиск подстроки использует индекс idx_url_url_trgm, если он создан.
+func (r *DBURLRepository) FindURLPageByUser(ctx context.Context, userID string, query model.UserURLQuery) (*model.UserURLPage, error) {
+	var sb strings.Builder
+	args := []any{userID}
+	sb.WriteString("SELECT " + urlItemColumns + " FROM url WHERE user_id = $1")
+
+	if !query.IncludeDeleted {
+		sb.WriteString(" AND NOT COALESCE(is_deleted, false)")
+	}
+	if query.Search != "" {
+		args = append(args, "%"+likeEscaper.Replace(query.Search)+"%")
+		fmt.Fprintf(&sb, " AND url ILIKE $%d", len(args))
+	}
+	if query.Tag != "" {
+		args = append(args, query.Tag)
+		fmt.Fprintf(&sb, " AND EXISTS (SELECT 1 FROM url_tag WHERE url_tag.url_id = url.id AND url_tag.tag = $%d)", len(args))
+	}
+
+	direction, comparison := "DESC", "<"
+	if query.Order == model.SortAsc {
+		direction, comparison = "ASC", ">"
+	}
+	if query.Cursor != nil {
+		args = append(args, query.Cursor.CreatedAt, query.Cursor.ShortID)
+		fmt.Fprintf(&sb, " AND (%s, short_id) %s ($%d, $%d)", urlCreatedAtKey, comparison, len(args)-1, len(args))
+	}
+	fmt.Fprintf(&sb, " ORDER BY %s %s, short_id %s", urlCreatedAtKey, direction, direction)
+	if query.Limit > 0 {
+		args = append(args, query.Limit+1)
+		fmt.Fprintf(&sb, " LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, sb.String(), args...)
+	if err != nil {
+		r.logger.Errorf("sql execution error: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []model.URLItem
+	for rows.Next() {
+		item, err := r.scanURLItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row deserialization error %w", err)
+	}
+
+	page := &model.UserURLPage{Items: items}
+	if query.Limit > 0 && len(items) > query.Limit {
+		page.Items = items[:query.Limit]
+		page.Next = model.NewURLCursor(page.Items[query.Limit-1])
+	}
+	return page, nil
+}
+
 // FindURLByID находит URL-адрес в базе данных по идентификатору URL-адреса.
 // Эта функция принимает идентификатор URL-адреса для поиска.
 func (r *DBURLRepository) FindURLByID(ctx context.Context, id string) (*model.URLItem, error) {
-	stmt, err := r.db.Prepare("SELECT url, short_id , is_deleted FROM url WHERE short_id = $1")
+	stmt, err := r.db.Prepare("SELECT " + urlItemColumns + " FROM url WHERE short_id = $1")
 	if err != nil {
 		r.logger.Errorf("sql validation error: %v", err)
 		return nil, err
 	}
 	defer stmt.Close()
 
-	var urlItem model.URLItem
-	err = stmt.QueryRow(id).Scan(&urlItem.URL, &urlItem.ShortID, &urlItem.IsDeleted)
+	urlItem, err := r.scanURLItem(stmt.QueryRow(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			r.logger.Debugf("url not found %s", id)
@@ -168,7 +490,133 @@ func (r *DBURLRepository) FindURLByID(ctx context.Context, id string) (*model.UR
 		return nil, err
 	}
 
-	return &urlItem, nil
+	return urlItem, nil
+}
+
+// SetTags заменяет метки URL-адреса пользователя в таблице url_tag.
+// Эта функция возвращает ErrRepoNotFound для отсутствующего или удаленного URL-адреса и ErrRepoNotOwned для URL-адреса другого пользователя.
+func (r *DBURLRepository) SetTags(ctx context.Context, userID string, shortID string, tags []string, updatedAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	var ownerID string
+	var isDeleted bool
+	err = tx.QueryRowContext(ctx,
+		"SELECT id, COALESCE(user_id::text, ''), COALESCE(is_deleted, false) FROM url WHERE short_id = $1 FOR UPDATE",
+		shortID,
+	).Scan(&id, &ownerID, &isDeleted)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRepoNotFound
+		}
+		r.logger.Errorf("sql execution error: %v", err)
+		return err
+	}
+
+	if isDeleted {
+		return ErrRepoNotFound
+	}
+	if ownerID != userID {
+		return ErrRepoNotOwned
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM url_tag WHERE url_id = $1", id); err != nil {
+		r.logger.Errorf("sql execution error: %v", err)
+		return err
+	}
+	if len(tags) > 0 {
+		_, err = tx.ExecContext(ctx, "INSERT INTO url_tag (url_id, tag) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING", id, tags)
+		if err != nil {
+			r.logger.Errorf("sql execution error: %v", err)
+			return err
+		}
+	}
+	if _, err = tx.ExecContext(ctx, "UPDATE url SET updated_at = $1 WHERE id = $2", updatedAt, id); err != nil {
+		r.logger.Errorf("sql execution error: %v", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CountTagsByUser возвращает метки пользователя с количеством не удаленных URL-адресов,
+// отсортированные по убыванию количества, а при равном количестве — по метке.
+func (r *DBURLRepository) CountTagsByUser(ctx context.Context, userID string) ([]model.TagCount, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT url_tag.tag, count(*) FROM url_tag JOIN url ON url.id = url_tag.url_id "+
+			"WHERE url.user_id = $1 AND NOT COALESCE(url.is_deleted, false) "+
+			"GROUP BY url_tag.tag ORDER BY count(*) DESC, url_tag.tag",
+		userID,
+	)
+	if err != nil {
+		r.logger.Errorf("sql execution error: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []model.TagCount{}
+	for rows.Next() {
+		var count model.TagCount
+		if err := rows.Scan(&count.Tag, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row deserialization error %w", err)
+	}
+	return counts, nil
+}
+
+// MarkAccessed сохраняет моменты последних переходов по URL-адресам одним запросом.
+// Более ранний момент не заменяет уже сохраненный более поздний.
+func (r *DBURLRepository) MarkAccessed(ctx context.Context, accessed map[string]time.Time) error {
+	if len(accessed) == 0 {
+		return nil
+	}
+
+	shortIDs := make([]string, 0, len(accessed))
+	timestamps := make([]time.Time, 0, len(accessed))
+	for shortID, ts := range accessed {
+		shortIDs = append(shortIDs, shortID)
+		timestamps = append(timestamps, ts)
+	}
+
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE url SET last_accessed_at = GREATEST(COALESCE(url.last_accessed_at, v.ts), v.ts) "+
+			"FROM unnest($1::text[], $2::timestamptz[]) AS v(short_id, ts) WHERE url.short_id = v.short_id",
+		shortIDs, timestamps,
+	)
+	if err != nil {
+		r.logger.Errorf("sql execution error: %v", err)
+	}
+	return err
+}
+
+// CountURLs возвращает количество не удаленных URL-адресов в базе данных.
+func (r *DBURLRepository) CountURLs(ctx context.Context) (int64, error) {
+	return r.count(ctx, "SELECT count(*) FROM url WHERE NOT COALESCE(is_deleted, false)")
+}
+
+// CountUsers возвращает количество пользователей, у которых есть не удаленные URL-адреса в базе данных.
+func (r *DBURLRepository) CountUsers(ctx context.Context) (int64, error) {
+	return r.count(ctx, "SELECT count(DISTINCT user_id) FROM url WHERE NOT COALESCE(is_deleted, false)")
+}
+
+// count выполняет агрегирующий запрос, возвращающий одно число.
+func (r *DBURLRepository) count(ctx context.Context, query string) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx, query).Scan(&count)
+	if err != nil {
+		r.logger.Errorf("sql execution error: %v", err)
+		return 0, err
+	}
+	return count, nil
 }
 
 // Exists проверяет, существует ли URL-адрес в базе данных.
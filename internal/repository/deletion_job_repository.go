@@ -0,0 +1,26 @@
+// Package repository содержит интерфейс для работы с хранилищем задач удаления URL-адресов.
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+)
+
+// DeletionJobRepository представляет интерфейс для работы с хранилищем задач удаления URL-адресов.
+type DeletionJobRepository interface {
+	// CreateJob сохраняет новую задачу удаления.
+	CreateJob(ctx context.Context, job model.DeletionJob) error
+	// ClaimJobs переводит в состояние model.DeletionJobRunning и возвращает не более limit задач, готовых к выполнению:
+	// ожидающие задачи, время следующей попытки которых наступило, и выполняемые задачи, не обновлявшиеся дольше lease.
+	ClaimJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.DeletionJob, error)
+	// UpdateJob сохраняет состояние, количество попыток, результаты и ошибку задачи удаления.
+	UpdateJob(ctx context.Context, job model.DeletionJob) error
+	// FindJob находит задачу удаления по идентификатору.
+	FindJob(ctx context.Context, id string) (*model.DeletionJob, error)
+	// CountUnfinishedJobs возвращает количество ожидающих и выполняемых задач удаления.
+	CountUnfinishedJobs(ctx context.Context) (int64, error)
+	// PurgeFinishedJobs удаляет выполненные и невыполненные задачи, завершенные не позднее заданного момента, и возвращает их количество.
+	PurgeFinishedJobs(ctx context.Context, before time.Time) (int64, error)
+}
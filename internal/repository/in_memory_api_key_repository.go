@@ -0,0 +1,235 @@
+// Package repository содержит реализацию хранилища API-ключей в памяти с журналом на диске.
+package repository
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"go.uber.org/zap"
+)
+
+// InMemoryAPIKeyRepository представляет хранилище API-ключей в памяти.
+// При заданном пути к файлу каждое изменение ключа дописывается в журнал, который читается при запуске.
+type InMemoryAPIKeyRepository struct {
+	// mu представляет mutex для синхронизации доступа к данным.
+	mu sync.RWMutex
+	// keys представляет карту ключей по идентификатору.
+	keys map[string]model.APIKey
+	// hashes представляет карту идентификаторов ключей по хешу ключа.
+	hashes map[string]string
+	// order представляет идентификаторы ключей в порядке создания.
+	order []string
+	// file представляет открытый на дозапись файл журнала.
+	file *os.File
+	// logger представляет логгер для записи сообщений.
+	logger zap.SugaredLogger
+}
+
+// NewInMemoryAPIKeyRepository возвращает новый экземпляр InMemoryAPIKeyRepository.
+// Эта функция принимает путь к файлу журнала (пустой путь отключает сохранение на диск) и логгер.
+func NewInMemoryAPIKeyRepository(filePath string, logger zap.SugaredLogger) (*InMemoryAPIKeyRepository, error) {
+	repo := &InMemoryAPIKeyRepository{
+		keys:   make(map[string]model.APIKey),
+		hashes: make(map[string]string),
+		logger: logger,
+	}
+
+	if filePath == "" {
+		return repo, nil
+	}
+
+	if err := repo.load(filePath); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// CreateKey сохраняет новый API-ключ, если у пользователя меньше maxActive действующих ключей.
+func (r *InMemoryAPIKeyRepository) CreateKey(ctx context.Context, key model.APIKey, maxActive int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.keys[key.ID]; ok {
+		return fmt.Errorf("api key %s already exists", key.ID)
+	}
+
+	active := 0
+	for _, existing := range r.keys {
+		if existing.UserID == key.UserID && !existing.IsRevoked() {
+			active++
+		}
+	}
+	if active >= maxActive {
+		return ErrRepoTooManyAPIKeys
+	}
+	if err := r.appendJournal(key); err != nil {
+		return err
+	}
+	r.apply(key)
+	return nil
+}
+
+// FindKeyByHash находит API-ключ по хешу ключа.
+func (r *InMemoryAPIKeyRepository) FindKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.hashes[keyHash]
+	if !ok {
+		return nil, ErrRepoNotFound
+	}
+	key := r.keys[id]
+	key.Scopes = slices.Clone(key.Scopes)
+	return &key, nil
+}
+
+// FindKeysByUser возвращает API-ключи пользователя в порядке создания.
+func (r *InMemoryAPIKeyRepository) FindKeysByUser(ctx context.Context, userID string) ([]model.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var keys []model.APIKey
+	for _, id := range r.order {
+		key := r.keys[id]
+		if key.UserID != userID {
+			continue
+		}
+		key.Scopes = slices.Clone(key.Scopes)
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// RevokeKey отзывает API-ключ пользователя.
+func (r *InMemoryAPIKeyRepository) RevokeKey(ctx context.Context, userID string, id string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok || key.UserID != userID || key.IsRevoked() {
+		return ErrRepoNotFound
+	}
+
+	key.RevokedAt = &revokedAt
+	if err := r.appendJournal(key); err != nil {
+		return err
+	}
+	r.apply(key)
+	return nil
+}
+
+// Close закрывает файл журнала.
+func (r *InMemoryAPIKeyRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := errors.Join(r.file.Sync(), r.file.Close())
+	r.file = nil
+	return err
+}
+
+// apply сохраняет состояние ключа в памяти.
+func (r *InMemoryAPIKeyRepository) apply(key model.APIKey) {
+	if _, ok := r.keys[key.ID]; !ok {
+		r.order = append(r.order, key.ID)
+	}
+	r.keys[key.ID] = key
+	r.hashes[key.KeyHash] = key.ID
+}
+
+// appendJournal дописывает состояние ключа в журнал и сбрасывает его на диск.
+func (r *InMemoryAPIKeyRepository) appendJournal(key model.APIKey) error {
+	if r.file == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode api key: %w", err)
+	}
+	if _, err := r.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write api key journal: %w", err)
+	}
+	return r.file.Sync()
+}
+
+// load восстанавливает ключи из журнала и переписывает журнал, оставляя по одной записи на ключ.
+// Оборванная последняя запись (например, после аварийного завершения) пропускается.
+func (r *InMemoryAPIKeyRepository) load(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if file != nil {
+		reader := bufio.NewReader(file)
+		for {
+			line, readErr := reader.ReadBytes('\n')
+			if errors.Is(readErr, io.EOF) {
+				break
+			}
+			if readErr != nil {
+				file.Close()
+				return readErr
+			}
+
+			var key model.APIKey
+			if err := json.Unmarshal(line, &key); err != nil {
+				r.logger.Warnf("api key journal %s has corrupted entry, skipping the rest", filePath)
+				break
+			}
+			r.apply(key)
+		}
+		file.Close()
+	}
+
+	if err := r.rewrite(filePath); err != nil {
+		return err
+	}
+
+	r.file, err = os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	return err
+}
+
+// rewrite атомарно записывает текущее состояние всех ключей в журнал.
+func (r *InMemoryAPIKeyRepository) rewrite(filePath string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, id := range r.order {
+		if err := encoder.Encode(r.keys[id]); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
@@ -0,0 +1,68 @@
+package repository_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestInMemoryAPIKeyRepository_Persistence(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	path := filepath.Join(t.TempDir(), "storage.json.keys")
+
+	repo, err := repository.NewInMemoryAPIKeyRepository(path, *logger)
+	require.NoError(t, err)
+
+	createdAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.CreateKey(ctx, model.APIKey{ID: "first", UserID: "user", KeyHash: "hash-1", Scopes: []model.APIKeyScope{model.APIKeyScopeRead}, CreatedAt: createdAt}, 10))
+	require.NoError(t, repo.CreateKey(ctx, model.APIKey{ID: "second", UserID: "user", KeyHash: "hash-2", Scopes: []model.APIKeyScope{model.APIKeyScopeDelete}, CreatedAt: createdAt}, 10))
+	require.NoError(t, repo.CreateKey(ctx, model.APIKey{ID: "foreign", UserID: "stranger", KeyHash: "hash-3", CreatedAt: createdAt}, 10))
+
+	revokedAt := createdAt.Add(time.Hour)
+	assert.ErrorIs(t, repo.RevokeKey(ctx, "user", "foreign", revokedAt), repository.ErrRepoNotFound)
+	require.NoError(t, repo.RevokeKey(ctx, "user", "first", revokedAt))
+	require.NoError(t, repo.Close())
+
+	restored, err := repository.NewInMemoryAPIKeyRepository(path, *logger)
+	require.NoError(t, err)
+	defer restored.Close()
+
+	key, err := restored.FindKeyByHash(ctx, "hash-1")
+	require.NoError(t, err)
+	require.NotNil(t, key.RevokedAt)
+	assert.True(t, revokedAt.Equal(*key.RevokedAt))
+
+	_, err = restored.FindKeyByHash(ctx, "missing")
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+
+	keys, err := restored.FindKeysByUser(ctx, "user")
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "first", keys[0].ID)
+	assert.Equal(t, []model.APIKeyScope{model.APIKeyScopeDelete}, keys[1].Scopes)
+}
+
+func TestInMemoryAPIKeyRepository_Limit(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	repo, err := repository.NewInMemoryAPIKeyRepository("", *logger)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.CreateKey(ctx, model.APIKey{ID: "first", UserID: "user", KeyHash: "hash-1"}, 2))
+	require.NoError(t, repo.CreateKey(ctx, model.APIKey{ID: "second", UserID: "user", KeyHash: "hash-2"}, 2))
+	assert.ErrorIs(t, repo.CreateKey(ctx, model.APIKey{ID: "third", UserID: "user", KeyHash: "hash-3"}, 2), repository.ErrRepoTooManyAPIKeys)
+	// Ключи других пользователей не учитываются.
+	require.NoError(t, repo.CreateKey(ctx, model.APIKey{ID: "foreign", UserID: "stranger", KeyHash: "hash-4"}, 2))
+
+	// Отозванные ключи не учитываются.
+	require.NoError(t, repo.RevokeKey(ctx, "user", "first", time.Now()))
+	require.NoError(t, repo.CreateKey(ctx, model.APIKey{ID: "third", UserID: "user", KeyHash: "hash-3"}, 2))
+}
@@ -0,0 +1,70 @@
+// Package repository содержит реализацию репозитория переходов по URL-адресам в памяти.
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/oegegr/shortener/internal/model"
+)
+
+// InMemoryClickRepository представляет репозиторий для работы с переходами по URL-адресам в памяти.
+// Как и в базе данных, хранятся все переходы вместе с источником, User-Agent и хешем IP-адреса;
+// данные не сохраняются между перезапусками.
+type InMemoryClickRepository struct {
+	// mu представляет mutex для синхронизации доступа к данным.
+	mu sync.RWMutex
+	// clicks представляет переходы по сокращенным идентификаторам.
+	clicks map[string][]model.Click
+}
+
+// NewInMemoryClickRepository возвращает новый экземпляр InMemoryClickRepository.
+func NewInMemoryClickRepository() *InMemoryClickRepository {
+	return &InMemoryClickRepository{
+		clicks: make(map[string][]model.Click),
+	}
+}
+
+// SaveClicks сохраняет переходы по URL-адресам в памяти.
+// Эта функция принимает список переходов для сохранения.
+func (repo *InMemoryClickRepository) SaveClicks(ctx context.Context, clicks []model.Click) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, click := range clicks {
+		repo.clicks[click.ShortID] = append(repo.clicks[click.ShortID], click)
+	}
+	return nil
+}
+
+// DeleteClicks удаляет из памяти переходы по заданным сокращенным идентификаторам URL-адресов.
+func (repo *InMemoryClickRepository) DeleteClicks(ctx context.Context, shortIDs []string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, id := range shortIDs {
+		delete(repo.clicks, id)
+	}
+	return nil
+}
+
+// GetClickStats возвращает статистику переходов по сокращенному идентификатору URL-адреса.
+func (repo *InMemoryClickRepository) GetClickStats(ctx context.Context, shortID string) (*model.URLStats, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	daily := make(map[string]int64)
+	for _, click := range repo.clicks[shortID] {
+		daily[click.TS.UTC().Format(model.ClickDateLayout)]++
+	}
+
+	stats := &model.URLStats{ShortID: shortID, Daily: []model.DailyClicks{}}
+	for day, count := range daily {
+		stats.Total += count
+		stats.Daily = append(stats.Daily, model.DailyClicks{Date: day, Count: count})
+	}
+
+	sort.Slice(stats.Daily, func(i, j int) bool { return stats.Daily[i].Date < stats.Daily[j].Date })
+	return stats, nil
+}
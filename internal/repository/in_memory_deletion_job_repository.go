@@ -0,0 +1,307 @@
+// Package repository содержит реализацию хранилища задач удаления URL-адресов в памяти с журналом на диске.
+package repository
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"go.uber.org/zap"
+)
+
+// deletionJournalCompactThreshold представляет минимальное количество записей журнала задач удаления,
+// после которого журнал переписывается, если записей больше чем вдвое больше, чем задач.
+const deletionJournalCompactThreshold = 1000
+
+// InMemoryDeletionJobRepository представляет хранилище задач удаления URL-адресов в памяти.
+// При заданном пути к файлу каждое изменение задачи дописывается в журнал, который читается при запуске,
+// поэтому невыполненные задачи переживают перезапуск сервиса. Журнал переписывается с одной записью на задачу
+// при запуске, при удалении завершенных задач и при накоплении устаревших записей.
+type InMemoryDeletionJobRepository struct {
+	// mu представляет mutex для синхронизации доступа к данным.
+	mu sync.Mutex
+	// jobs представляет карту задач по идентификатору.
+	jobs map[string]model.DeletionJob
+	// unfinished представляет идентификаторы ожидающих и выполняемых задач в порядке создания.
+	unfinished []string
+	// filePath представляет путь к файлу журнала.
+	filePath string
+	// file представляет открытый на дозапись файл журнала.
+	file *os.File
+	// entries представляет количество записей в журнале.
+	entries int
+	// logger представляет логгер для записи сообщений.
+	logger zap.SugaredLogger
+}
+
+// NewInMemoryDeletionJobRepository возвращает новый экземпляр InMemoryDeletionJobRepository.
+// Эта функция принимает путь к файлу журнала (пустой путь отключает сохранение на диск) и логгер.
+func NewInMemoryDeletionJobRepository(filePath string, logger zap.SugaredLogger) (*InMemoryDeletionJobRepository, error) {
+	repo := &InMemoryDeletionJobRepository{
+		jobs:     make(map[string]model.DeletionJob),
+		filePath: filePath,
+		logger:   logger,
+	}
+
+	if filePath == "" {
+		return repo, nil
+	}
+
+	if err := repo.load(); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// CreateJob сохраняет новую задачу удаления.
+func (r *InMemoryDeletionJobRepository) CreateJob(ctx context.Context, job model.DeletionJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.appendJournal(job); err != nil {
+		return err
+	}
+	r.jobs[job.ID] = job
+	if isUnfinishedJob(job) {
+		r.unfinished = append(r.unfinished, job.ID)
+	}
+	r.compactIfNeeded()
+	return nil
+}
+
+// ClaimJobs переводит готовые к выполнению задачи в состояние model.DeletionJobRunning и возвращает их.
+// Просматриваются только ожидающие и выполняемые задачи.
+func (r *InMemoryDeletionJobRepository) ClaimJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.DeletionJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer r.compactIfNeeded()
+
+	var claimed []model.DeletionJob
+	for _, id := range r.unfinished {
+		if len(claimed) >= limit {
+			break
+		}
+
+		job := r.jobs[id]
+		ready := job.Status == model.DeletionJobPending && !job.NextAttemptAt.After(now)
+		stale := job.Status == model.DeletionJobRunning && !job.UpdatedAt.After(now.Add(-lease))
+		if !ready && !stale {
+			continue
+		}
+
+		job.Status = model.DeletionJobRunning
+		job.UpdatedAt = now
+		if err := r.appendJournal(job); err != nil {
+			return claimed, err
+		}
+		r.jobs[id] = job
+		claimed = append(claimed, job)
+	}
+	return claimed, nil
+}
+
+// UpdateJob сохраняет состояние задачи удаления.
+func (r *InMemoryDeletionJobRepository) UpdateJob(ctx context.Context, job model.DeletionJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.jobs[job.ID]; !ok {
+		return ErrRepoNotFound
+	}
+	if err := r.appendJournal(job); err != nil {
+		return err
+	}
+	r.jobs[job.ID] = job
+	if !isUnfinishedJob(job) {
+		r.unfinished = slices.DeleteFunc(r.unfinished, func(id string) bool { return id == job.ID })
+	}
+	r.compactIfNeeded()
+	return nil
+}
+
+// FindJob находит задачу удаления по идентификатору.
+func (r *InMemoryDeletionJobRepository) FindJob(ctx context.Context, id string) (*model.DeletionJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, ErrRepoNotFound
+	}
+	job.ShortIDs = slices.Clone(job.ShortIDs)
+	job.Results = slices.Clone(job.Results)
+	return &job, nil
+}
+
+// CountUnfinishedJobs возвращает количество ожидающих и выполняемых задач удаления.
+func (r *InMemoryDeletionJobRepository) CountUnfinishedJobs(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return int64(len(r.unfinished)), nil
+}
+
+// PurgeFinishedJobs удаляет выполненные и невыполненные задачи, завершенные не позднее заданного момента,
+// и переписывает журнал без них.
+func (r *InMemoryDeletionJobRepository) PurgeFinishedJobs(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, job := range r.jobs {
+		if !isUnfinishedJob(job) && !job.UpdatedAt.After(before) {
+			delete(r.jobs, id)
+			purged++
+		}
+	}
+
+	if purged > 0 && r.file != nil {
+		if err := r.compact(); err != nil {
+			return purged, err
+		}
+	}
+	return purged, nil
+}
+
+// Close закрывает файл журнала.
+func (r *InMemoryDeletionJobRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := errors.Join(r.file.Sync(), r.file.Close())
+	r.file = nil
+	return err
+}
+
+// isUnfinishedJob проверяет, ожидает ли задача выполнения или выполняется.
+func isUnfinishedJob(job model.DeletionJob) bool {
+	return job.Status == model.DeletionJobPending || job.Status == model.DeletionJobRunning
+}
+
+// appendJournal дописывает состояние задачи в журнал и сбрасывает его на диск.
+func (r *InMemoryDeletionJobRepository) appendJournal(job model.DeletionJob) error {
+	if r.file == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode deletion job: %w", err)
+	}
+	if _, err := r.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write deletion job journal: %w", err)
+	}
+	r.entries++
+	return r.file.Sync()
+}
+
+// compactIfNeeded переписывает журнал, если устаревших записей в нем больше, чем актуальных.
+// Ошибка переписывания не является ошибкой операции: записи остаются в журнале.
+func (r *InMemoryDeletionJobRepository) compactIfNeeded() {
+	if r.file == nil || r.entries < deletionJournalCompactThreshold || r.entries <= 2*len(r.jobs) {
+		return
+	}
+	if err := r.compact(); err != nil {
+		r.logger.Errorf("Failed to compact deletion job journal: %s", err.Error())
+	}
+}
+
+// compact переписывает журнал с одной записью на задачу и открывает его на дозапись.
+func (r *InMemoryDeletionJobRepository) compact() error {
+	if err := r.rewrite(); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(r.filePath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	if r.file != nil {
+		r.file.Close()
+	}
+	r.file = file
+	r.entries = len(r.jobs)
+	return nil
+}
+
+// load восстанавливает задачи из журнала и переписывает журнал, оставляя по одной записи на задачу.
+// Оборванная последняя запись (например, после аварийного завершения) пропускается;
+// поврежденная запись в середине журнала означает потерю данных, и возвращается ErrRepoJournalCorrupted.
+func (r *InMemoryDeletionJobRepository) load() error {
+	file, err := os.Open(r.filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if file != nil {
+		jobs, _, err := readJournal[model.DeletionJob](file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("deletion job journal %s: %w", r.filePath, err)
+		}
+		for _, job := range jobs {
+			r.jobs[job.ID] = job
+		}
+	}
+
+	for _, job := range r.sortedJobs() {
+		if isUnfinishedJob(job) {
+			r.unfinished = append(r.unfinished, job.ID)
+		}
+	}
+	return r.compact()
+}
+
+// sortedJobs возвращает задачи в порядке создания.
+func (r *InMemoryDeletionJobRepository) sortedJobs() []model.DeletionJob {
+	jobs := make([]model.DeletionJob, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		jobs = append(jobs, job)
+	}
+	slices.SortFunc(jobs, func(a, b model.DeletionJob) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return jobs
+}
+
+// rewrite атомарно записывает текущее состояние всех задач в журнал.
+func (r *InMemoryDeletionJobRepository) rewrite() error {
+	tmp, err := os.CreateTemp(filepath.Dir(r.filePath), filepath.Base(r.filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, job := range r.sortedJobs() {
+		if err := encoder.Encode(job); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.filePath)
+}
@@ -0,0 +1,180 @@
+package repository_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestInMemoryDeletionJobRepository_ClaimJobs(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	repo, err := repository.NewInMemoryDeletionJobRepository("", *logger)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	require.NoError(t, repo.CreateJob(ctx, *model.NewDeletionJob("ready", "user", []string{"a"}, now)))
+	delayed := model.NewDeletionJob("delayed", "user", []string{"b"}, now)
+	delayed.NextAttemptAt = now.Add(time.Hour)
+	require.NoError(t, repo.CreateJob(ctx, *delayed))
+
+	jobs, err := repo.ClaimJobs(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "ready", jobs[0].ID)
+	assert.Equal(t, model.DeletionJobRunning, jobs[0].Status)
+
+	// Выполняемая задача не выбирается повторно, пока не истекла аренда.
+	jobs, err = repo.ClaimJobs(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	jobs, err = repo.ClaimJobs(ctx, now.Add(2*time.Minute), time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "ready", jobs[0].ID)
+
+	count, err := repo.CountUnfinishedJobs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestInMemoryDeletionJobRepository_Persistence(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	path := filepath.Join(t.TempDir(), "storage.json.deletions")
+
+	repo, err := repository.NewInMemoryDeletionJobRepository(path, *logger)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	require.NoError(t, repo.CreateJob(ctx, *model.NewDeletionJob("done", "user", []string{"a"}, now)))
+	require.NoError(t, repo.CreateJob(ctx, *model.NewDeletionJob("pending", "user", []string{"b"}, now)))
+
+	job, err := repo.FindJob(ctx, "done")
+	require.NoError(t, err)
+	job.Status = model.DeletionJobDone
+	job.Results = []model.DeleteResult{{ShortID: "a", Status: model.DeleteStatusDeleted}}
+	require.NoError(t, repo.UpdateJob(ctx, *job))
+	require.NoError(t, repo.Close())
+
+	restored, err := repository.NewInMemoryDeletionJobRepository(path, *logger)
+	require.NoError(t, err)
+	defer restored.Close()
+
+	job, err = restored.FindJob(ctx, "done")
+	require.NoError(t, err)
+	assert.Equal(t, model.DeletionJobDone, job.Status)
+	assert.Equal(t, []model.DeleteResult{{ShortID: "a", Status: model.DeleteStatusDeleted}}, job.Results)
+
+	jobs, err := restored.ClaimJobs(ctx, time.Now().UTC(), time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "pending", jobs[0].ID)
+
+	_, err = restored.FindJob(ctx, "missing")
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+}
+
+func TestInMemoryDeletionJobRepository_PurgeFinishedJobs(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	path := filepath.Join(t.TempDir(), "storage.json.deletions")
+
+	repo, err := repository.NewInMemoryDeletionJobRepository(path, *logger)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	require.NoError(t, repo.CreateJob(ctx, *model.NewDeletionJob("old", "user", []string{"a"}, now.Add(-2*time.Hour))))
+	require.NoError(t, repo.CreateJob(ctx, *model.NewDeletionJob("recent", "user", []string{"b"}, now)))
+	require.NoError(t, repo.CreateJob(ctx, *model.NewDeletionJob("pending", "user", []string{"c"}, now.Add(-2*time.Hour))))
+
+	for _, finished := range []struct {
+		id        string
+		updatedAt time.Time
+	}{{"old", now.Add(-2 * time.Hour)}, {"recent", now}} {
+		job, err := repo.FindJob(ctx, finished.id)
+		require.NoError(t, err)
+		job.Status = model.DeletionJobDone
+		job.UpdatedAt = finished.updatedAt
+		require.NoError(t, repo.UpdateJob(ctx, *job))
+	}
+
+	purged, err := repo.PurgeFinishedJobs(ctx, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	_, err = repo.FindJob(ctx, "old")
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+	require.NoError(t, repo.Close())
+
+	restored, err := repository.NewInMemoryDeletionJobRepository(path, *logger)
+	require.NoError(t, err)
+	defer restored.Close()
+
+	_, err = restored.FindJob(ctx, "old")
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+	_, err = restored.FindJob(ctx, "recent")
+	assert.NoError(t, err)
+	count, err := restored.CountUnfinishedJobs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestInMemoryDeletionJobRepository_JournalCompaction(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	path := filepath.Join(t.TempDir(), "storage.json.deletions")
+
+	repo, err := repository.NewInMemoryDeletionJobRepository(path, *logger)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	now := time.Now().UTC()
+	require.NoError(t, repo.CreateJob(ctx, *model.NewDeletionJob("job", "user", []string{"a"}, now)))
+	for i := 0; i < 1000; i++ {
+		_, err := repo.ClaimJobs(ctx, now.Add(time.Duration(i)*time.Hour), time.Minute, 10)
+		require.NoError(t, err)
+	}
+
+	// Журнал переписывается во время работы и не растет с каждым изменением задачи.
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Less(t, bytes.Count(data, []byte("\n")), 1000)
+}
+
+func TestInMemoryDeletionJobRepository_JournalCorrupted(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	path := filepath.Join(t.TempDir(), "storage.json.deletions")
+
+	journal := []byte(`{"id":"one","user_id":"user","short_ids":["a"],"status":"pending"}
+garbage
+{"id":"two","user_id":"user","short_ids":["b"],"status":"pending"}
+`)
+	require.NoError(t, os.WriteFile(path, journal, 0666))
+
+	_, err := repository.NewInMemoryDeletionJobRepository(path, *logger)
+	assert.ErrorIs(t, err, repository.ErrRepoJournalCorrupted)
+
+	unchanged, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, journal, unchanged)
+
+	// Оборванная последняя запись пропускается.
+	require.NoError(t, os.WriteFile(path, journal[:bytes.Index(journal, []byte("garbage"))+len("garbage")], 0666))
+	repo, err := repository.NewInMemoryDeletionJobRepository(path, *logger)
+	require.NoError(t, err)
+	defer repo.Close()
+	count, err := repo.CountUnfinishedJobs(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,89 @@
+// Package repository содержит реализацию счетчика сокращенных идентификаторов в памяти с сохранением на диск.
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultSequenceBlockSize представляет количество значений счетчика, резервируемых одной записью на диск.
+const DefaultSequenceBlockSize = 1000
+
+// InMemorySequenceRepository представляет атомарный счетчик сокращенных идентификаторов в памяти.
+// При заданном пути к файлу счетчик резервирует значения блоками и сохраняет верхнюю границу блока до их выдачи,
+// поэтому после перезапуска выдача продолжается с конца последнего блока и значения не повторяются;
+// неиспользованный остаток блока пропускается.
+type InMemorySequenceRepository struct {
+	// mu представляет mutex для синхронизации резервирования блоков.
+	mu sync.Mutex
+	// next представляет последнее выданное значение счетчика.
+	next atomic.Uint64
+	// reserved представляет верхнюю границу зарезервированных значений.
+	reserved atomic.Uint64
+	// blockSize представляет количество значений, резервируемых за одну запись на диск.
+	blockSize uint64
+	// filePath представляет путь к файлу с верхней границей зарезервированных значений.
+	filePath string
+}
+
+// NewInMemorySequenceRepository возвращает новый экземпляр InMemorySequenceRepository.
+// Эта функция принимает путь к файлу счетчика (пустой путь отключает сохранение на диск) и размер резервируемого блока.
+func NewInMemorySequenceRepository(filePath string, blockSize uint64) (*InMemorySequenceRepository, error) {
+	repo := &InMemorySequenceRepository{
+		blockSize: max(blockSize, 1),
+		filePath:  filePath,
+	}
+
+	if filePath == "" {
+		repo.reserved.Store(math.MaxUint64)
+		return repo, nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return repo, nil
+		}
+		return nil, err
+	}
+
+	reserved, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sequence file %s: %w", filePath, err)
+	}
+	repo.next.Store(reserved)
+	repo.reserved.Store(reserved)
+	return repo, nil
+}
+
+// NextSequence возвращает следующее значение счетчика, начиная с единицы.
+func (r *InMemorySequenceRepository) NextSequence(ctx context.Context) (uint64, error) {
+	next := r.next.Add(1)
+	if next <= r.reserved.Load() {
+		return next, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for next > r.reserved.Load() {
+		reserved := r.reserved.Load() + r.blockSize
+		if err := r.persist(reserved); err != nil {
+			return 0, err
+		}
+		r.reserved.Store(reserved)
+	}
+	return next, nil
+}
+
+// persist атомарно записывает верхнюю границу зарезервированных значений в файл через временный файл.
+func (r *InMemorySequenceRepository) persist(reserved uint64) error {
+	return writeFileAtomic(r.filePath, []byte(strconv.FormatUint(reserved, 10)))
+}
@@ -0,0 +1,31 @@
+package repository_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemorySequenceRepository_Persistence(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "storage.json.seq")
+
+	repo, err := repository.NewInMemorySequenceRepository(path, 10)
+	require.NoError(t, err)
+	for want := uint64(1); want <= 12; want++ {
+		next, err := repo.NextSequence(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, want, next)
+	}
+
+	// После перезапуска выдача продолжается с конца зарезервированного блока.
+	restored, err := repository.NewInMemorySequenceRepository(path, 10)
+	require.NoError(t, err)
+	next, err := restored.NextSequence(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(21), next)
+}
@@ -0,0 +1,71 @@
+// Package repository содержит реализацию хранилища длины сокращенных идентификаторов в памяти с сохранением на диск.
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// InMemoryShortCodeLengthRepository представляет хранилище длины сокращенных идентификаторов в памяти.
+// При заданном пути к файлу длина записывается в файл при каждом увеличении и читается из него при запуске.
+type InMemoryShortCodeLengthRepository struct {
+	// mu представляет mutex для синхронизации доступа к длине.
+	mu sync.Mutex
+	// length представляет сохраненную длину.
+	length int
+	// filePath представляет путь к файлу с длиной.
+	filePath string
+}
+
+// NewInMemoryShortCodeLengthRepository возвращает новый экземпляр InMemoryShortCodeLengthRepository.
+// Эта функция принимает путь к файлу длины; пустой путь отключает сохранение на диск.
+func NewInMemoryShortCodeLengthRepository(filePath string) (*InMemoryShortCodeLengthRepository, error) {
+	repo := &InMemoryShortCodeLengthRepository{filePath: filePath}
+	if filePath == "" {
+		return repo, nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return repo, nil
+		}
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid short code length file %s: %q", filePath, data)
+	}
+	repo.length = length
+	return repo, nil
+}
+
+// LoadShortCodeLength возвращает сохраненную длину; 0 означает, что длина еще не сохранялась.
+func (r *InMemoryShortCodeLengthRepository) LoadShortCodeLength(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.length, nil
+}
+
+// SaveShortCodeLength сохраняет длину; меньшая длина не заменяет уже сохраненную большую.
+func (r *InMemoryShortCodeLengthRepository) SaveShortCodeLength(ctx context.Context, length int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if length <= r.length {
+		return nil
+	}
+	if r.filePath != "" {
+		if err := writeFileAtomic(r.filePath, []byte(strconv.Itoa(length))); err != nil {
+			return err
+		}
+	}
+	r.length = length
+	return nil
+}
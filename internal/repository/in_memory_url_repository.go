@@ -2,68 +2,96 @@
 package repository
 
 import (
+	"cmp"
 	"context"
-	"encoding/json"
+	"errors"
 	"os"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/oegegr/shortener/internal/model"
 
-	"github.com/samber/lo/mutable"
+	"github.com/samber/lo"
 	"go.uber.org/zap"
 )
 
+// domainURL представляет ключ карты оригинальных URL-адресов: оригинальный URL-адрес уникален в пределах домена.
+type domainURL struct {
+	// domain представляет домен сокращенного URL-адреса.
+	domain string
+	// url представляет оригинальный URL-адрес.
+	url string
+}
+
 // InMemoryURLRepository представляет репозиторий для работы с URL-адресами в памяти.
+// При заданном пути к файлу изменения записываются в журнал упреждающей записи,
+// который периодически сворачивается в снимок.
 type InMemoryURLRepository struct {
 	// mu представляет mutex для синхронизации доступа к данным.
 	mu sync.RWMutex
 	// shortIDMap представляет карту сокращенных идентификаторов URL-адресов.
 	shortIDMap map[string]model.URLItem
-	// urlMap представляет карту URL-адресов.
-	urlMap map[string]model.URLItem
+	// urlMap представляет карту URL-адресов по домену и оригинальному URL-адресу.
+	urlMap map[domainURL]model.URLItem
 	// userMap представляет карту URL-адресов пользователя.
 	userMap map[string][]model.URLItem
-	// fileStoragePath представляет путь к файлу для хранения данных.
+	// edits представляет историю изменений URL-адресов по сокращенному идентификатору.
+	edits map[string][]model.URLEdit
+	// tagIndex представляет инвертированный индекс меток: идентификатор пользователя -> метка -> сокращенные идентификаторы.
+	tagIndex map[string]map[string]map[string]struct{}
+	// fileStoragePath представляет путь к файлу снимка данных.
 	fileStoragePath string
 	// logger представляет логгер для записи сообщений.
 	logger zap.SugaredLogger
 	// persistent представляет флаг, указывающий, следует ли хранить данные в файле.
 	persistent bool
+	// journal представляет журнал упреждающей записи.
+	journal *urlJournal
+	// journalCfg представляет настройки журнала.
+	journalCfg JournalConfig
+	// stop представляет канал для остановки фонового сброса журнала.
+	stop chan struct{}
+	// wg представляет группу ожидания фонового сброса журнала.
+	wg sync.WaitGroup
+	// compacting представляет флаг выполняющегося в фоне сворачивания журнала в снимок.
+	compacting bool
+	// compactWG представляет группу ожидания фонового сворачивания журнала.
+	compactWG sync.WaitGroup
+	// closeOnce гарантирует однократное закрытие репозитория.
+	closeOnce sync.Once
 }
 
 // NewInMemoryURLRepository возвращает новый экземпляр InMemoryURLRepository.
-// Эта функция принимает путь к файлу для хранения данных и логгер.
-func NewInMemoryURLRepository(fileStoragePath string, logger zap.SugaredLogger) (*InMemoryURLRepository, error) {
-	items, err := loadData(fileStoragePath, logger)
-	if err != nil {
-		logger.Fatal("Failed to create InMemory repository with error: %w", err.Error())
-		return nil, err
+// Эта функция принимает путь к файлу для хранения данных, настройки журнала и логгер.
+// При запуске данные загружаются из снимка, после чего применяются записи журнала.
+func NewInMemoryURLRepository(fileStoragePath string, journalCfg JournalConfig, logger zap.SugaredLogger) (*InMemoryURLRepository, error) {
+	storage := &InMemoryURLRepository{
+		fileStoragePath: fileStoragePath,
+		logger:          logger,
+		persistent:      fileStoragePath != "",
+		urlMap:          make(map[domainURL]model.URLItem),
+		shortIDMap:      make(map[string]model.URLItem),
+		userMap:         make(map[string][]model.URLItem),
+		edits:           make(map[string][]model.URLEdit),
+		tagIndex:        make(map[string]map[string]map[string]struct{}),
+		journalCfg:      journalCfg,
+		stop:            make(chan struct{}),
 	}
 
-	shortIDs := make(map[string]model.URLItem, len(items))
-	urls := make(map[string]model.URLItem, len(items))
-	users := make(map[string][]model.URLItem, len(items))
-
-	for _, item := range items {
-		shortIDs[item.ShortID] = item
-		urls[item.URL] = item
-
-		var userItems []model.URLItem
-		userItems, ok := users[item.UserID]
-		if !ok {
-			userItems = []model.URLItem{}
-		}
+	if !storage.persistent {
+		return storage, nil
+	}
 
-		users[item.UserID] = append(userItems, item)
+	if err := storage.loadData(); err != nil {
+		logger.Errorf("Failed to create InMemory repository with error: %s", err.Error())
+		return nil, err
 	}
 
-	storage := &InMemoryURLRepository{
-		fileStoragePath: fileStoragePath,
-		logger:          logger,
-		persistent:      fileStoragePath != "",
-		urlMap:          urls,
-		shortIDMap:      shortIDs,
-		userMap:         users,
+	if journalCfg.SyncPolicy == SyncInterval && journalCfg.SyncInterval > 0 {
+		storage.wg.Add(1)
+		go storage.syncLoop()
 	}
 
 	return storage, nil
@@ -80,71 +108,237 @@ func (repo *InMemoryURLRepository) CreateURL(ctx context.Context, items []model.
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
+	batchIDs := make(map[string]struct{}, len(items))
 	for _, item := range items {
 		_, ok := repo.shortIDMap[item.ShortID]
+		if !ok {
+			_, ok = batchIDs[item.ShortID]
+		}
 		if ok {
+			if item.IsCustom {
+				return ErrRepoAliasAlreadyExists
+			}
 			return ErrRepoShortIDAlreadyExists
 		}
+		batchIDs[item.ShortID] = struct{}{}
 
-		_, ok = repo.urlMap[item.URL]
+		_, ok = repo.urlMap[domainURL{item.Domain, item.URL}]
 		if ok {
 			return ErrRepoURLAlreadyExists
 		}
 	}
 
+	events := make([]journalEvent, 0, len(items))
 	for _, item := range items {
-		repo.shortIDMap[item.ShortID] = item
-		repo.urlMap[item.URL] = item
-		repo.userMap[item.UserID] = append(repo.userMap[item.UserID], item)
+		events = append(events, journalEvent{Op: journalOpCreate, Item: &item})
+	}
+	if err := repo.appendJournal(events); err != nil {
+		return err
 	}
 
-	if repo.persistent {
-		err := repo.saveData()
-		if err != nil {
-			return err
-		}
+	for _, item := range items {
+		repo.applyCreate(item)
 	}
 
+	repo.compactIfNeeded()
 	return nil
 }
 
-// DeleteURL удаляет URL-адреса из репозитория.
-// Эта функция принимает список идентификаторов URL-адресов для удаления.
-func (repo *InMemoryURLRepository) DeleteURL(ctx context.Context, ids []string) error {
+// DeleteURL помечает удаленными URL-адреса пользователя в репозитории.
+// Эта функция принимает идентификатор пользователя и список идентификаторов URL-адресов для удаления.
+// URL-адреса других пользователей не изменяются и попадают в результат со статусом DeleteStatusNotOwned.
+func (repo *InMemoryURLRepository) DeleteURL(ctx context.Context, userID string, ids []string) ([]model.DeleteResult, error) {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
+	results := make([]model.DeleteResult, 0, len(ids))
 	for _, id := range ids {
 		item, ok := repo.shortIDMap[id]
 
 		if !ok {
-			return ErrRepoNotFound
+			results = append(results, model.DeleteResult{ShortID: id, Status: model.DeleteStatusNotFound})
+			continue
 		}
 
-		item.IsDeleted = true
-		repo.shortIDMap[item.ShortID] = item
-		repo.urlMap[item.URL] = item
-		userItems := repo.userMap[item.UserID]
+		if item.UserID != userID {
+			results = append(results, model.DeleteResult{ShortID: id, Status: model.DeleteStatusNotOwned})
+			continue
+		}
 
-		for idx, userItem := range userItems {
-			if userItem.ShortID == id {
-				userItems[idx].IsDeleted = true
-				break
-			}
+		results = append(results, model.DeleteResult{ShortID: id, Status: model.DeleteStatusDeleted})
+	}
+
+	var events []journalEvent
+	for _, result := range results {
+		if result.Status == model.DeleteStatusDeleted {
+			events = append(events, journalEvent{Op: journalOpDelete, ShortID: result.ShortID})
 		}
+	}
+	if err := repo.appendJournal(events); err != nil {
+		return nil, err
+	}
 
+	for _, event := range events {
+		repo.applyDelete(event.ShortID)
 	}
 
-	if repo.persistent {
-		err := repo.saveData()
-		if err != nil {
-			return err
+	repo.compactIfNeeded()
+	return results, nil
+}
+
+// UpdateURL изменяет оригинальный URL-адрес пользователя в репозитории и сохраняет запись в истории изменений.
+// Эта функция возвращает ErrRepoNotFound для отсутствующего или удаленного URL-адреса, ErrRepoNotOwned для URL-адреса
+// другого пользователя и ErrRepoURLAlreadyExists, если новый URL-адрес уже сокращен.
+// Если URL-адрес не изменился, запись в истории не создается.
+func (repo *InMemoryURLRepository) UpdateURL(ctx context.Context, edit model.URLEdit) (*model.URLEdit, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	item, ok := repo.shortIDMap[edit.ShortID]
+	if !ok || item.IsDeleted {
+		return nil, ErrRepoNotFound
+	}
+	if item.UserID != edit.UserID {
+		return nil, ErrRepoNotOwned
+	}
+	if item.IsExpired(edit.EditedAt) {
+		return nil, ErrRepoURLExpired
+	}
+
+	edit.OldURL = item.URL
+	if edit.OldURL == edit.NewURL {
+		return &edit, nil
+	}
+	if _, ok := repo.urlMap[domainURL{item.Domain, edit.NewURL}]; ok {
+		return nil, ErrRepoURLAlreadyExists
+	}
+
+	item.URL = edit.NewURL
+	item.UpdatedAt = edit.EditedAt
+	if err := repo.appendJournal([]journalEvent{{Op: journalOpUpdate, Item: &item, Edit: &edit}}); err != nil {
+		return nil, err
+	}
+	repo.applyUpdate(item, edit)
+
+	repo.compactIfNeeded()
+	return &edit, nil
+}
+
+// SetTags заменяет метки URL-адреса пользователя.
+// Эта функция возвращает ErrRepoNotFound для отсутствующего или удаленного URL-адреса и ErrRepoNotOwned для URL-адреса другого пользователя.
+func (repo *InMemoryURLRepository) SetTags(ctx context.Context, userID string, shortID string, tags []string, updatedAt time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	item, ok := repo.shortIDMap[shortID]
+	if !ok || item.IsDeleted {
+		return ErrRepoNotFound
+	}
+	if item.UserID != userID {
+		return ErrRepoNotOwned
+	}
+
+	item.Tags = slices.Clone(tags)
+	item.UpdatedAt = updatedAt
+	if err := repo.appendJournal([]journalEvent{{Op: journalOpTags, Item: &item}}); err != nil {
+		return err
+	}
+	repo.applyCreate(item)
+
+	repo.compactIfNeeded()
+	return nil
+}
+
+// CountTagsByUser возвращает метки пользователя с количеством не удаленных URL-адресов,
+// отсортированные по убыванию количества, а при равном количестве — по метке.
+func (repo *InMemoryURLRepository) CountTagsByUser(ctx context.Context, userID string) ([]model.TagCount, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	counts := []model.TagCount{}
+	for tag, shortIDs := range repo.tagIndex[userID] {
+		var count int64
+		for shortID := range shortIDs {
+			if !repo.shortIDMap[shortID].IsDeleted {
+				count++
+			}
+		}
+		if count > 0 {
+			counts = append(counts, model.TagCount{Tag: tag, Count: count})
+		}
+	}
+
+	slices.SortFunc(counts, func(a, b model.TagCount) int {
+		if a.Count != b.Count {
+			return cmp.Compare(b.Count, a.Count)
 		}
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	return counts, nil
+}
+
+// MarkAccessed сохраняет моменты последних переходов по URL-адресам.
+// Более ранний момент не заменяет уже сохраненный более поздний; неизвестные идентификаторы пропускаются.
+// Моменты переходов хранятся только в памяти и попадают на диск со снимком при сворачивании журнала или закрытии репозитория:
+// переходы не записываются в журнал, чтобы не раздувать его, а потеря последних моментов при аварийной остановке допустима.
+func (repo *InMemoryURLRepository) MarkAccessed(ctx context.Context, accessed map[string]time.Time) error {
+	if len(accessed) == 0 {
+		return nil
 	}
 
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.applyAccess(accessed)
 	return nil
 }
 
+// FindURLEdits возвращает историю изменений URL-адреса в хронологическом порядке.
+func (repo *InMemoryURLRepository) FindURLEdits(ctx context.Context, shortID string) ([]model.URLEdit, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return slices.Clone(repo.edits[shortID]), nil
+}
+
+// FindExpiredURL возвращает сокращенные идентификаторы URL-адресов в репозитории, срок действия которых истек к заданному моменту.
+func (repo *InMemoryURLRepository) FindExpiredURL(ctx context.Context, before time.Time) ([]string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var ids []string
+	for id, item := range repo.shortIDMap {
+		if item.IsExpired(before) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// PurgeExpiredURL физически удаляет из репозитория URL-адреса из заданного списка, срок действия которых истек к заданному моменту,
+// вместе с историей изменений и метками.
+// Эта функция возвращает количество удаленных URL-адресов.
+func (repo *InMemoryURLRepository) PurgeExpiredURL(ctx context.Context, ids []string, before time.Time) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var events []journalEvent
+	for _, id := range ids {
+		if item, ok := repo.shortIDMap[id]; ok && item.IsExpired(before) {
+			events = append(events, journalEvent{Op: journalOpPurge, ShortID: id})
+		}
+	}
+	if err := repo.appendJournal(events); err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		repo.applyPurge(event.ShortID)
+	}
+
+	repo.compactIfNeeded()
+	return int64(len(events)), nil
+}
+
 // FindURLByID находит URL-адрес в репозитории по идентификатору URL-адреса.
 // Эта функция принимает идентификатор URL-адреса для поиска.
 func (repo *InMemoryURLRepository) FindURLByID(ctx context.Context, id string) (*model.URLItem, error) {
@@ -154,19 +348,19 @@ func (repo *InMemoryURLRepository) FindURLByID(ctx context.Context, id string) (
 	if !ok || item.IsDeleted {
 		return nil, ErrRepoNotFound
 	}
-	return model.NewURLItem(item.URL, id, item.UserID, item.IsDeleted), nil
+	return &item, nil
 }
 
-// FindURLByURL находит URL-адрес в репозитории по URL-адресу.
-// Эта функция принимает URL-адрес для поиска.
-func (repo *InMemoryURLRepository) FindURLByURL(ctx context.Context, url string) (*model.URLItem, error) {
+// FindURLByURL находит URL-адрес в репозитории по домену и оригинальному URL-адресу.
+// Эта функция принимает домен и URL-адрес для поиска.
+func (repo *InMemoryURLRepository) FindURLByURL(ctx context.Context, domain string, url string) (*model.URLItem, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
-	item, ok := repo.urlMap[url]
+	item, ok := repo.urlMap[domainURL{domain, url}]
 	if !ok || item.IsDeleted {
 		return nil, ErrRepoNotFound
 	}
-	return model.NewURLItem(url, item.ShortID, item.UserID, item.IsDeleted), nil
+	return &item, nil
 }
 
 // FindURLByUser находит URL-адреса в репозитории по идентификатору пользователя.
@@ -180,7 +374,7 @@ func (repo *InMemoryURLRepository) FindURLByUser(ctx context.Context, userID str
 		return nil, ErrRepoNotFound
 	}
 
-	result := mutable.Filter(items, func(item model.URLItem) bool { return !item.IsDeleted })
+	result := lo.Filter(items, func(item model.URLItem, _ int) bool { return !item.IsDeleted })
 
 	if len(result) == 0 {
 		return nil, ErrRepoNotFound
@@ -188,6 +382,76 @@ func (repo *InMemoryURLRepository) FindURLByUser(ctx context.Context, userID str
 	return result, nil
 }
 
+// FindURLPageByUser возвращает страницу URL-адресов пользователя, отсортированных по времени создания и сокращенному идентификатору.
+func (repo *InMemoryURLRepository) FindURLPageByUser(ctx context.Context, userID string, query model.UserURLQuery) (*model.UserURLPage, error) {
+	repo.mu.RLock()
+	candidates := repo.userMap[userID]
+	if query.Tag != "" {
+		candidates = nil
+		for shortID := range repo.tagIndex[userID][query.Tag] {
+			candidates = append(candidates, repo.shortIDMap[shortID])
+		}
+	}
+
+	search := strings.ToLower(query.Search)
+	items := lo.Filter(candidates, func(item model.URLItem, _ int) bool {
+		if item.IsDeleted && !query.IncludeDeleted {
+			return false
+		}
+		if search != "" && !strings.Contains(strings.ToLower(item.URL), search) {
+			return false
+		}
+		return query.Cursor == nil || query.Cursor.After(item, query.Order)
+	})
+	repo.mu.RUnlock()
+
+	slices.SortFunc(items, func(a, b model.URLItem) int {
+		cmp := a.CreatedAt.Compare(b.CreatedAt)
+		if cmp == 0 {
+			cmp = strings.Compare(a.ShortID, b.ShortID)
+		}
+		if query.Order == model.SortAsc {
+			return cmp
+		}
+		return -cmp
+	})
+
+	page := &model.UserURLPage{Items: items}
+	if query.Limit > 0 && len(items) > query.Limit {
+		page.Items = items[:query.Limit]
+		page.Next = model.NewURLCursor(page.Items[query.Limit-1])
+	}
+	return page, nil
+}
+
+// CountURLs возвращает количество не удаленных URL-адресов в репозитории.
+func (repo *InMemoryURLRepository) CountURLs(ctx context.Context) (int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var count int64
+	for _, item := range repo.shortIDMap {
+		if !item.IsDeleted {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CountUsers возвращает количество пользователей, у которых есть не удаленные URL-адреса в репозитории.
+func (repo *InMemoryURLRepository) CountUsers(ctx context.Context) (int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var count int64
+	for _, items := range repo.userMap {
+		if slices.ContainsFunc(items, func(item model.URLItem) bool { return !item.IsDeleted }) {
+			count++
+		}
+	}
+	return count, nil
+}
+
 // Exists проверяет, существует ли URL-адрес в репозитории.
 // Эта функция принимает идентификатор URL-адреса для проверки.
 func (repo *InMemoryURLRepository) Exists(ctx context.Context, id string) bool {
@@ -197,36 +461,318 @@ func (repo *InMemoryURLRepository) Exists(ctx context.Context, id string) bool {
 	return ok
 }
 
-// saveData сохраняет данные в файле.
-func (repo *InMemoryURLRepository) saveData() error {
-	file, err := os.OpenFile(repo.fileStoragePath, os.O_WRONLY|os.O_CREATE, 0666)
-	if err != nil {
+// Close сбрасывает журнал на диск, сворачивает его в снимок и освобождает файловые ресурсы.
+func (repo *InMemoryURLRepository) Close() error {
+	if !repo.persistent {
+		return nil
+	}
+
+	var err error
+	repo.closeOnce.Do(func() {
+		close(repo.stop)
+		repo.wg.Wait()
+		repo.compactWG.Wait()
+
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		err = errors.Join(repo.compact(), repo.journal.Close())
+	})
+	return err
+}
+
+// applyCreate добавляет URL-адрес в карты репозитория.
+// Повторное применение той же записи не создает дубликатов.
+func (repo *InMemoryURLRepository) applyCreate(item model.URLItem) {
+	if old, ok := repo.shortIDMap[item.ShortID]; ok {
+		if old.URL != item.URL {
+			delete(repo.urlMap, domainURL{old.Domain, old.URL})
+		}
+		repo.unindexTags(old)
+	}
+	repo.indexTags(item)
+	repo.shortIDMap[item.ShortID] = item
+	repo.urlMap[domainURL{item.Domain, item.URL}] = item
+
+	userItems := repo.userMap[item.UserID]
+	idx := slices.IndexFunc(userItems, func(userItem model.URLItem) bool { return userItem.ShortID == item.ShortID })
+	if idx >= 0 {
+		userItems[idx] = item
+		return
+	}
+	repo.userMap[item.UserID] = append(userItems, item)
+}
+
+// indexTags добавляет метки URL-адреса в инвертированный индекс.
+func (repo *InMemoryURLRepository) indexTags(item model.URLItem) {
+	if len(item.Tags) == 0 {
+		return
+	}
+	userTags, ok := repo.tagIndex[item.UserID]
+	if !ok {
+		userTags = make(map[string]map[string]struct{})
+		repo.tagIndex[item.UserID] = userTags
+	}
+	for _, tag := range item.Tags {
+		if userTags[tag] == nil {
+			userTags[tag] = make(map[string]struct{})
+		}
+		userTags[tag][item.ShortID] = struct{}{}
+	}
+}
+
+// unindexTags удаляет метки URL-адреса из инвертированного индекса.
+func (repo *InMemoryURLRepository) unindexTags(item model.URLItem) {
+	userTags := repo.tagIndex[item.UserID]
+	for _, tag := range item.Tags {
+		delete(userTags[tag], item.ShortID)
+		if len(userTags[tag]) == 0 {
+			delete(userTags, tag)
+		}
+	}
+}
+
+// applyUpdate заменяет URL-адрес в картах репозитория и добавляет запись в историю изменений.
+// Повторное применение той же записи (например, при воспроизведении журнала после прерванного сворачивания) не создает дубликатов.
+func (repo *InMemoryURLRepository) applyUpdate(item model.URLItem, edit model.URLEdit) {
+	repo.applyCreate(item)
+
+	edits := repo.edits[edit.ShortID]
+	if slices.ContainsFunc(edits, func(e model.URLEdit) bool { return e.NewURL == edit.NewURL && e.EditedAt.Equal(edit.EditedAt) }) {
+		return
+	}
+	repo.edits[edit.ShortID] = append(edits, edit)
+}
+
+// applyAccess обновляет моменты последних переходов по URL-адресам.
+func (repo *InMemoryURLRepository) applyAccess(accessed map[string]time.Time) {
+	for shortID, ts := range accessed {
+		item, ok := repo.shortIDMap[shortID]
+		if !ok || (item.LastAccessedAt != nil && !ts.After(*item.LastAccessedAt)) {
+			continue
+		}
+		item.LastAccessedAt = &ts
+		repo.applyCreate(item)
+	}
+}
+
+// applyDelete помечает URL-адрес удаленным.
+func (repo *InMemoryURLRepository) applyDelete(id string) {
+	item, ok := repo.shortIDMap[id]
+	if !ok {
+		return
+	}
+
+	item.IsDeleted = true
+	repo.shortIDMap[item.ShortID] = item
+	repo.urlMap[domainURL{item.Domain, item.URL}] = item
+
+	userItems := repo.userMap[item.UserID]
+	for idx, userItem := range userItems {
+		if userItem.ShortID == id {
+			userItems[idx].IsDeleted = true
+			break
+		}
+	}
+}
+
+// applyPurge удаляет URL-адрес из карт репозитория.
+func (repo *InMemoryURLRepository) applyPurge(id string) {
+	item, ok := repo.shortIDMap[id]
+	if !ok {
+		return
+	}
+
+	delete(repo.shortIDMap, id)
+	delete(repo.urlMap, domainURL{item.Domain, item.URL})
+	delete(repo.edits, id)
+	repo.unindexTags(item)
+	repo.userMap[item.UserID] = slices.DeleteFunc(repo.userMap[item.UserID], func(userItem model.URLItem) bool {
+		return userItem.ShortID == id
+	})
+}
+
+// appendJournal записывает события в журнал до их применения к данным в памяти.
+func (repo *InMemoryURLRepository) appendJournal(events []journalEvent) error {
+	if !repo.persistent || len(events) == 0 {
+		return nil
+	}
+	return repo.journal.Append(events...)
+}
+
+// compactIfNeeded запускает сворачивание журнала в снимок, если количество записей превысило порог.
+// Под блокировкой данные копируются, а журнал переименовывается в файл сворачиваемого журнала;
+// снимки записываются на диск в фоне, не блокируя операции с репозиторием.
+// Ошибка сворачивания не является ошибкой операции: записи остаются в журналах до следующего сворачивания.
+func (repo *InMemoryURLRepository) compactIfNeeded() {
+	if !repo.persistent || repo.compacting || repo.journalCfg.CompactThreshold <= 0 || repo.journal.entries < repo.journalCfg.CompactThreshold {
+		return
+	}
+
+	compactingPath := repo.fileStoragePath + compactingSuffix
+	// Файл сворачиваемого журнала остается после неудачного сворачивания; новые записи остаются в текущем журнале,
+	// пока снимок не будет сохранен и файл не будет удален.
+	if _, err := os.Stat(compactingPath); errors.Is(err, os.ErrNotExist) {
+		if err := repo.journal.Rotate(compactingPath); err != nil {
+			repo.logger.Errorf("Failed to rotate journal: %s", err.Error())
+			return
+		}
+	}
+
+	items, edits := repo.snapshotData()
+	repo.compacting = true
+	repo.compactWG.Add(1)
+	go func() {
+		defer repo.compactWG.Done()
+
+		err := repo.writeSnapshots(items, edits)
+		if err == nil {
+			err = removeJournalFile(compactingPath)
+		}
+		if err != nil {
+			repo.logger.Errorf("Failed to compact journal: %s", err.Error())
+		} else {
+			repo.logger.Debugf("Compacted journal into snapshot of %d items", len(items))
+		}
+
+		repo.mu.Lock()
+		repo.compacting = false
+		repo.mu.Unlock()
+	}()
+}
+
+// compact сохраняет снимки данных и истории изменений и очищает журналы.
+// Эта функция вызывается под блокировкой при загрузке и закрытии репозитория, когда фоновое сворачивание не выполняется.
+func (repo *InMemoryURLRepository) compact() error {
+	items, edits := repo.snapshotData()
+	if err := repo.writeSnapshots(items, edits); err != nil {
 		return err
 	}
-	defer file.Close()
+	if err := removeJournalFile(repo.fileStoragePath + compactingSuffix); err != nil {
+		return err
+	}
+	repo.logger.Debugf("Compacted %d journal entries into snapshot of %d items", repo.journal.entries, len(items))
+	return repo.journal.Reset()
+}
 
-	var items []model.URLItem
+// snapshotData возвращает копии URL-адресов и истории изменений для записи снимков.
+func (repo *InMemoryURLRepository) snapshotData() ([]model.URLItem, []model.URLEdit) {
+	items := make([]model.URLItem, 0, len(repo.shortIDMap))
 	for _, item := range repo.shortIDMap {
-		repo.logger.Debugln("Create UrlItem", item.ShortID, item.URL)
-		items = append(items, *model.NewURLItem(item.URL, item.ShortID, item.UserID, item.IsDeleted))
+		items = append(items, item)
 	}
 
-	return json.NewEncoder(file).Encode(items)
+	var edits []model.URLEdit
+	for _, itemEdits := range repo.edits {
+		edits = append(edits, itemEdits...)
+	}
+	return items, edits
 }
 
-// loadData загружает данные из файла.
-func loadData(fileStoragePath string, logger zap.SugaredLogger) ([]model.URLItem, error) {
-	var items []model.URLItem
-	if fileStoragePath != "" {
-		file, err := os.OpenFile(fileStoragePath, os.O_RDONLY|os.O_CREATE, 0666)
-		if err != nil {
-			return nil, err
+// writeSnapshots записывает снимки истории изменений и данных.
+// Оба снимка сначала полностью записываются во временные файлы и только затем переименовываются,
+// поэтому ошибка записи не оставляет новый снимок одного файла рядом со старым снимком другого.
+func (repo *InMemoryURLRepository) writeSnapshots(items []model.URLItem, edits []model.URLEdit) error {
+	editsTmp, err := writeSnapshotTemp(repo.fileStoragePath+editsSuffix, edits)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(editsTmp)
+
+	itemsTmp, err := writeSnapshotTemp(repo.fileStoragePath, items)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(itemsTmp)
+
+	if err := os.Rename(editsTmp, repo.fileStoragePath+editsSuffix); err != nil {
+		return err
+	}
+	return os.Rename(itemsTmp, repo.fileStoragePath)
+}
+
+// syncLoop периодически сбрасывает журнал на диск.
+func (repo *InMemoryURLRepository) syncLoop() {
+	defer repo.wg.Done()
+
+	ticker := time.NewTicker(repo.journalCfg.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			repo.mu.Lock()
+			err := repo.journal.Sync()
+			repo.mu.Unlock()
+			if err != nil {
+				repo.logger.Errorf("Failed to sync journal: %s", err.Error())
+			}
+		case <-repo.stop:
+			return
 		}
-		defer file.Close()
+	}
+}
 
-		json.NewDecoder(file).Decode(&items)
-		logger.Debugln("Load UrlItems", items)
-		return items, nil
+// loadData загружает снимок данных и применяет к нему записи журнала.
+func (repo *InMemoryURLRepository) loadData() error {
+	items, err := readSnapshot[model.URLItem](repo.fileStoragePath)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		// В снимках, сохраненных до появления поля updated_at, момент изменения совпадает с моментом создания.
+		if item.UpdatedAt.IsZero() {
+			item.UpdatedAt = item.CreatedAt
+		}
+		repo.applyCreate(item)
 	}
-	return []model.URLItem{}, nil
+
+	edits, err := readSnapshot[model.URLEdit](repo.fileStoragePath + editsSuffix)
+	if err != nil {
+		return err
+	}
+	for _, edit := range edits {
+		repo.edits[edit.ShortID] = append(repo.edits[edit.ShortID], edit)
+	}
+
+	// Журнал, сворачивание которого не завершилось, содержит записи, предшествующие записям текущего журнала.
+	compactingEvents, err := readJournalFile(repo.fileStoragePath + compactingSuffix)
+	if err != nil {
+		return err
+	}
+
+	journal, journalEvents, err := openURLJournal(repo.fileStoragePath+journalSuffix, repo.journalCfg.SyncPolicy, repo.logger)
+	if err != nil {
+		return err
+	}
+	repo.journal = journal
+
+	events := append(compactingEvents, journalEvents...)
+	for _, event := range events {
+		switch event.Op {
+		case journalOpCreate:
+			if event.Item != nil {
+				repo.applyCreate(*event.Item)
+			}
+		case journalOpDelete:
+			repo.applyDelete(event.ShortID)
+		case journalOpPurge:
+			repo.applyPurge(event.ShortID)
+		case journalOpUpdate:
+			if event.Item != nil && event.Edit != nil {
+				repo.applyUpdate(*event.Item, *event.Edit)
+			}
+		case journalOpTags:
+			if event.Item != nil {
+				repo.applyCreate(*event.Item)
+			}
+		default:
+			repo.logger.Warnf("Skip unknown journal operation %q", event.Op)
+		}
+	}
+	repo.logger.Debugf("Loaded %d items from snapshot and replayed %d journal entries", len(items), len(events))
+
+	if len(events) > 0 {
+		return repo.compact()
+	}
+	return nil
 }
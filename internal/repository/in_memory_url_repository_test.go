@@ -0,0 +1,490 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestInMemoryURLRepository_DeleteURL_Ownership(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	repo, err := repository.NewInMemoryURLRepository("", repository.DefaultJournalConfig(), *logger)
+	require.NoError(t, err)
+
+	err = repo.CreateURL(ctx, []model.URLItem{
+		*model.NewURLItem("https://owner.com", "own", "owner", false),
+		*model.NewURLItem("https://other.com", "other", "stranger", false),
+	})
+	require.NoError(t, err)
+
+	results, err := repo.DeleteURL(ctx, "owner", []string{"own", "other", "missing"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []model.DeleteResult{
+		{ShortID: "own", Status: model.DeleteStatusDeleted},
+		{ShortID: "other", Status: model.DeleteStatusNotOwned},
+		{ShortID: "missing", Status: model.DeleteStatusNotFound},
+	}, results)
+
+	_, err = repo.FindURLByID(ctx, "own")
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+
+	item, err := repo.FindURLByID(ctx, "other")
+	require.NoError(t, err)
+	assert.False(t, item.IsDeleted)
+}
+
+func TestInMemoryURLRepository_PurgeExpiredURL(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	repo, err := repository.NewInMemoryURLRepository("", repository.DefaultJournalConfig(), *logger)
+	require.NoError(t, err)
+
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+
+	expired := *model.NewURLItem("https://expired.com", "expired", "user", false)
+	expired.ExpiresAt = &past
+	alive := *model.NewURLItem("https://alive.com", "alive", "user", false)
+	alive.ExpiresAt = &future
+	permanent := *model.NewURLItem("https://permanent.com", "permanent", "user", false)
+
+	require.NoError(t, repo.CreateURL(ctx, []model.URLItem{expired, alive, permanent}))
+
+	ids, err := repo.FindExpiredURL(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"expired"}, ids)
+
+	deleted, err := repo.PurgeExpiredURL(ctx, []string{"expired", "alive"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	assert.False(t, repo.Exists(ctx, "expired"))
+	assert.True(t, repo.Exists(ctx, "alive"))
+	assert.True(t, repo.Exists(ctx, "permanent"))
+
+	items, err := repo.FindURLByUser(ctx, "user")
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+}
+
+func TestInMemoryURLRepository_Counts(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	repo, err := repository.NewInMemoryURLRepository("", repository.DefaultJournalConfig(), *logger)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.CreateURL(ctx, []model.URLItem{
+		*model.NewURLItem("https://a.com", "a", "first", false),
+		*model.NewURLItem("https://b.com", "b", "first", false),
+		*model.NewURLItem("https://c.com", "c", "second", false),
+	}))
+	_, err = repo.DeleteURL(ctx, "second", []string{"c"})
+	require.NoError(t, err)
+
+	urls, err := repo.CountURLs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), urls)
+
+	users, err := repo.CountUsers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), users)
+}
+
+func TestInMemoryURLRepository_JournalReplay(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	path := filepath.Join(t.TempDir(), "storage.json")
+	journalCfg := repository.JournalConfig{SyncPolicy: repository.SyncAlways, CompactThreshold: 0}
+
+	repo, err := repository.NewInMemoryURLRepository(path, journalCfg, *logger)
+	require.NoError(t, err)
+
+	err = repo.CreateURL(ctx, []model.URLItem{
+		*model.NewURLItem("https://one.com", "one", "user", false),
+		*model.NewURLItem("https://two.com", "two", "user", false),
+	})
+	require.NoError(t, err)
+	_, err = repo.DeleteURL(ctx, "user", []string{"two"})
+	require.NoError(t, err)
+
+	// Имитируем аварийное завершение: снимок не сохраняется, в журнале остается оборванная запись.
+	file, err := os.OpenFile(path+".journal", os.O_WRONLY|os.O_APPEND, 0666)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"op":"create","item":{"short_id":"thr`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	restored, err := repository.NewInMemoryURLRepository(path, journalCfg, *logger)
+	require.NoError(t, err)
+	defer restored.Close()
+
+	item, err := restored.FindURLByID(ctx, "one")
+	require.NoError(t, err)
+	assert.Equal(t, "https://one.com", item.URL)
+
+	_, err = restored.FindURLByID(ctx, "two")
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+	assert.False(t, restored.Exists(ctx, "thr"))
+
+	items, err := restored.FindURLByUser(ctx, "user")
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+}
+
+func TestInMemoryURLRepository_JournalCorrupted(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	path := filepath.Join(t.TempDir(), "storage.json")
+	journalCfg := repository.JournalConfig{SyncPolicy: repository.SyncAlways, CompactThreshold: 0}
+
+	// Поврежденная запись в середине журнала, за которой следуют корректные записи.
+	journal := []byte(`{"op":"create","item":{"short_id":"one","original_url":"https://one.com","user_id":"user"}}
+garbage
+{"op":"create","item":{"short_id":"two","original_url":"https://two.com","user_id":"user"}}
+`)
+	require.NoError(t, os.WriteFile(path+".journal", journal, 0666))
+
+	_, err := repository.NewInMemoryURLRepository(path, journalCfg, *logger)
+	assert.ErrorIs(t, err, repository.ErrRepoJournalCorrupted)
+
+	// Журнал не усекается, чтобы записи после поврежденной строки можно было восстановить.
+	unchanged, err := os.ReadFile(path + ".journal")
+	require.NoError(t, err)
+	assert.Equal(t, journal, unchanged)
+}
+
+func TestInMemoryURLRepository_CompactingJournalReplay(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	path := filepath.Join(t.TempDir(), "storage.json")
+	journalCfg := repository.JournalConfig{SyncPolicy: repository.SyncAlways, CompactThreshold: 0}
+
+	// Имитируем аварийное завершение во время сворачивания: снимок не записан, журнал переименован.
+	compacting := []byte(`{"op":"create","item":{"short_id":"one","original_url":"https://one.com","user_id":"user"}}
+`)
+	require.NoError(t, os.WriteFile(path+".journal.compacting", compacting, 0666))
+	journal := []byte(`{"op":"delete","short_id":"one"}
+{"op":"create","item":{"short_id":"two","original_url":"https://two.com","user_id":"user"}}
+`)
+	require.NoError(t, os.WriteFile(path+".journal", journal, 0666))
+
+	repo, err := repository.NewInMemoryURLRepository(path, journalCfg, *logger)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	_, err = repo.FindURLByID(ctx, "one")
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+	_, err = repo.FindURLByID(ctx, "two")
+	assert.NoError(t, err)
+
+	_, err = os.Stat(path + ".journal.compacting")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestInMemoryURLRepository_JournalCompaction(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	path := filepath.Join(t.TempDir(), "storage.json")
+	journalCfg := repository.JournalConfig{SyncPolicy: repository.SyncNever, CompactThreshold: 2}
+
+	repo, err := repository.NewInMemoryURLRepository(path, journalCfg, *logger)
+	require.NoError(t, err)
+
+	err = repo.CreateURL(ctx, []model.URLItem{
+		*model.NewURLItem("https://one.com", "one", "user", false),
+		*model.NewURLItem("https://two.com", "two", "user", false),
+	})
+	require.NoError(t, err)
+
+	journal, err := os.ReadFile(path + ".journal")
+	require.NoError(t, err)
+	assert.Empty(t, journal)
+
+	// Снимок записывается в фоне; файл сворачиваемого журнала удаляется после его записи.
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path + ".journal.compacting")
+		return errors.Is(err, os.ErrNotExist)
+	}, time.Second, 10*time.Millisecond)
+
+	var snapshot []model.URLItem
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &snapshot))
+	assert.Len(t, snapshot, 2)
+
+	err = repo.CreateURL(ctx, []model.URLItem{*model.NewURLItem("https://three.com", "three", "user", false)})
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	restored, err := repository.NewInMemoryURLRepository(path, journalCfg, *logger)
+	require.NoError(t, err)
+	defer restored.Close()
+
+	count, err := restored.CountURLs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestInMemoryURLRepository_UpdateURL(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	path := filepath.Join(t.TempDir(), "storage.json")
+	journalCfg := repository.JournalConfig{SyncPolicy: repository.SyncAlways, CompactThreshold: 0}
+
+	repo, err := repository.NewInMemoryURLRepository(path, journalCfg, *logger)
+	require.NoError(t, err)
+
+	editedAt := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	expired := model.NewURLItem("https://expired.com", "expired", "owner", false)
+	expired.ExpiresAt = &editedAt
+
+	err = repo.CreateURL(ctx, []model.URLItem{
+		*model.NewURLItem("https://old.com", "own", "owner", false),
+		*model.NewURLItem("https://taken.com", "other", "stranger", false),
+		*expired,
+	})
+	require.NoError(t, err)
+
+	edit, err := repo.UpdateURL(ctx, model.URLEdit{ShortID: "own", UserID: "owner", NewURL: "https://new.com", EditedAt: editedAt})
+	require.NoError(t, err)
+	assert.Equal(t, "https://old.com", edit.OldURL)
+
+	_, err = repo.UpdateURL(ctx, model.URLEdit{ShortID: "own", UserID: "owner", NewURL: "https://taken.com"})
+	assert.ErrorIs(t, err, repository.ErrRepoURLAlreadyExists)
+	_, err = repo.UpdateURL(ctx, model.URLEdit{ShortID: "other", UserID: "owner", NewURL: "https://mine.com"})
+	assert.ErrorIs(t, err, repository.ErrRepoNotOwned)
+	_, err = repo.UpdateURL(ctx, model.URLEdit{ShortID: "missing", UserID: "owner", NewURL: "https://mine.com"})
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+	_, err = repo.UpdateURL(ctx, model.URLEdit{ShortID: "expired", UserID: "owner", NewURL: "https://mine.com", EditedAt: editedAt})
+	assert.ErrorIs(t, err, repository.ErrRepoURLExpired)
+
+	_, err = repo.FindURLByURL(ctx, "", "https://old.com")
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+	require.NoError(t, repo.Close())
+
+	// История изменений переживает перезапуск и сворачивание журнала.
+	for range 2 {
+		restored, err := repository.NewInMemoryURLRepository(path, journalCfg, *logger)
+		require.NoError(t, err)
+
+		item, err := restored.FindURLByID(ctx, "own")
+		require.NoError(t, err)
+		assert.Equal(t, "https://new.com", item.URL)
+
+		edits, err := restored.FindURLEdits(ctx, "own")
+		require.NoError(t, err)
+		require.Len(t, edits, 1)
+		assert.Equal(t, "https://old.com", edits[0].OldURL)
+		assert.True(t, editedAt.Equal(edits[0].EditedAt))
+		require.NoError(t, restored.Close())
+	}
+}
+
+func TestInMemoryURLRepository_FindURLPageByUser(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	repo, err := repository.NewInMemoryURLRepository("", repository.DefaultJournalConfig(), *logger)
+	require.NoError(t, err)
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var items []model.URLItem
+	for idx, id := range []string{"a", "b", "c", "d", "e"} {
+		item := *model.NewURLItem("https://example.com/"+id, id, "user", false)
+		item.CreatedAt = base.Add(time.Duration(idx) * time.Hour)
+		items = append(items, item)
+	}
+	items[4].URL = "https://OTHER.org/e"
+	other := *model.NewURLItem("https://foreign.com", "x", "stranger", false)
+	require.NoError(t, repo.CreateURL(ctx, append(items, other)))
+	_, err = repo.DeleteURL(ctx, "user", []string{"c"})
+	require.NoError(t, err)
+
+	shortIDs := func(page *model.UserURLPage) []string {
+		var ids []string
+		for _, item := range page.Items {
+			ids = append(ids, item.ShortID)
+		}
+		return ids
+	}
+
+	t.Run("Newest First With Cursor", func(t *testing.T) {
+		query := model.UserURLQuery{Limit: 2, Order: model.SortDesc}
+		page, err := repo.FindURLPageByUser(ctx, "user", query)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"e", "d"}, shortIDs(page))
+		require.NotNil(t, page.Next)
+
+		cursor, err := model.ParseURLCursor(page.Next.Encode())
+		require.NoError(t, err)
+		query.Cursor = cursor
+		page, err = repo.FindURLPageByUser(ctx, "user", query)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "a"}, shortIDs(page))
+		assert.Nil(t, page.Next)
+	})
+
+	t.Run("Oldest First Including Deleted", func(t *testing.T) {
+		page, err := repo.FindURLPageByUser(ctx, "user", model.UserURLQuery{Limit: 10, Order: model.SortAsc, IncludeDeleted: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, shortIDs(page))
+		assert.True(t, page.Items[2].IsDeleted)
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		page, err := repo.FindURLPageByUser(ctx, "user", model.UserURLQuery{Limit: 10, Order: model.SortDesc, Search: "other"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"e"}, shortIDs(page))
+	})
+}
+
+func TestInMemoryURLRepository_Metadata(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	path := filepath.Join(t.TempDir(), "storage.json")
+	journalCfg := repository.JournalConfig{SyncPolicy: repository.SyncAlways, CompactThreshold: 0}
+
+	// Снимок в формате до появления полей updated_at, last_accessed_at и tags.
+	legacy := `[{"short_id":"old","original_url":"https://old.com","user_id":"user","id_deleted":false,"created_at":"2024-06-01T10:00:00Z"}]`
+	require.NoError(t, os.WriteFile(path, []byte(legacy), 0666))
+
+	repo, err := repository.NewInMemoryURLRepository(path, journalCfg, *logger)
+	require.NoError(t, err)
+
+	item, err := repo.FindURLByID(ctx, "old")
+	require.NoError(t, err)
+	assert.Equal(t, item.CreatedAt, item.UpdatedAt)
+	assert.Nil(t, item.LastAccessedAt)
+	assert.Empty(t, item.Tags)
+
+	tagged := *model.NewURLItem("https://new.com", "new", "user", false)
+	tagged.Tags = []string{"docs", "team"}
+	require.NoError(t, repo.CreateURL(ctx, []model.URLItem{tagged}))
+
+	journalInfo, err := os.Stat(path + ".journal")
+	require.NoError(t, err)
+
+	accessedAt := time.Date(2025, 2, 1, 8, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.MarkAccessed(ctx, map[string]time.Time{"old": accessedAt, "missing": accessedAt}))
+	require.NoError(t, repo.MarkAccessed(ctx, map[string]time.Time{"old": accessedAt.Add(-time.Hour)}))
+
+	// Переходы не записываются в журнал и сохраняются только снимком при закрытии.
+	afterAccess, err := os.Stat(path + ".journal")
+	require.NoError(t, err)
+	assert.Equal(t, journalInfo.Size(), afterAccess.Size())
+	require.NoError(t, repo.Close())
+
+	restored, err := repository.NewInMemoryURLRepository(path, journalCfg, *logger)
+	require.NoError(t, err)
+	defer restored.Close()
+
+	item, err = restored.FindURLByID(ctx, "old")
+	require.NoError(t, err)
+	require.NotNil(t, item.LastAccessedAt)
+	assert.True(t, accessedAt.Equal(*item.LastAccessedAt))
+
+	item, err = restored.FindURLByID(ctx, "new")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs", "team"}, item.Tags)
+}
+
+func TestInMemoryURLRepository_Tags(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	path := filepath.Join(t.TempDir(), "storage.json")
+	journalCfg := repository.JournalConfig{SyncPolicy: repository.SyncAlways, CompactThreshold: 0}
+	repo, err := repository.NewInMemoryURLRepository(path, journalCfg, *logger)
+	require.NoError(t, err)
+
+	docs := *model.NewURLItem("https://docs.com", "docs", "user", false)
+	docs.Tags = []string{"docs", "team"}
+	blog := *model.NewURLItem("https://blog.com", "blog", "user", false)
+	blog.Tags = []string{"team"}
+	gone := *model.NewURLItem("https://gone.com", "gone", "user", false)
+	gone.Tags = []string{"team"}
+	foreign := *model.NewURLItem("https://foreign.com", "foreign", "stranger", false)
+	foreign.Tags = []string{"team"}
+	require.NoError(t, repo.CreateURL(ctx, []model.URLItem{docs, blog, gone, foreign}))
+	_, err = repo.DeleteURL(ctx, "user", []string{"gone"})
+	require.NoError(t, err)
+
+	updatedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.ErrorIs(t, repo.SetTags(ctx, "user", "foreign", []string{"x"}, updatedAt), repository.ErrRepoNotOwned)
+	assert.ErrorIs(t, repo.SetTags(ctx, "user", "gone", []string{"x"}, updatedAt), repository.ErrRepoNotFound)
+	assert.ErrorIs(t, repo.SetTags(ctx, "user", "missing", []string{"x"}, updatedAt), repository.ErrRepoNotFound)
+	require.NoError(t, repo.SetTags(ctx, "user", "blog", []string{"news", "team"}, updatedAt))
+	require.NoError(t, repo.Close())
+
+	restored, err := repository.NewInMemoryURLRepository(path, journalCfg, *logger)
+	require.NoError(t, err)
+	defer restored.Close()
+
+	item, err := restored.FindURLByID(ctx, "blog")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"news", "team"}, item.Tags)
+	assert.True(t, updatedAt.Equal(item.UpdatedAt))
+
+	counts, err := restored.CountTagsByUser(ctx, "user")
+	require.NoError(t, err)
+	assert.Equal(t, []model.TagCount{{Tag: "team", Count: 2}, {Tag: "docs", Count: 1}, {Tag: "news", Count: 1}}, counts)
+
+	page, err := restored.FindURLPageByUser(ctx, "user", model.UserURLQuery{Order: model.SortAsc, Tag: "team", IncludeDeleted: true})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 3)
+
+	page, err = restored.FindURLPageByUser(ctx, "user", model.UserURLQuery{Order: model.SortAsc, Tag: "news"})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "blog", page.Items[0].ShortID)
+
+	require.NoError(t, restored.SetTags(ctx, "user", "blog", nil, updatedAt))
+	page, err = restored.FindURLPageByUser(ctx, "user", model.UserURLQuery{Order: model.SortAsc, Tag: "news"})
+	require.NoError(t, err)
+	assert.Empty(t, page.Items)
+}
+
+func TestInMemoryURLRepository_Domains(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	path := filepath.Join(t.TempDir(), "storage.json")
+	journalCfg := repository.JournalConfig{SyncPolicy: repository.SyncAlways, CompactThreshold: 0}
+	repo, err := repository.NewInMemoryURLRepository(path, journalCfg, *logger)
+	require.NoError(t, err)
+
+	branded := *model.NewURLItem("https://docs.com", model.ShortKey("brand.link", "docs"), "user", false)
+	branded.Domain = "brand.link"
+	// Один и тот же код и оригинальный URL-адрес допустимы в разных доменах.
+	require.NoError(t, repo.CreateURL(ctx, []model.URLItem{
+		*model.NewURLItem("https://docs.com", "docs", "user", false),
+		branded,
+	}))
+
+	duplicate := *model.NewURLItem("https://docs.com", model.ShortKey("brand.link", "other"), "user", false)
+	duplicate.Domain = "brand.link"
+	assert.ErrorIs(t, repo.CreateURL(ctx, []model.URLItem{duplicate}), repository.ErrRepoURLAlreadyExists)
+	require.NoError(t, repo.Close())
+
+	restored, err := repository.NewInMemoryURLRepository(path, journalCfg, *logger)
+	require.NoError(t, err)
+	defer restored.Close()
+
+	item, err := restored.FindURLByURL(ctx, "brand.link", "https://docs.com")
+	require.NoError(t, err)
+	assert.Equal(t, "docs@brand.link", item.ShortID)
+	assert.Equal(t, "docs", item.Code())
+
+	item, err = restored.FindURLByURL(ctx, "", "https://docs.com")
+	require.NoError(t, err)
+	assert.Equal(t, "docs", item.ShortID)
+}
@@ -0,0 +1,10 @@
+// Package repository содержит интерфейс счетчика для последовательных сокращенных идентификаторов.
+package repository
+
+import "context"
+
+// SequenceRepository представляет интерфейс монотонного счетчика, из значений которого строятся сокращенные идентификаторы.
+type SequenceRepository interface {
+	// NextSequence возвращает следующее значение счетчика; значения не повторяются, но между ними допустимы пропуски.
+	NextSequence(ctx context.Context) (uint64, error)
+}
@@ -0,0 +1,13 @@
+// Package repository содержит интерфейс хранилища длины генерируемых сокращенных идентификаторов.
+package repository
+
+import "context"
+
+// ShortCodeLengthRepository представляет интерфейс хранилища эффективной длины генерируемых сокращенных идентификаторов,
+// чтобы увеличенная из-за коллизий длина сохранялась между запусками.
+type ShortCodeLengthRepository interface {
+	// LoadShortCodeLength возвращает сохраненную длину; 0 означает, что длина еще не сохранялась.
+	LoadShortCodeLength(ctx context.Context) (int, error)
+	// SaveShortCodeLength сохраняет длину; меньшая длина не заменяет уже сохраненную большую.
+	SaveShortCodeLength(ctx context.Context, length int) error
+}
@@ -0,0 +1,346 @@
+// Package repository содержит реализацию журнала упреждающей записи для файлового хранилища URL-адресов.
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"go.uber.org/zap"
+)
+
+// SyncPolicy представляет политику сброса журнала на диск.
+type SyncPolicy string
+
+// SyncAlways означает сброс журнала на диск после каждой записи.
+const SyncAlways SyncPolicy = "always"
+
+// SyncInterval означает периодический сброс журнала на диск в фоновом потоке.
+const SyncInterval SyncPolicy = "interval"
+
+// SyncNever означает, что сброс журнала на диск выполняется операционной системой.
+const SyncNever SyncPolicy = "never"
+
+// ParseSyncPolicy возвращает политику сброса журнала по ее названию.
+func ParseSyncPolicy(policy string) (SyncPolicy, error) {
+	switch SyncPolicy(policy) {
+	case SyncAlways, SyncInterval, SyncNever:
+		return SyncPolicy(policy), nil
+	default:
+		return "", fmt.Errorf("unknown sync policy %q", policy)
+	}
+}
+
+// JournalConfig представляет настройки журнала файлового хранилища.
+type JournalConfig struct {
+	// SyncPolicy представляет политику сброса журнала на диск.
+	SyncPolicy SyncPolicy
+	// SyncInterval представляет интервал сброса журнала на диск для политики SyncInterval.
+	SyncInterval time.Duration
+	// CompactThreshold представляет количество записей журнала, после которого журнал сворачивается в снимок.
+	CompactThreshold int
+}
+
+// DefaultJournalConfig возвращает настройки журнала по умолчанию.
+func DefaultJournalConfig() JournalConfig {
+	return JournalConfig{
+		SyncPolicy:       SyncInterval,
+		SyncInterval:     1 * time.Second,
+		CompactThreshold: 10000,
+	}
+}
+
+// journalSuffix представляет суффикс файла журнала относительно файла снимка.
+const journalSuffix = ".journal"
+
+// compactingSuffix представляет суффикс файла журнала, переименованного на время сворачивания в снимок.
+const compactingSuffix = ".journal.compacting"
+
+// editsSuffix представляет суффикс файла снимка истории изменений относительно файла снимка.
+const editsSuffix = ".edits"
+
+// journalOp представляет тип записи журнала.
+type journalOp string
+
+// Типы записей журнала.
+const (
+	// journalOpCreate означает создание URL-адреса.
+	journalOpCreate journalOp = "create"
+	// journalOpDelete означает пометку URL-адреса удаленным.
+	journalOpDelete journalOp = "delete"
+	// journalOpPurge означает физическое удаление URL-адреса.
+	journalOpPurge journalOp = "purge"
+	// journalOpUpdate означает изменение оригинального URL-адреса.
+	journalOpUpdate journalOp = "update"
+	// journalOpTags означает замену меток URL-адреса.
+	journalOpTags journalOp = "tags"
+)
+
+// journalEvent представляет запись журнала.
+type journalEvent struct {
+	// Op представляет тип записи.
+	Op journalOp `json:"op"`
+	// Item представляет созданный URL-адрес для записи journalOpCreate и измененный URL-адрес для записей journalOpUpdate и journalOpTags.
+	Item *model.URLItem `json:"item,omitempty"`
+	// ShortID представляет сокращенный идентификатор для записей journalOpDelete и journalOpPurge.
+	ShortID string `json:"short_id,omitempty"`
+	// Edit представляет запись истории изменений для записи journalOpUpdate.
+	Edit *model.URLEdit `json:"edit,omitempty"`
+}
+
+// urlJournal представляет журнал упреждающей записи в формате JSON Lines.
+type urlJournal struct {
+	// path представляет путь к файлу журнала.
+	path string
+	// file представляет открытый на дозапись файл журнала.
+	file *os.File
+	// policy представляет политику сброса журнала на диск.
+	policy SyncPolicy
+	// entries представляет количество записей в журнале.
+	entries int
+	// dirty представляет флаг наличия записей, не сброшенных на диск.
+	dirty bool
+}
+
+// openURLJournal открывает журнал и возвращает записи, сохраненные в нем.
+// Если последняя запись прервана (например, при аварийном завершении во время записи), журнал усекается до последней корректной записи.
+// Поврежденная запись в середине журнала означает потерю данных: журнал не изменяется, и возвращается ErrRepoJournalCorrupted.
+func openURLJournal(path string, policy SyncPolicy, logger zap.SugaredLogger) (*urlJournal, []journalEvent, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events, validSize, err := readJournal[journalEvent](file)
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("journal %s: %w", path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	if info.Size() != validSize {
+		logger.Warnf("journal %s has corrupted tail, truncating from %d to %d bytes", path, info.Size(), validSize)
+		if err := file.Truncate(validSize); err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+	}
+
+	if _, err := file.Seek(validSize, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return &urlJournal{path: path, file: file, policy: policy, entries: len(events)}, events, nil
+}
+
+// readJournalFile читает записи журнала из файла, не изменяя его; отсутствующий файл означает пустой журнал.
+func readJournalFile(path string) ([]journalEvent, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	events, _, err := readJournal[journalEvent](file)
+	if err != nil {
+		return nil, fmt.Errorf("journal %s: %w", path, err)
+	}
+	return events, nil
+}
+
+// readJournal читает записи журнала и возвращает размер корректной части файла.
+// Только последняя строка может быть прерванной записью: строка без перевода строки или с некорректным JSON в конце файла
+// не входит в корректную часть. Некорректная строка, за которой следуют другие записи, означает повреждение журнала.
+func readJournal[T any](r io.Reader) ([]T, int64, error) {
+	var events []T
+	var validSize int64
+	corruptedLine := 0
+
+	reader := bufio.NewReader(r)
+	for lineNum := 1; ; lineNum++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, 0, err
+		}
+		if corruptedLine > 0 && len(line) > 0 {
+			return nil, 0, fmt.Errorf("%w: invalid record at line %d", ErrRepoJournalCorrupted, corruptedLine)
+		}
+		if errors.Is(err, io.EOF) {
+			// Строка без перевода строки означает прерванную запись.
+			return events, validSize, nil
+		}
+
+		var event T
+		if err := json.Unmarshal(line, &event); err != nil {
+			corruptedLine = lineNum
+			continue
+		}
+
+		events = append(events, event)
+		validSize += int64(len(line))
+	}
+}
+
+// Append дописывает записи в журнал одной операцией записи.
+func (j *urlJournal) Append(events ...journalEvent) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return fmt.Errorf("failed to encode journal event: %w", err)
+		}
+	}
+
+	if _, err := j.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	j.entries += len(events)
+	j.dirty = true
+
+	if j.policy == SyncAlways {
+		return j.Sync()
+	}
+	return nil
+}
+
+// Sync сбрасывает записи журнала на диск.
+func (j *urlJournal) Sync() error {
+	if !j.dirty {
+		return nil
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	j.dirty = false
+	return nil
+}
+
+// Rotate переименовывает файл журнала в rotatedPath и продолжает запись в новый пустой файл журнала.
+func (j *urlJournal) Rotate(rotatedPath string) error {
+	if err := j.Sync(); err != nil {
+		return err
+	}
+	if err := os.Rename(j.path, rotatedPath); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(j.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return errors.Join(err, os.Rename(rotatedPath, j.path))
+	}
+
+	rotated := j.file
+	j.file = file
+	j.entries = 0
+	j.dirty = false
+	return rotated.Close()
+}
+
+// Reset очищает журнал после сохранения снимка.
+func (j *urlJournal) Reset() error {
+	if err := j.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := j.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	j.entries = 0
+	j.dirty = false
+	return j.file.Sync()
+}
+
+// Close сбрасывает журнал на диск и закрывает файл.
+func (j *urlJournal) Close() error {
+	return errors.Join(j.Sync(), j.file.Close())
+}
+
+// removeJournalFile удаляет файл журнала; отсутствующий файл не является ошибкой.
+func removeJournalFile(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// readSnapshot читает снимок хранилища в формате JSON-массива.
+// Отсутствующий или пустой файл означает пустое хранилище.
+func readSnapshot[T any](path string) ([]T, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var items []T
+	if err := json.NewDecoder(bufio.NewReader(file)).Decode(&items); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to decode snapshot %s: %w", path, err)
+	}
+	return items, nil
+}
+
+// writeFileAtomic атомарно заменяет содержимое файла: данные пишутся во временный файл, который затем переименовывается.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// writeSnapshotTemp записывает снимок хранилища во временный файл рядом с path и возвращает его имя.
+// Вызывающая сторона переименовывает временный файл в path, чтобы атомарно заменить снимок, и удаляет его при ошибке.
+func writeSnapshotTemp[T any](path string, items []T) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+
+	writer := bufio.NewWriter(tmp)
+	err = json.NewEncoder(writer).Encode(items)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
@@ -4,6 +4,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/oegegr/shortener/internal/model"
 )
@@ -17,20 +18,58 @@ var ErrRepoURLAlreadyExists = errors.New("url already exists")
 // ErrRepoShortIDAlreadyExists представляет ошибку, которая возникает при попытке создать уже существующий сокращенный идентификатор.
 var ErrRepoShortIDAlreadyExists = errors.New("short id already exists")
 
+// ErrRepoAliasAlreadyExists представляет ошибку, которая возникает при попытке создать URL-адрес с уже занятым пользовательским идентификатором.
+var ErrRepoAliasAlreadyExists = errors.New("alias already exists")
+
+// ErrRepoNotOwned представляет ошибку, которая возникает при попытке изменить URL-адрес другого пользователя.
+var ErrRepoNotOwned = errors.New("url is owned by another user")
+
+// ErrRepoURLExpired представляет ошибку, которая возникает при попытке изменить URL-адрес с истекшим сроком действия.
+var ErrRepoURLExpired = errors.New("url has expired")
+
+// ErrRepoTooManyAPIKeys представляет ошибку, которая возникает, когда у пользователя уже достигнут предел действующих API-ключей.
+var ErrRepoTooManyAPIKeys = errors.New("too many api keys")
+
+// ErrRepoJournalCorrupted представляет ошибку, которая возникает, когда в середине журнала файлового хранилища найдена поврежденная запись.
+var ErrRepoJournalCorrupted = errors.New("journal is corrupted")
+
 // URLRepository представляет интерфейс для работы с репозиторием URL-адресов.
 type URLRepository interface {
 	// Ping проверяет подключение к репозиторию.
 	Ping(ctx context.Context) error
 	// CreateURL создает новые URL-адреса в репозитории.
 	CreateURL(ctx context.Context, urlItem []model.URLItem) error
-	// DeleteURL удаляет URL-адреса из репозитория.
-	DeleteURL(ctx context.Context, ids []string) error
+	// DeleteURL помечает удаленными URL-адреса, принадлежащие пользователю, и возвращает результат по каждому идентификатору.
+	DeleteURL(ctx context.Context, userID string, ids []string) ([]model.DeleteResult, error)
+	// UpdateURL изменяет оригинальный URL-адрес, принадлежащий пользователю, и сохраняет запись в истории изменений.
+	// Эта функция принимает запись изменения без старого URL-адреса и возвращает заполненную запись.
+	UpdateURL(ctx context.Context, edit model.URLEdit) (*model.URLEdit, error)
+	// SetTags заменяет метки URL-адреса, принадлежащего пользователю.
+	SetTags(ctx context.Context, userID string, shortID string, tags []string, updatedAt time.Time) error
+	// CountTagsByUser возвращает метки пользователя с количеством не удаленных URL-адресов.
+	CountTagsByUser(ctx context.Context, userID string) ([]model.TagCount, error)
+	// MarkAccessed сохраняет моменты последних переходов по URL-адресам.
+	// Эта функция принимает карту моментов переходов по сокращенному идентификатору.
+	MarkAccessed(ctx context.Context, accessed map[string]time.Time) error
+	// FindURLEdits возвращает историю изменений URL-адреса в хронологическом порядке.
+	FindURLEdits(ctx context.Context, shortID string) ([]model.URLEdit, error)
+	// FindExpiredURL возвращает сокращенные идентификаторы URL-адресов, срок действия которых истек к заданному моменту.
+	FindExpiredURL(ctx context.Context, before time.Time) ([]string, error)
+	// PurgeExpiredURL физически удаляет URL-адреса из заданного списка, срок действия которых истек к заданному моменту,
+	// вместе с историей изменений и метками, и возвращает их количество.
+	PurgeExpiredURL(ctx context.Context, ids []string, before time.Time) (int64, error)
 	// FindURLByID находит URL-адрес в репозитории по идентификатору URL-адреса.
 	FindURLByID(ctx context.Context, id string) (*model.URLItem, error)
-	// FindURLByURL находит URL-адрес в репозитории по URL-адресу.
-	FindURLByURL(ctx context.Context, id string) (*model.URLItem, error)
+	// FindURLByURL находит URL-адрес в репозитории по домену и оригинальному URL-адресу.
+	FindURLByURL(ctx context.Context, domain string, url string) (*model.URLItem, error)
 	// FindURLByUser находит URL-адреса в репозитории по идентификатору пользователя.
 	FindURLByUser(ctx context.Context, userID string) ([]model.URLItem, error)
+	// FindURLPageByUser возвращает страницу URL-адресов пользователя, отсортированных по времени создания.
+	FindURLPageByUser(ctx context.Context, userID string, query model.UserURLQuery) (*model.UserURLPage, error)
+	// CountURLs возвращает количество не удаленных URL-адресов.
+	CountURLs(ctx context.Context) (int64, error)
+	// CountUsers возвращает количество пользователей, у которых есть не удаленные URL-адреса.
+	CountUsers(ctx context.Context) (int64, error)
 	// Exists проверяет, существует ли URL-адрес в репозитории.
 	Exists(ctx context.Context, id string) bool
 }
@@ -3,6 +3,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/oegegr/shortener/internal/model"
 	"github.com/stretchr/testify/mock"
@@ -26,20 +27,63 @@ func (m *MockURLRepository) CreateURL(ctx context.Context, urls []model.URLItem)
 }
 
 // DeleteURL удаляет URL-адреса из репозитория (мок-реализация).
-func (m *MockURLRepository) DeleteURL(ctx context.Context, ids []string) error {
-	args := m.Called(ctx, ids)
+func (m *MockURLRepository) DeleteURL(ctx context.Context, userID string, ids []string) ([]model.DeleteResult, error) {
+	args := m.Called(ctx, userID, ids)
+	return args.Get(0).([]model.DeleteResult), args.Error(1)
+}
+
+// UpdateURL изменяет оригинальный URL-адрес (мок-реализация).
+func (m *MockURLRepository) UpdateURL(ctx context.Context, edit model.URLEdit) (*model.URLEdit, error) {
+	args := m.Called(ctx, edit)
+	return args.Get(0).(*model.URLEdit), args.Error(1)
+}
+
+// SetTags заменяет метки URL-адреса (мок-реализация).
+func (m *MockURLRepository) SetTags(ctx context.Context, userID string, shortID string, tags []string, updatedAt time.Time) error {
+	args := m.Called(ctx, userID, shortID, tags, updatedAt)
+	return args.Error(0)
+}
+
+// CountTagsByUser возвращает метки пользователя с количеством URL-адресов (мок-реализация).
+func (m *MockURLRepository) CountTagsByUser(ctx context.Context, userID string) ([]model.TagCount, error) {
+	args := m.Called(ctx, userID)
+	counts, _ := args.Get(0).([]model.TagCount)
+	return counts, args.Error(1)
+}
+
+// MarkAccessed сохраняет моменты последних переходов по URL-адресам (мок-реализация).
+func (m *MockURLRepository) MarkAccessed(ctx context.Context, accessed map[string]time.Time) error {
+	args := m.Called(ctx, accessed)
 	return args.Error(0)
 }
 
+// FindURLEdits возвращает историю изменений URL-адреса (мок-реализация).
+func (m *MockURLRepository) FindURLEdits(ctx context.Context, shortID string) ([]model.URLEdit, error) {
+	args := m.Called(ctx, shortID)
+	return args.Get(0).([]model.URLEdit), args.Error(1)
+}
+
+// FindExpiredURL возвращает сокращенные идентификаторы URL-адресов с истекшим сроком действия (мок-реализация).
+func (m *MockURLRepository) FindExpiredURL(ctx context.Context, before time.Time) ([]string, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// PurgeExpiredURL физически удаляет URL-адреса с истекшим сроком действия (мок-реализация).
+func (m *MockURLRepository) PurgeExpiredURL(ctx context.Context, ids []string, before time.Time) (int64, error) {
+	args := m.Called(ctx, ids, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 // FindURLByID находит URL-адрес в репозитории по идентификатору URL-адреса (мок-реализация).
 func (m *MockURLRepository) FindURLByID(ctx context.Context, id string) (*model.URLItem, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(*model.URLItem), args.Error(1)
 }
 
-// FindURLByURL находит URL-адрес в репозитории по URL-адресу (мок-реализация).
-func (m *MockURLRepository) FindURLByURL(ctx context.Context, url string) (*model.URLItem, error) {
-	args := m.Called(ctx, url)
+// FindURLByURL находит URL-адрес в репозитории по домену и оригинальному URL-адресу (мок-реализация).
+func (m *MockURLRepository) FindURLByURL(ctx context.Context, domain string, url string) (*model.URLItem, error) {
+	args := m.Called(ctx, domain, url)
 	return args.Get(0).(*model.URLItem), args.Error(1)
 }
 
@@ -49,6 +93,25 @@ func (m *MockURLRepository) FindURLByUser(ctx context.Context, id string) ([]mod
 	return args.Get(0).([]model.URLItem), args.Error(1)
 }
 
+// FindURLPageByUser возвращает страницу URL-адресов пользователя (мок-реализация).
+func (m *MockURLRepository) FindURLPageByUser(ctx context.Context, userID string, query model.UserURLQuery) (*model.UserURLPage, error) {
+	args := m.Called(ctx, userID, query)
+	page, _ := args.Get(0).(*model.UserURLPage)
+	return page, args.Error(1)
+}
+
+// CountURLs возвращает количество не удаленных URL-адресов (мок-реализация).
+func (m *MockURLRepository) CountURLs(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// CountUsers возвращает количество пользователей с не удаленными URL-адресами (мок-реализация).
+func (m *MockURLRepository) CountUsers(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 // Exists проверяет, существует ли URL-адрес в репозитории (мок-реализация).
 func (m *MockURLRepository) Exists(ctx context.Context, id string) bool {
 	args := m.Called(ctx, id)
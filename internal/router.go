@@ -2,44 +2,113 @@
 package internal
 
 import (
+	"net"
+
 	"github.com/go-chi/chi/v5"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/oegegr/shortener/internal/handler"
 	"github.com/oegegr/shortener/internal/middleware"
+	"github.com/oegegr/shortener/internal/model"
 	"github.com/oegegr/shortener/internal/repository"
 	"github.com/oegegr/shortener/internal/service"
+	pkghttp "github.com/oegegr/shortener/pkg/http"
+	"github.com/oegegr/shortener/pkg/ratelimit"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 )
 
 // NewShortenerRouter возвращает новый экземпляр роутера для приложения.
-// Эта функция принимает логгер, сервис сокращения URL-адресов, парсер JWT-токенов, репозиторий URL-адресов и менеджер аудита логов.
+// Эта функция принимает логгер, сервис сокращения URL-адресов, парсер JWT-токенов, сервис API-ключей, репозиторий URL-адресов, менеджер аудита логов,
+// запись переходов, сервис статистики переходов, сервис внутренней статистики, провайдер задач удаления,
+// провайдер QR-кодов, хранилище ограничителей частоты запросов, ограничения частоты запросов, доверенную подсеть,
+// доверенные прокси-серверы, реестр доменов сокращенных URL-адресов и реестр метрик.
 func NewShortenerRouter(
 	logger zap.SugaredLogger,
 	service service.URLShortener,
 	jwtParser service.JWTParser,
+	apiKeys service.APIKeyManager,
 	repo repository.URLRepository,
 	logAudit service.LogAuditManager,
+	clickTracker service.ClickTracker,
+	statsProvider service.URLStatsProvider,
+	internalStatsProvider service.InternalStatsProvider,
+	deletionJobProvider service.DeletionJobProvider,
+	qrProvider service.QRCodeProvider,
+	rateLimitStore ratelimit.Store,
+	rateLimits middleware.RateLimits,
+	trustedSubnet *net.IPNet,
+	trustedProxies pkghttp.TrustedProxies,
+	domains *service.DomainRegistry,
+	metricsGatherer prometheus.Gatherer,
 ) *chi.Mux {
-	shortenerHandler := handler.NewShortenerHandler(service, &middleware.AuthContextUserIDPovider{}, logAudit)
+	userIDProvider := &middleware.AuthContextUserIDPovider{}
+	shortenerHandler := handler.NewShortenerHandler(service, userIDProvider, logAudit, clickTracker)
+	statsHandler := handler.NewStatsHandler(statsProvider, userIDProvider)
+	internalStatsHandler := handler.NewInternalStatsHandler(internalStatsProvider)
+	deletionHandler := handler.NewDeletionHandler(deletionJobProvider, userIDProvider)
+	qrHandler := handler.NewQRHandler(qrProvider)
 	pingHandler := handler.NewPingHandler(repo)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeys, userIDProvider)
+	jwksHandler := handler.NewJWKSHandler(&jwtParser)
+
+	shortenLimit := middleware.RateLimitMiddleware(logger, rateLimitStore, "shorten", rateLimits.Shorten, middleware.SingleRequestCost)
+	batchLimit := middleware.RateLimitMiddleware(logger, rateLimitStore, "batch", rateLimits.Batch, middleware.BatchRequestCost)
+	batchSize := middleware.BatchSizeMiddleware(rateLimits.BatchMaxSize)
+	redirectLimit := middleware.RateLimitMiddleware(logger, rateLimitStore, "redirect", rateLimits.Redirect, middleware.SingleRequestCost)
+
+	shortenScope := middleware.RequireAPIKeyScope(model.APIKeyScopeShorten)
+	readScope := middleware.RequireAPIKeyScope(model.APIKeyScopeRead)
+	deleteScope := middleware.RequireAPIKeyScope(model.APIKeyScopeDelete)
 
 	router := chi.NewRouter()
-	router.Use(middleware.ZapLogger(logger))
 	typesToGzip := []string{"application/json", "text/html"}
 	router.Use(
+		middleware.ClientIPMiddleware(trustedProxies),
+		middleware.Metrics,
 		middleware.ZapLogger(logger),
 		middleware.GzipMiddleware(typesToGzip),
-		middleware.AuthMiddleware(logger, jwtParser),
 	)
-	router.Get("/ping", pingHandler.Ping)
-	router.Post("/api/shorten/batch", shortenerHandler.APIShortenBatchURL)
-	router.Post("/api/shorten", shortenerHandler.APIShortenURL)
-	router.Get("/api/user/urls", shortenerHandler.APIUserURL)
-	router.Delete("/api/user/urls", shortenerHandler.APIUserBatchDeleteURL)
-	router.Post("/*", shortenerHandler.ShortenURL)
-	router.Get("/{short_url}", shortenerHandler.RedirectToOriginalURL)
+
+	// Внутренние эндпоинты обслуживаются без аутентификации, чтобы сборщики статистики и метрик не получали cookie пользователей.
+	trustedOnly := middleware.TrustedSubnetMiddleware(trustedSubnet)
+	router.With(trustedOnly).Get("/api/internal/stats", internalStatsHandler.APIInternalStats)
+	router.With(trustedOnly).Handle("/metrics", promhttp.HandlerFor(metricsGatherer, promhttp.HandlerOpts{}))
+
+	// Набор открытых ключей кэшируется общими кэшами, поэтому ответ не должен содержать cookie и токен пользователя.
+	router.Get("/.well-known/jwks.json", jwksHandler.JWKS)
+
+	// QR-коды кэшируются общими кэшами и не зависят от пользователя, поэтому также обслуживаются без аутентификации.
+	router.Group(func(router chi.Router) {
+		router.Use(middleware.ShortDomainMiddleware(domains))
+		router.With(redirectLimit).Get("/{short_url}"+model.QRCodePath, qrHandler.QRCode)
+	})
+
+	router.Group(func(router chi.Router) {
+		router.Use(
+			middleware.AuthMiddleware(logger, jwtParser, apiKeys),
+			middleware.ShortDomainMiddleware(domains),
+		)
+		router.Get("/ping", pingHandler.Ping)
+		router.With(shortenScope, batchSize, shortenLimit, batchLimit).Post("/api/shorten/batch", shortenerHandler.APIShortenBatchURL)
+		router.With(shortenScope, shortenLimit).Post("/api/shorten", shortenerHandler.APIShortenURL)
+		router.With(readScope).Get("/api/user/urls", shortenerHandler.APIUserURL)
+		router.With(shortenScope, shortenLimit).Patch("/api/user/urls/{short_id}", shortenerHandler.APIUserUpdateURL)
+		router.With(readScope).Get("/api/user/urls/{short_id}/history", shortenerHandler.APIUserURLHistory)
+		router.With(shortenScope).Put("/api/user/urls/{short_id}/tags", shortenerHandler.APIUserSetTags)
+		router.With(readScope).Get("/api/user/tags", shortenerHandler.APIUserTags)
+		router.With(readScope).Get("/api/user/urls/{short_id}/stats", statsHandler.APIUserURLStats)
+		router.With(deleteScope).Delete("/api/user/urls", shortenerHandler.APIUserBatchDeleteURL)
+		router.With(readScope).Get("/api/user/urls/deletions/{job_id}", deletionHandler.APIUserDeletionJob)
+		router.With(middleware.RejectAPIKey).Post("/api/user/keys", apiKeyHandler.APIUserCreateKey)
+		router.With(middleware.RejectAPIKey).Get("/api/user/keys", apiKeyHandler.APIUserKeys)
+		router.With(middleware.RejectAPIKey).Delete("/api/user/keys/{key_id}", apiKeyHandler.APIUserRevokeKey)
+		router.With(shortenScope, shortenLimit).Post("/*", shortenerHandler.ShortenURL)
+		router.With(redirectLimit).Get("/{short_url}", shortenerHandler.RedirectToOriginalURL)
+		router.With(redirectLimit).Post("/{short_url}"+model.UnlockPath, shortenerHandler.UnlockURL)
+	})
 
 	return router
 }
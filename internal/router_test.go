@@ -0,0 +1,74 @@
+package internal_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oegegr/shortener/internal"
+	"github.com/oegegr/shortener/internal/middleware"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/oegegr/shortener/pkg/ratelimit"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// newTestRouter возвращает роутер приложения с заданным сервисом сокращения URL-адресов.
+func newTestRouter(t *testing.T, urlService *service.MockURLService) *chi.Mux {
+	logger := zaptest.NewLogger(t).Sugar()
+	jwtParser := service.NewJWTParser("secret", time.Hour, *logger)
+	apiKeyRepo, err := repository.NewInMemoryAPIKeyRepository("", *logger)
+	require.NoError(t, err)
+
+	return internal.NewShortenerRouter(
+		*logger,
+		urlService,
+		jwtParser,
+		service.NewAPIKeyService(apiKeyRepo),
+		new(repository.MockURLRepository),
+		new(service.MockLogAuditManager),
+		new(service.MockClickTracker),
+		nil,
+		nil,
+		nil,
+		urlService,
+		ratelimit.NewInMemoryStore(time.Minute),
+		middleware.RateLimits{},
+		nil,
+		nil,
+		service.NewDomainRegistry("http://localhost:8080"),
+		prometheus.NewRegistry(),
+	)
+}
+
+func TestShortenerRouter_JWKSWithoutSession(t *testing.T) {
+	router := newTestRouter(t, new(service.MockURLService))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// Ответ кэшируется общими кэшами и не должен создавать пользователя.
+	assert.Empty(t, w.Header().Get("Set-Cookie"))
+	assert.Empty(t, w.Header().Get("Authorization"))
+}
+
+func TestShortenerRouter_QRCodeWithoutSession(t *testing.T) {
+	urlService := new(service.MockURLService)
+	urlService.On("GetQRContent", mock.Anything, "abc123").Return("http://localhost:8080/abc123", nil)
+	router := newTestRouter(t, urlService)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/abc123/qr", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Cache-Control"), "public")
+	assert.Empty(t, w.Header().Get("Set-Cookie"))
+	assert.Empty(t, w.Header().Get("Authorization"))
+}
@@ -0,0 +1,45 @@
+// Package service содержит проверку пользовательских сокращенных идентификаторов.
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+)
+
+// minAliasLength представляет минимальную длину пользовательского идентификатора.
+const minAliasLength = 3
+
+// maxAliasLength представляет максимальную длину пользовательского идентификатора.
+const maxAliasLength = 64
+
+// aliasPattern представляет допустимые символы пользовательского идентификатора.
+var aliasPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// reservedAliases представляет идентификаторы, совпадающие с маршрутами приложения.
+var reservedAliases = map[string]struct{}{
+	"api":     {},
+	"ping":    {},
+	"metrics": {},
+	"static":  {},
+}
+
+// ValidateAlias проверяет пользовательский сокращенный идентификатор.
+// Эта функция возвращает ошибку app_error.ErrServiceInvalidAlias с описанием нарушенного правила.
+func ValidateAlias(alias string) error {
+	if len(alias) < minAliasLength || len(alias) > maxAliasLength {
+		return fmt.Errorf("%w: length must be between %d and %d", app_error.ErrServiceInvalidAlias, minAliasLength, maxAliasLength)
+	}
+
+	if !aliasPattern.MatchString(alias) {
+		return fmt.Errorf("%w: only latin letters, digits, '-' and '_' are allowed", app_error.ErrServiceInvalidAlias)
+	}
+
+	if _, ok := reservedAliases[strings.ToLower(alias)]; ok {
+		return fmt.Errorf("%w: %q is reserved", app_error.ErrServiceInvalidAlias, alias)
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+// Package service содержит реализацию сервиса API-ключей для машинных клиентов.
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+)
+
+// Ограничения API-ключей.
+const (
+	// apiKeySecretBytes представляет количество случайных байт ключа.
+	apiKeySecretBytes = 32
+	// apiKeyDisplayLength представляет количество символов ключа после префикса, сохраняемых для отображения.
+	apiKeyDisplayLength = 8
+	// maxAPIKeyNameLength представляет максимальную длину названия ключа в символах.
+	maxAPIKeyNameLength = 100
+	// maxAPIKeysPerUser представляет максимальное количество действующих ключей пользователя.
+	maxAPIKeysPerUser = 50
+)
+
+// apiKeyScopes представляет допустимые области доступа API-ключей.
+var apiKeyScopes = []model.APIKeyScope{model.APIKeyScopeShorten, model.APIKeyScopeRead, model.APIKeyScopeDelete}
+
+// APIKeyAuthenticator представляет интерфейс проверки API-ключей.
+type APIKeyAuthenticator interface {
+	// Authenticate возвращает действующий API-ключ по его значению
+	// или app_error.ErrServiceInvalidAPIKey, если ключ неизвестен или отозван.
+	Authenticate(ctx context.Context, rawKey string) (*model.APIKey, error)
+}
+
+// APIKeyManager представляет интерфейс управления API-ключами пользователя.
+type APIKeyManager interface {
+	APIKeyAuthenticator
+	// CreateKey создает API-ключ пользователя и возвращает его вместе со значением ключа, которое больше нигде не сохраняется.
+	CreateKey(ctx context.Context, userID string, name string, scopes []model.APIKeyScope) (*model.APIKey, string, error)
+	// ListKeys возвращает API-ключи пользователя, включая отозванные.
+	ListKeys(ctx context.Context, userID string) ([]model.APIKey, error)
+	// RevokeKey отзывает API-ключ пользователя.
+	RevokeKey(ctx context.Context, userID string, id string) error
+}
+
+// APIKeyService представляет реализацию сервиса API-ключей.
+// Ключи имеют вид "sk_<случайная строка>" и хранятся в виде хеша SHA-256: ключи содержат достаточно энтропии,
+// поэтому медленное хеширование, как для паролей, не требуется и не замедляет каждый запрос.
+type APIKeyService struct {
+	// repo представляет хранилище API-ключей.
+	repo repository.APIKeyRepository
+}
+
+// NewAPIKeyService возвращает новый экземпляр APIKeyService.
+// Эта функция принимает хранилище API-ключей.
+func NewAPIKeyService(repo repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+// CreateKey создает API-ключ пользователя.
+// Эта функция возвращает app_error.ErrServiceInvalidAPIKeyName и app_error.ErrServiceInvalidAPIKeyScope для недопустимых параметров
+// и app_error.ErrServiceTooManyAPIKeys, если у пользователя уже слишком много действующих ключей.
+func (s *APIKeyService) CreateKey(ctx context.Context, userID string, name string, scopes []model.APIKeyScope) (*model.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > maxAPIKeyNameLength {
+		return nil, "", fmt.Errorf("%w: length must not exceed %d", app_error.ErrServiceInvalidAPIKeyName, maxAPIKeyNameLength)
+	}
+
+	normalized, err := normalizeAPIKeyScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	rawKey := model.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	key := model.APIKey{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    rawKey[:len(model.APIKeyPrefix)+apiKeyDisplayLength],
+		KeyHash:   hashAPIKey(rawKey),
+		Scopes:    normalized,
+		CreatedAt: time.Now().UTC(),
+	}
+	err = s.repo.CreateKey(ctx, key, maxAPIKeysPerUser)
+	if errors.Is(err, repository.ErrRepoTooManyAPIKeys) {
+		return nil, "", fmt.Errorf("%w: limit is %d", app_error.ErrServiceTooManyAPIKeys, maxAPIKeysPerUser)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return &key, rawKey, nil
+}
+
+// ListKeys возвращает API-ключи пользователя, включая отозванные.
+func (s *APIKeyService) ListKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	return s.repo.FindKeysByUser(ctx, userID)
+}
+
+// RevokeKey отзывает API-ключ пользователя.
+// Эта функция возвращает repository.ErrRepoNotFound, если ключ не найден, принадлежит другому пользователю или уже отозван.
+func (s *APIKeyService) RevokeKey(ctx context.Context, userID string, id string) error {
+	return s.repo.RevokeKey(ctx, userID, id, time.Now().UTC())
+}
+
+// Authenticate возвращает действующий API-ключ по его значению.
+func (s *APIKeyService) Authenticate(ctx context.Context, rawKey string) (*model.APIKey, error) {
+	if !strings.HasPrefix(rawKey, model.APIKeyPrefix) {
+		return nil, app_error.ErrServiceInvalidAPIKey
+	}
+
+	key, err := s.repo.FindKeyByHash(ctx, hashAPIKey(rawKey))
+	if errors.Is(err, repository.ErrRepoNotFound) {
+		return nil, app_error.ErrServiceInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if key.IsRevoked() {
+		return nil, app_error.ErrServiceInvalidAPIKey
+	}
+	return key, nil
+}
+
+// normalizeAPIKeyScopes проверяет области доступа и возвращает их без повторов в порядке apiKeyScopes.
+func normalizeAPIKeyScopes(scopes []model.APIKeyScope) ([]model.APIKeyScope, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", app_error.ErrServiceInvalidAPIKeyScope)
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(apiKeyScopes, scope) {
+			return nil, fmt.Errorf("%w: %q", app_error.ErrServiceInvalidAPIKeyScope, scope)
+		}
+	}
+
+	var normalized []model.APIKeyScope
+	for _, scope := range apiKeyScopes {
+		if slices.Contains(scopes, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
+
+// hashAPIKey возвращает хеш значения API-ключа.
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,76 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestAPIKeyService(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	repo, err := repository.NewInMemoryAPIKeyRepository("", *logger)
+	require.NoError(t, err)
+	svc := service.NewAPIKeyService(repo)
+
+	key, rawKey, err := svc.CreateKey(ctx, "user", " ci bot ", []model.APIKeyScope{model.APIKeyScopeRead, model.APIKeyScopeShorten, model.APIKeyScopeRead})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(rawKey, model.APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(rawKey, key.Prefix))
+	assert.NotContains(t, key.KeyHash, rawKey)
+	assert.Equal(t, "ci bot", key.Name)
+	assert.Equal(t, []model.APIKeyScope{model.APIKeyScopeShorten, model.APIKeyScopeRead}, key.Scopes)
+
+	authenticated, err := svc.Authenticate(ctx, rawKey)
+	require.NoError(t, err)
+	assert.Equal(t, "user", authenticated.UserID)
+	assert.True(t, authenticated.HasScope(model.APIKeyScopeRead))
+	assert.False(t, authenticated.HasScope(model.APIKeyScopeDelete))
+
+	_, err = svc.Authenticate(ctx, rawKey+"x")
+	assert.ErrorIs(t, err, app_error.ErrServiceInvalidAPIKey)
+	_, err = svc.Authenticate(ctx, "not-a-key")
+	assert.ErrorIs(t, err, app_error.ErrServiceInvalidAPIKey)
+
+	// Чужой ключ отозвать нельзя, а отозванный ключ перестает действовать, но остается в списке.
+	assert.ErrorIs(t, svc.RevokeKey(ctx, "stranger", key.ID), repository.ErrRepoNotFound)
+	require.NoError(t, svc.RevokeKey(ctx, "user", key.ID))
+	assert.ErrorIs(t, svc.RevokeKey(ctx, "user", key.ID), repository.ErrRepoNotFound)
+	_, err = svc.Authenticate(ctx, rawKey)
+	assert.ErrorIs(t, err, app_error.ErrServiceInvalidAPIKey)
+
+	keys, err := svc.ListKeys(ctx, "user")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.True(t, keys[0].IsRevoked())
+}
+
+func TestAPIKeyService_CreateKey_Validation(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	repo, err := repository.NewInMemoryAPIKeyRepository("", *logger)
+	require.NoError(t, err)
+	svc := service.NewAPIKeyService(repo)
+
+	_, _, err = svc.CreateKey(ctx, "user", "bot", nil)
+	assert.ErrorIs(t, err, app_error.ErrServiceInvalidAPIKeyScope)
+	_, _, err = svc.CreateKey(ctx, "user", "bot", []model.APIKeyScope{"admin"})
+	assert.ErrorIs(t, err, app_error.ErrServiceInvalidAPIKeyScope)
+	_, _, err = svc.CreateKey(ctx, "user", strings.Repeat("a", 101), []model.APIKeyScope{model.APIKeyScopeRead})
+	assert.ErrorIs(t, err, app_error.ErrServiceInvalidAPIKeyName)
+
+	for range 50 {
+		_, _, err = svc.CreateKey(ctx, "user", "bot", []model.APIKeyScope{model.APIKeyScopeRead})
+		require.NoError(t, err)
+	}
+	_, _, err = svc.CreateKey(ctx, "user", "bot", []model.APIKeyScope{model.APIKeyScopeRead})
+	assert.ErrorIs(t, err, app_error.ErrServiceTooManyAPIKeys)
+}
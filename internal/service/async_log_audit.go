@@ -0,0 +1,276 @@
+// Package service содержит реализацию асинхронного менеджера аудита логов.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oegegr/shortener/internal/metrics"
+	"github.com/oegegr/shortener/internal/model"
+
+	"github.com/avast/retry-go"
+	"go.uber.org/zap"
+)
+
+// auditRetryAttempts представляет максимальное количество попыток доставки пакета аудит-логов.
+const auditRetryAttempts = 5
+
+// auditRetryDelay представляет начальную задержку между попытками доставки; задержка растет экспоненциально.
+const auditRetryDelay = 100 * time.Millisecond
+
+// auditRetryMaxDelay представляет максимальную задержку между попытками доставки.
+const auditRetryMaxDelay = 5 * time.Second
+
+// AsyncLogAuditManager представляет менеджер аудита логов, который не блокирует обработку запросов.
+// Для каждого аудитора создается собственная очередь и фоновый поток, который доставляет лог-элементы пакетами
+// с повторными попытками. Недоставленные лог-элементы записываются в файл недоставленных сообщений.
+type AsyncLogAuditManager struct {
+	// workers представляет фоновые потоки аудиторов.
+	workers []*auditWorker
+	// deadLetter представляет файл недоставленных сообщений.
+	deadLetter *AuditDeadLetter
+	// logger представляет логгер для записи сообщений.
+	logger zap.SugaredLogger
+	// mu представляет mutex для синхронизации остановки с добавлением лог-элементов.
+	mu sync.RWMutex
+	// stopped представляет флаг, указывающий, что прием лог-элементов остановлен.
+	stopped bool
+	// workerWG представляет группу ожидания для фоновых потоков.
+	workerWG sync.WaitGroup
+	// deliveryCtx представляет контекст доставки, который отменяется, если остановка не уложилась в отведенное время.
+	deliveryCtx context.Context
+	// cancelDelivery отменяет контекст доставки.
+	cancelDelivery context.CancelFunc
+}
+
+// auditWorker представляет очередь и параметры доставки лог-элементов одному аудитору.
+type auditWorker struct {
+	// name представляет название аудитора.
+	name string
+	// auditor представляет аудитора.
+	auditor LogAuditor
+	// queue представляет очередь лог-элементов.
+	queue chan model.LogAuditItem
+	// batchSize представляет максимальный размер пакета лог-элементов.
+	batchSize int
+	// flushInterval представляет максимальное время ожидания перед доставкой неполного пакета.
+	flushInterval time.Duration
+}
+
+// NewAsyncLogAuditManager возвращает новый экземпляр AsyncLogAuditManager и запускает фоновые потоки.
+// Эта функция принимает список аудиторов, файл недоставленных сообщений (может быть nil), логгер,
+// размер очереди каждого аудитора, размер пакета и интервал доставки.
+func NewAsyncLogAuditManager(
+	auditors []LogAuditor,
+	deadLetter *AuditDeadLetter,
+	logger zap.SugaredLogger,
+	queueSize int,
+	batchSize int,
+	flushInterval time.Duration,
+) *AsyncLogAuditManager {
+	deliveryCtx, cancelDelivery := context.WithCancel(context.Background())
+	manager := &AsyncLogAuditManager{
+		deadLetter:     deadLetter,
+		logger:         logger,
+		deliveryCtx:    deliveryCtx,
+		cancelDelivery: cancelDelivery,
+	}
+	for _, auditor := range auditors {
+		manager.workers = append(manager.workers, &auditWorker{
+			name:          auditorName(auditor),
+			auditor:       auditor,
+			queue:         make(chan model.LogAuditItem, queueSize),
+			batchSize:     batchSize,
+			flushInterval: flushInterval,
+		})
+	}
+	manager.Start()
+	return manager
+}
+
+// Start запускает фоновые потоки доставки лог-элементов.
+func (m *AsyncLogAuditManager) Start() {
+	m.workerWG.Add(len(m.workers))
+	for _, worker := range m.workers {
+		go m.run(worker)
+	}
+}
+
+// Stop прекращает прием лог-элементов, доставляет накопленные лог-элементы и дожидается завершения фоновых потоков.
+// Если контекст завершается раньше, повторные попытки доставки прерываются,
+// а недоставленные лог-элементы записываются в файл недоставленных сообщений.
+func (m *AsyncLogAuditManager) Stop(ctx context.Context) {
+	m.mu.Lock()
+	m.stopped = true
+	for _, worker := range m.workers {
+		close(worker.queue)
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.workerWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		m.logger.Warn("audit delivery has been interrupted by shutdown")
+		m.cancelDelivery()
+		<-done
+	}
+	m.cancelDelivery()
+}
+
+// NotifyAllAuditors добавляет лог-элемент в очереди всех аудиторов.
+// Если очередь аудитора переполнена, лог-элемент сразу записывается в файл недоставленных сообщений.
+func (m *AsyncLogAuditManager) NotifyAllAuditors(ctx context.Context, logItem model.LogAuditItem) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.stopped {
+		return
+	}
+
+	for _, worker := range m.workers {
+		select {
+		case worker.queue <- logItem:
+		default:
+			m.logger.Warnf("audit queue of %s auditor is full", worker.name)
+			m.fail(worker, []model.LogAuditItem{logItem})
+		}
+	}
+}
+
+// run представляет фоновый поток, доставляющий лог-элементы аудитору пакетами.
+func (m *AsyncLogAuditManager) run(worker *auditWorker) {
+	defer m.workerWG.Done()
+
+	ticker := time.NewTicker(worker.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]model.LogAuditItem, 0, worker.batchSize)
+	for {
+		select {
+		case item, ok := <-worker.queue:
+			if !ok {
+				m.deliver(worker, batch)
+				return
+			}
+			batch = append(batch, item)
+			if len(batch) >= worker.batchSize {
+				m.deliver(worker, batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			m.deliver(worker, batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// deliver доставляет пакет лог-элементов аудитору с повторными попытками и экспоненциальной задержкой.
+func (m *AsyncLogAuditManager) deliver(worker *auditWorker, batch []model.LogAuditItem) {
+	if len(batch) == 0 {
+		return
+	}
+
+	err := retry.Do(
+		func() error {
+			return saveLogItems(m.deliveryCtx, worker.auditor, batch)
+		},
+		retry.Context(m.deliveryCtx),
+		retry.Attempts(auditRetryAttempts),
+		retry.Delay(auditRetryDelay),
+		retry.MaxDelay(auditRetryMaxDelay),
+		retry.DelayType(retry.BackOffDelay),
+		retry.LastErrorOnly(true),
+		retry.OnRetry(func(n uint, err error) {
+			m.logger.Debugf("retry %d of audit delivery to %s auditor: %v", n+1, worker.name, err)
+		}),
+	)
+	if err != nil {
+		m.logger.Errorf("failed to deliver %d audit items to %s auditor: %v", len(batch), worker.name, err)
+		m.fail(worker, batch)
+	}
+}
+
+// fail учитывает недоставленные лог-элементы в метриках и записывает их в файл недоставленных сообщений.
+func (m *AsyncLogAuditManager) fail(worker *auditWorker, items []model.LogAuditItem) {
+	metrics.AuditDeliveryFailuresTotal.WithLabelValues(worker.name).Add(float64(len(items)))
+
+	if m.deadLetter == nil {
+		return
+	}
+	if err := m.deadLetter.Write(worker.name, items); err != nil {
+		m.logger.Errorf("failed to write %d audit items to dead letter file: %v", len(items), err)
+	}
+}
+
+// saveLogItems сохраняет пакет лог-элементов одной операцией, если аудитор это поддерживает.
+func saveLogItems(ctx context.Context, auditor LogAuditor, items []model.LogAuditItem) error {
+	if batchAuditor, ok := auditor.(BatchLogAuditor); ok {
+		return batchAuditor.SaveLogItems(ctx, items)
+	}
+
+	for _, item := range items {
+		if err := auditor.SaveLogItem(ctx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AuditDeadLetter представляет файл недоставленных аудит-логов в формате JSON Lines.
+type AuditDeadLetter struct {
+	// path представляет путь к файлу.
+	path string
+	// mu представляет mutex для синхронизации доступа к файлу.
+	mu sync.Mutex
+}
+
+// deadLetterEntry представляет запись файла недоставленных аудит-логов.
+type deadLetterEntry struct {
+	// Auditor представляет название аудитора, которому не удалось доставить лог-элемент.
+	Auditor string `json:"auditor"`
+	// Item представляет недоставленный лог-элемент.
+	Item model.LogAuditItem `json:"item"`
+}
+
+// NewAuditDeadLetter возвращает новый экземпляр AuditDeadLetter.
+// Эта функция принимает путь к файлу недоставленных аудит-логов.
+func NewAuditDeadLetter(path string) *AuditDeadLetter {
+	return &AuditDeadLetter{path: path}
+}
+
+// Write дописывает недоставленные лог-элементы аудитора в файл.
+func (d *AuditDeadLetter) Write(auditor string, items []model.LogAuditItem) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var data []byte
+	for _, item := range items {
+		line, err := json.Marshal(deadLetterEntry{Auditor: auditor, Item: item})
+		if err != nil {
+			return fmt.Errorf("failed to marshal dead letter entry: %w", err)
+		}
+		data = append(data, line...)
+		data = append(data, '\n')
+	}
+
+	file, err := os.OpenFile(d.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open dead letter file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write dead letter file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package service_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestAsyncLogAuditManager_BatchesAndRetries(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+
+	var (
+		mu       sync.Mutex
+		batches  [][]model.LogAuditItem
+		requests atomic.Int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Первый запрос завершается ошибкой, чтобы проверить повторную доставку.
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var items []model.LogAuditItem
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&items))
+		mu.Lock()
+		batches = append(batches, items)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	auditors := []service.LogAuditor{service.NewHTTPLogAuditor(server.URL)}
+	manager := service.NewAsyncLogAuditManager(auditors, nil, *logger, 10, 100, time.Hour)
+
+	manager.NotifyAllAuditors(ctx, *model.NewLogAuditItem("https://one.com", "user", model.LogActionShorten))
+	manager.NotifyAllAuditors(ctx, *model.NewLogAuditItem("https://two.com", "user", model.LogActionFollow))
+	manager.Stop(ctx)
+
+	// Лог-элементы после остановки игнорируются.
+	manager.NotifyAllAuditors(ctx, *model.NewLogAuditItem("https://three.com", "user", model.LogActionFollow))
+
+	assert.Equal(t, int32(2), requests.Load())
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0], 2)
+	assert.Equal(t, "https://one.com", batches[0][0].URL)
+	assert.Equal(t, "https://two.com", batches[0][1].URL)
+}
+
+func TestAsyncLogAuditManager_DeadLetter(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	deadLetterPath := filepath.Join(dir, "dead_letter.jsonl")
+	auditLogPath := filepath.Join(dir, "audit.log")
+	auditors := []service.LogAuditor{
+		service.NewFileLogAuditor(auditLogPath),
+		service.NewHTTPLogAuditor(server.URL),
+	}
+	manager := service.NewAsyncLogAuditManager(auditors, service.NewAuditDeadLetter(deadLetterPath), *logger, 10, 100, time.Hour)
+
+	manager.NotifyAllAuditors(ctx, *model.NewLogAuditItem("https://one.com", "user", model.LogActionShorten))
+	manager.Stop(ctx)
+
+	// Ответ 4xx не повторяется.
+	assert.Equal(t, int32(1), requests.Load())
+
+	auditLog, err := os.ReadFile(auditLogPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(auditLog), "https://one.com")
+
+	file, err := os.Open(deadLetterPath)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var entries []map[string]any
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 1)
+	assert.Equal(t, "http", entries[0]["auditor"])
+	assert.Equal(t, "https://one.com", entries[0]["item"].(map[string]any)["url"])
+}
+
+func TestAsyncLogAuditManager_StopDeadline(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	deadLetterPath := filepath.Join(t.TempDir(), "dead_letter.jsonl")
+	auditors := []service.LogAuditor{service.NewHTTPLogAuditor(server.URL)}
+	manager := service.NewAsyncLogAuditManager(auditors, service.NewAuditDeadLetter(deadLetterPath), *logger, 10, 100, time.Hour)
+
+	manager.NotifyAllAuditors(ctx, *model.NewLogAuditItem("https://one.com", "user", model.LogActionShorten))
+
+	stopCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	manager.Stop(stopCtx)
+
+	// Повторные попытки прерываются по завершении контекста остановки, а лог-элементы не теряются.
+	assert.Less(t, time.Since(started), time.Second)
+	data, err := os.ReadFile(deadLetterPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "https://one.com")
+}
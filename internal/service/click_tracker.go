@@ -0,0 +1,174 @@
+// Package service содержит реализацию асинхронной записи переходов по сокращенным URL-адресам.
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"go.uber.org/zap"
+)
+
+// ClickTracker представляет интерфейс для записи переходов по сокращенным URL-адресам.
+type ClickTracker interface {
+	// Track регистрирует переход по сокращенному URL-адресу.
+	Track(ctx context.Context, shortID string, referrer string, userAgent string, ip string)
+}
+
+// URLAccessRecorder представляет интерфейс для сохранения моментов последних переходов по URL-адресам.
+type URLAccessRecorder interface {
+	// MarkAccessed сохраняет моменты последних переходов по сокращенному идентификатору.
+	MarkAccessed(ctx context.Context, accessed map[string]time.Time) error
+}
+
+// QueueClickTracker представляет реализацию ClickTracker, которая накапливает переходы в очереди
+// и сохраняет их в репозиторий пакетами в фоновом потоке.
+type QueueClickTracker struct {
+	// clickRepository представляет репозиторий переходов.
+	clickRepository repository.ClickRepository
+	// accessRecorder представляет хранилище моментов последних переходов по URL-адресам.
+	accessRecorder URLAccessRecorder
+	// logger представляет логгер для записи сообщений.
+	logger zap.SugaredLogger
+	// ipHashKey представляет ключ для хеширования IP-адресов.
+	ipHashKey []byte
+	// queue представляет очередь переходов.
+	queue chan model.Click
+	// batchSize представляет максимальный размер пакета переходов.
+	batchSize int
+	// flushInterval представляет максимальное время ожидания перед сохранением неполного пакета.
+	flushInterval time.Duration
+	// mu представляет mutex для синхронизации остановки с добавлением переходов.
+	mu sync.RWMutex
+	// stopped представляет флаг, указывающий, что прием переходов остановлен.
+	stopped bool
+	// workerWG представляет группу ожидания для фонового потока.
+	workerWG sync.WaitGroup
+}
+
+// NewQueueClickTracker возвращает новый экземпляр QueueClickTracker и запускает фоновый поток.
+// Эта функция принимает репозиторий переходов, хранилище моментов последних переходов, логгер, ключ хеширования IP-адресов, размер очереди, размер пакета и интервал сохранения.
+func NewQueueClickTracker(
+	repo repository.ClickRepository,
+	accessRecorder URLAccessRecorder,
+	logger zap.SugaredLogger,
+	ipHashKey string,
+	queueSize int,
+	batchSize int,
+	flushInterval time.Duration,
+) *QueueClickTracker {
+	tracker := &QueueClickTracker{
+		clickRepository: repo,
+		accessRecorder:  accessRecorder,
+		logger:          logger,
+		ipHashKey:       []byte(ipHashKey),
+		queue:           make(chan model.Click, queueSize),
+		batchSize:       batchSize,
+		flushInterval:   flushInterval,
+	}
+	tracker.Start()
+	return tracker
+}
+
+// Start запускает фоновый поток сохранения переходов.
+func (t *QueueClickTracker) Start() {
+	t.workerWG.Add(1)
+	go t.worker()
+}
+
+// Stop прекращает прием переходов, сохраняет накопленные переходы и дожидается завершения фонового потока.
+func (t *QueueClickTracker) Stop() {
+	t.mu.Lock()
+	t.stopped = true
+	close(t.queue)
+	t.mu.Unlock()
+
+	t.workerWG.Wait()
+}
+
+// Track добавляет переход в очередь. Если очередь переполнена, переход отбрасывается.
+func (t *QueueClickTracker) Track(ctx context.Context, shortID string, referrer string, userAgent string, ip string) {
+	click := model.Click{
+		ShortID:   shortID,
+		TS:        time.Now().UTC(),
+		Referrer:  referrer,
+		UserAgent: userAgent,
+		IPHash:    t.hashIP(ip),
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.stopped {
+		return
+	}
+
+	select {
+	case t.queue <- click:
+	default:
+		t.logger.Warn("click queue is full, click has been dropped")
+	}
+}
+
+// hashIP возвращает HMAC-SHA256 IP-адреса, чтобы не хранить адрес клиента в открытом виде.
+func (t *QueueClickTracker) hashIP(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, t.ipHashKey)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// worker представляет фоновый поток, сохраняющий переходы пакетами.
+func (t *QueueClickTracker) worker() {
+	defer t.workerWG.Done()
+
+	ticker := time.NewTicker(t.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]model.Click, 0, t.batchSize)
+	for {
+		select {
+		case click, ok := <-t.queue:
+			if !ok {
+				t.flush(batch)
+				return
+			}
+			batch = append(batch, click)
+			if len(batch) >= t.batchSize {
+				t.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			t.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flush сохраняет пакет переходов в репозиторий и обновляет моменты последних переходов по URL-адресам.
+// Момент последнего перехода обновляется одной операцией на пакет, а не при каждом перенаправлении.
+func (t *QueueClickTracker) flush(batch []model.Click) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := t.clickRepository.SaveClicks(context.Background(), batch); err != nil {
+		t.logger.Errorf("failed to save clicks: %v", err)
+	}
+
+	accessed := make(map[string]time.Time)
+	for _, click := range batch {
+		if click.TS.After(accessed[click.ShortID]) {
+			accessed[click.ShortID] = click.TS
+		}
+	}
+	if err := t.accessRecorder.MarkAccessed(context.Background(), accessed); err != nil {
+		t.logger.Errorf("failed to save last access time: %v", err)
+	}
+}
@@ -0,0 +1,17 @@
+// Package service содержит мок-реализацию записи переходов для тестирования.
+package service
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockClickTracker представляет мок-реализацию записи переходов по сокращенным URL-адресам.
+type MockClickTracker struct {
+	mock.Mock
+}
+
+// Track регистрирует переход по сокращенному URL-адресу (мок-реализация).
+func (m *MockClickTracker) Track(ctx context.Context, shortID string, referrer string, userAgent string, ip string) {
+}
@@ -0,0 +1,79 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestQueueClickTracker_FlushOnStop(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	clickRepo := repository.NewInMemoryClickRepository()
+	urlRepo, err := repository.NewInMemoryURLRepository("", repository.DefaultJournalConfig(), *logger)
+	require.NoError(t, err)
+	require.NoError(t, urlRepo.CreateURL(ctx, []model.URLItem{*model.NewURLItem("https://original.com", "abc", user, false)}))
+	tracker := service.NewQueueClickTracker(clickRepo, urlRepo, *logger, "key", 10, 100, time.Hour)
+
+	tracker.Track(ctx, "abc", "https://ref.com", "agent", "10.0.0.1")
+	tracker.Track(ctx, "abc", "", "agent", "10.0.0.2")
+	tracker.Stop()
+
+	// Переходы после остановки игнорируются.
+	tracker.Track(ctx, "abc", "", "agent", "10.0.0.3")
+
+	stats, err := clickRepo.GetClickStats(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Total)
+	require.Len(t, stats.Daily, 1)
+	assert.Equal(t, time.Now().UTC().Format(model.ClickDateLayout), stats.Daily[0].Date)
+
+	item, err := urlRepo.FindURLByID(ctx, "abc")
+	require.NoError(t, err)
+	require.NotNil(t, item.LastAccessedAt)
+	assert.WithinDuration(t, time.Now(), *item.LastAccessedAt, time.Minute)
+}
+
+func TestURLStatsService_GetURLStats(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	urlRepo, err := repository.NewInMemoryURLRepository("", repository.DefaultJournalConfig(), *logger)
+	require.NoError(t, err)
+	clickRepo := repository.NewInMemoryClickRepository()
+	svc := service.NewURLStatsService(urlRepo, clickRepo)
+
+	require.NoError(t, urlRepo.CreateURL(ctx, []model.URLItem{*model.NewURLItem("https://original.com", "abc", user, false)}))
+	require.NoError(t, clickRepo.SaveClicks(ctx, []model.Click{
+		{ShortID: "abc", TS: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{ShortID: "abc", TS: time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)},
+		{ShortID: "abc", TS: time.Date(2024, 3, 2, 1, 0, 0, 0, time.UTC)},
+	}))
+
+	t.Run("Owner", func(t *testing.T) {
+		stats, err := svc.GetURLStats(ctx, user, "abc")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), stats.Total)
+		assert.Equal(t, []model.DailyClicks{
+			{Date: "2024-03-01", Count: 2},
+			{Date: "2024-03-02", Count: 1},
+		}, stats.Daily)
+	})
+
+	t.Run("Not Owner", func(t *testing.T) {
+		_, err := svc.GetURLStats(ctx, "stranger", "abc")
+		assert.ErrorIs(t, err, app_error.ErrServiceURLNotOwned)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		_, err := svc.GetURLStats(ctx, user, "missing")
+		assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+	})
+}
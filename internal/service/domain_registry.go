@@ -0,0 +1,163 @@
+// Package service содержит реестр доменов сокращенных URL-адресов.
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+)
+
+// ShortDomain представляет дополнительный домен сокращенных URL-адресов.
+type ShortDomain struct {
+	// BaseURL представляет базовый URL-адрес сокращенных URL-адресов домена.
+	BaseURL string
+	// Users представляет пользователей, которым разрешено сокращать URL-адреса в домене; пустой список разрешает всем.
+	Users []string
+}
+
+// NewShortDomain возвращает дополнительный домен с проверенным базовым URL-адресом.
+// Эта функция принимает базовый URL-адрес со схемой и хостом и пользователей, которым разрешен домен; пустые имена пропускаются.
+func NewShortDomain(baseURL string, users []string) (ShortDomain, error) {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return ShortDomain{}, fmt.Errorf("invalid short domain %q: base url with scheme and host is required", baseURL)
+	}
+
+	domain := ShortDomain{BaseURL: baseURL}
+	for _, user := range users {
+		if user = strings.TrimSpace(user); user != "" {
+			domain.Users = append(domain.Users, user)
+		}
+	}
+	return domain, nil
+}
+
+// registeredDomain представляет домен в реестре.
+type registeredDomain struct {
+	// baseURL представляет базовый URL-адрес сокращенных URL-адресов домена.
+	baseURL string
+	// users представляет пользователей, которым разрешен домен; nil разрешает всем.
+	users map[string]struct{}
+}
+
+// DomainRegistry представляет реестр доменов сокращенных URL-адресов.
+// Домен по умолчанию задается BaseURL и обозначается пустой строкой; остальные домены обозначаются именем хоста.
+type DomainRegistry struct {
+	// defaultBaseURL представляет базовый URL-адрес домена по умолчанию.
+	defaultBaseURL string
+	// defaultHost представляет имя хоста домена по умолчанию.
+	defaultHost string
+	// domains представляет дополнительные домены по имени хоста.
+	domains map[string]registeredDomain
+}
+
+// NewDomainRegistry возвращает новый экземпляр DomainRegistry.
+// Эта функция принимает базовый URL-адрес домена по умолчанию и дополнительные домены.
+func NewDomainRegistry(baseURL string, domains ...ShortDomain) *DomainRegistry {
+	registry := &DomainRegistry{
+		defaultBaseURL: strings.TrimRight(baseURL, "/"),
+		defaultHost:    hostOf(baseURL),
+		domains:        make(map[string]registeredDomain, len(domains)),
+	}
+
+	for _, domain := range domains {
+		host := hostOf(domain.BaseURL)
+		if host == "" || host == registry.defaultHost {
+			continue
+		}
+
+		registered := registeredDomain{baseURL: strings.TrimRight(domain.BaseURL, "/")}
+		if len(domain.Users) > 0 {
+			registered.users = make(map[string]struct{}, len(domain.Users))
+			for _, user := range domain.Users {
+				registered.users[user] = struct{}{}
+			}
+		}
+		registry.domains[host] = registered
+	}
+	return registry
+}
+
+// ResolveHost возвращает домен по значению заголовка Host.
+// Хост домена по умолчанию и неизвестные хосты соответствуют домену по умолчанию.
+func (r *DomainRegistry) ResolveHost(host string) string {
+	host = strings.ToLower(host)
+	if _, ok := r.domains[host]; ok {
+		return host
+	}
+
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		if _, ok := r.domains[hostname]; ok {
+			return hostname
+		}
+	}
+	return ""
+}
+
+// Resolve возвращает домен, в котором пользователь сокращает URL-адрес.
+// Эта функция принимает имя хоста из запроса (пустая строка означает домен по умолчанию) и идентификатор пользователя
+// и возвращает app_error.ErrServiceUnknownDomain для ненастроенного домена и app_error.ErrServiceDomainNotAllowed,
+// если домен не разрешен пользователю.
+func (r *DomainRegistry) Resolve(domain string, userID string) (string, error) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if domain == "" || domain == r.defaultHost {
+		return "", nil
+	}
+
+	registered, ok := r.domains[domain]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", app_error.ErrServiceUnknownDomain, domain)
+	}
+
+	if registered.users != nil {
+		if _, ok := registered.users[userID]; !ok {
+			return "", fmt.Errorf("%w: %s", app_error.ErrServiceDomainNotAllowed, domain)
+		}
+	}
+	return domain, nil
+}
+
+// BaseURL возвращает базовый URL-адрес сокращенных URL-адресов домена.
+// Для домена, удаленного из конфигурации, используется схема домена по умолчанию, чтобы ранее выданные ссылки оставались полными.
+func (r *DomainRegistry) BaseURL(domain string) string {
+	if domain == "" {
+		return r.defaultBaseURL
+	}
+	if registered, ok := r.domains[domain]; ok {
+		return registered.baseURL
+	}
+
+	scheme := "https"
+	if parsed, err := url.Parse(r.defaultBaseURL); err == nil && parsed.Scheme != "" {
+		scheme = parsed.Scheme
+	}
+	return scheme + "://" + domain
+}
+
+// hostOf возвращает имя хоста базового URL-адреса в нижнем регистре или пустую строку для некорректного URL-адреса.
+func hostOf(baseURL string) string {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Host)
+}
+
+// domainContextKey представляет ключ домена сокращенных URL-адресов в контексте запроса.
+type domainContextKey struct{}
+
+// ContextWithDomain возвращает контекст с доменом сокращенных URL-адресов, по которому пришел запрос.
+func ContextWithDomain(ctx context.Context, domain string) context.Context {
+	return context.WithValue(ctx, domainContextKey{}, domain)
+}
+
+// DomainFromContext возвращает домен сокращенных URL-адресов из контекста; пустая строка означает домен по умолчанию.
+func DomainFromContext(ctx context.Context) string {
+	domain, _ := ctx.Value(domainContextKey{}).(string)
+	return domain
+}
@@ -0,0 +1,75 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewShortDomain(t *testing.T) {
+	domain, err := service.NewShortDomain(" https://brand.link/ ", nil)
+	require.NoError(t, err)
+	assert.Equal(t, service.ShortDomain{BaseURL: "https://brand.link"}, domain)
+
+	domain, err = service.NewShortDomain("https://partner.link", []string{"alice", " bob ", ""})
+	require.NoError(t, err)
+	assert.Equal(t, service.ShortDomain{BaseURL: "https://partner.link", Users: []string{"alice", "bob"}}, domain)
+
+	_, err = service.NewShortDomain("brand.link", nil)
+	assert.Error(t, err)
+}
+
+func TestDomainRegistry_Resolve(t *testing.T) {
+	registry := service.NewDomainRegistry("https://short.com",
+		service.ShortDomain{BaseURL: "https://brand.link"},
+		service.ShortDomain{BaseURL: "https://partner.link", Users: []string{"alice"}},
+	)
+
+	tests := []struct {
+		name    string
+		domain  string
+		userID  string
+		want    string
+		wantErr error
+	}{
+		{name: "default domain", domain: "", userID: "bob", want: ""},
+		{name: "default host", domain: "short.com", userID: "bob", want: ""},
+		{name: "open domain", domain: "Brand.Link", userID: "bob", want: "brand.link"},
+		{name: "restricted domain allowed", domain: "partner.link", userID: "alice", want: "partner.link"},
+		{name: "restricted domain denied", domain: "partner.link", userID: "bob", wantErr: app_error.ErrServiceDomainNotAllowed},
+		{name: "unknown domain", domain: "evil.link", userID: "alice", wantErr: app_error.ErrServiceUnknownDomain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := registry.Resolve(tt.domain, tt.userID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDomainRegistry_ResolveHostAndBaseURL(t *testing.T) {
+	registry := service.NewDomainRegistry("http://short.com:8080", service.ShortDomain{BaseURL: "https://brand.link"})
+
+	assert.Equal(t, "brand.link", registry.ResolveHost("BRAND.link"))
+	assert.Equal(t, "brand.link", registry.ResolveHost("brand.link:443"))
+	assert.Equal(t, "", registry.ResolveHost("short.com:8080"))
+	assert.Equal(t, "", registry.ResolveHost("unknown.com"))
+
+	assert.Equal(t, "http://short.com:8080", registry.BaseURL(""))
+	assert.Equal(t, "https://brand.link", registry.BaseURL("brand.link"))
+	assert.Equal(t, "http://retired.link", registry.BaseURL("retired.link"))
+
+	ctx := service.ContextWithDomain(context.Background(), "brand.link")
+	assert.Equal(t, "brand.link", service.DomainFromContext(ctx))
+	assert.Equal(t, "", service.DomainFromContext(context.Background()))
+}
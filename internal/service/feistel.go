@@ -0,0 +1,66 @@
+// Package service содержит перестановку значений счетчика сетью Фейстеля для непредсказуемых коротких кодов.
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/bits"
+)
+
+// maxFeistelWidth представляет максимальную длину кода, на множестве значений которой строится перестановка:
+// 62^10 помещается в uint64, а 62^11 — нет.
+const maxFeistelWidth = 10
+
+// feistelRounds представляет количество раундов сети Фейстеля.
+const feistelRounds = 6
+
+// feistelPermutation представляет биекцию множества [0, 62^width) на себя, заданную секретным ключом.
+// Перестановка строится сбалансированной сетью Фейстеля над ближайшим сверху четным числом бит;
+// значения вне множества повторно шифруются, пока не попадут в него (cycle walking).
+type feistelPermutation struct {
+	// key представляет секретный ключ раундовой функции.
+	key []byte
+}
+
+// newFeistelPermutation возвращает перестановку с заданным секретным ключом.
+func newFeistelPermutation(key []byte) *feistelPermutation {
+	return &feistelPermutation{key: key}
+}
+
+// permute возвращает образ значения n < 62^width.
+func (p *feistelPermutation) permute(n uint64, width int) uint64 {
+	domain := uint64(1)
+	for range width {
+		domain *= base62
+	}
+
+	halfBits := (bits.Len64(domain-1) + 1) / 2
+	for {
+		n = p.encrypt(n, halfBits)
+		if n < domain {
+			return n
+		}
+	}
+}
+
+// encrypt шифрует значение из 2*halfBits бит сетью Фейстеля.
+func (p *feistelPermutation) encrypt(n uint64, halfBits int) uint64 {
+	mask := uint64(1)<<halfBits - 1
+	left, right := n>>halfBits, n&mask
+	for round := range feistelRounds {
+		left, right = right, left^(p.roundFunc(round, right)&mask)
+	}
+	return left<<halfBits | right
+}
+
+// roundFunc возвращает значение раундовой функции HMAC-SHA256 для номера раунда и правой половины.
+func (p *feistelPermutation) roundFunc(round int, half uint64) uint64 {
+	var msg [9]byte
+	msg[0] = byte(round)
+	binary.BigEndian.PutUint64(msg[1:], half)
+
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write(msg[:])
+	return binary.BigEndian.Uint64(mac.Sum(nil))
+}
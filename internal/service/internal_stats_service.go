@@ -0,0 +1,44 @@
+// Package service содержит реализацию сервиса агрегированной статистики.
+package service
+
+import (
+	"context"
+
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+)
+
+// InternalStatsProvider представляет интерфейс для получения агрегированной статистики сервиса.
+type InternalStatsProvider interface {
+	// GetInternalStats возвращает количество сокращенных URL-адресов и пользователей и текущую длину сокращенных идентификаторов.
+	GetInternalStats(ctx context.Context) (*model.InternalStats, error)
+}
+
+// InternalStatsService представляет реализацию сервиса агрегированной статистики.
+type InternalStatsService struct {
+	// urlRepository представляет репозиторий URL-адресов.
+	urlRepository repository.URLRepository
+	// codeLength представляет эффективную длину генерируемых сокращенных идентификаторов.
+	codeLength *ShortCodeLength
+}
+
+// NewInternalStatsService возвращает новый экземпляр InternalStatsService.
+// Эта функция принимает репозиторий URL-адресов и эффективную длину сокращенных идентификаторов.
+func NewInternalStatsService(repo repository.URLRepository, codeLength *ShortCodeLength) *InternalStatsService {
+	return &InternalStatsService{urlRepository: repo, codeLength: codeLength}
+}
+
+// GetInternalStats возвращает количество сокращенных URL-адресов и пользователей и текущую длину сокращенных идентификаторов.
+func (s *InternalStatsService) GetInternalStats(ctx context.Context) (*model.InternalStats, error) {
+	urls, err := s.urlRepository.CountURLs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.urlRepository.CountUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.InternalStats{URLs: urls, Users: users, ShortCodeLength: s.codeLength.Get()}, nil
+}
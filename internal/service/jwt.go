@@ -2,11 +2,22 @@
 package service
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"maps"
+	"math/big"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/oegegr/shortener/internal/model"
 	"go.uber.org/zap"
 )
 
@@ -18,10 +29,43 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
+// DefaultJWTLifetime представляет время жизни JWT-токенов по умолчанию.
+const DefaultJWTLifetime = 24 * time.Hour
+
+// minRSAKeyBits представляет минимальный допустимый размер ключа RSA.
+const minRSAKeyBits = 2048
+
+// jwtKeyIDLength представляет длину идентификатора ключа (kid), вычисляемого по открытому ключу.
+const jwtKeyIDLength = 16
+
+// jwtKey представляет ключ подписи или проверки JWT-токенов.
+type jwtKey struct {
+	// id представляет идентификатор ключа (kid); пустая строка у симметричного ключа.
+	id string
+	// method представляет алгоритм подписи.
+	method jwt.SigningMethod
+	// signKey представляет ключ подписи; nil у ключей, используемых только для проверки.
+	signKey any
+	// verifyKey представляет ключ проверки подписи.
+	verifyKey any
+}
+
+// JWKSProvider представляет интерфейс получения открытых ключей проверки JWT-токенов.
+type JWKSProvider interface {
+	// JWKS возвращает открытые ключи проверки JWT-токенов в формате JSON Web Key Set.
+	JWKS() model.JSONWebKeySet
+}
+
 // JWTParser представляет парсер JWT-токенов.
+// Токены подписываются одним ключом, а проверяются любым из действующих ключей, выбранным по заголовку kid,
+// что позволяет менять ключ подписи без отзыва уже выданных токенов.
 type JWTParser struct {
-	// jwtSecret представляет секретный ключ для подписи JWT-токенов.
-	jwtSecret string
+	// signingKey представляет ключ подписи новых токенов.
+	signingKey jwtKey
+	// verifyKeys представляет ключи проверки по идентификатору ключа.
+	verifyKeys map[string]jwtKey
+	// lifetime представляет время жизни выдаваемых токенов.
+	lifetime time.Duration
 	// logger представляет логгер для записи сообщений.
 	logger zap.SugaredLogger
 }
@@ -29,36 +73,106 @@ type JWTParser struct {
 // ErrInvalidJWTToken представляет ошибку, которая возникает при невалидном JWT-токене.
 var ErrInvalidJWTToken = errors.New("invalid token")
 
-// NewJWTParser возвращает новый экземпляр JWTParser.
-// Эта функция принимает секретный ключ для подписи JWT-токенов и логгер.
-func NewJWTParser(jwtSecret string, logger zap.SugaredLogger) JWTParser {
-	return JWTParser{jwtSecret, logger}
+// NewJWTParser возвращает новый экземпляр JWTParser, подписывающий токены алгоритмом HS256.
+// Эта функция принимает секретный ключ для подписи JWT-токенов, время жизни токенов и логгер.
+func NewJWTParser(jwtSecret string, lifetime time.Duration, logger zap.SugaredLogger) JWTParser {
+	key := symmetricJWTKey(jwtSecret)
+	return JWTParser{
+		signingKey: key,
+		verifyKeys: map[string]jwtKey{key.id: key},
+		lifetime:   lifetime,
+		logger:     logger,
+	}
+}
+
+// NewAsymmetricJWTParser возвращает новый экземпляр JWTParser, подписывающий токены закрытым ключом:
+// RS256 для ключа RSA и EdDSA для ключа Ed25519.
+// Эта функция принимает закрытый ключ подписи в формате PEM, дополнительные ключи проверки в формате PEM
+// (например, предыдущий ключ подписи на время ротации), секретный ключ HS256 для проверки токенов, выданных до перехода
+// на асимметричную подпись (пустая строка отключает их прием), время жизни токенов и логгер.
+func NewAsymmetricJWTParser(
+	privateKeyPEM []byte,
+	verifyKeysPEM [][]byte,
+	legacySecret string,
+	lifetime time.Duration,
+	logger zap.SugaredLogger,
+) (JWTParser, error) {
+	signer, err := parsePrivateKeyPEM(privateKeyPEM)
+	if err != nil {
+		return JWTParser{}, fmt.Errorf("failed to load jwt signing key: %w", err)
+	}
+
+	signingKey, err := asymmetricJWTKey(signer.Public())
+	if err != nil {
+		return JWTParser{}, fmt.Errorf("failed to load jwt signing key: %w", err)
+	}
+	signingKey.signKey = signer
+
+	parser := JWTParser{
+		signingKey: signingKey,
+		verifyKeys: map[string]jwtKey{signingKey.id: signingKey},
+		lifetime:   lifetime,
+		logger:     logger,
+	}
+
+	for idx, data := range verifyKeysPEM {
+		public, err := parsePublicKeyPEM(data)
+		if err != nil {
+			return JWTParser{}, fmt.Errorf("failed to load jwt verification key #%d: %w", idx+1, err)
+		}
+		key, err := asymmetricJWTKey(public)
+		if err != nil {
+			return JWTParser{}, fmt.Errorf("failed to load jwt verification key #%d: %w", idx+1, err)
+		}
+		parser.verifyKeys[key.id] = key
+	}
+
+	if legacySecret != "" {
+		key := symmetricJWTKey(legacySecret)
+		parser.verifyKeys[key.id] = key
+	}
+	return parser, nil
+}
+
+// Lifetime возвращает время жизни выдаваемых токенов.
+func (v *JWTParser) Lifetime() time.Duration {
+	return v.lifetime
 }
 
 // CreateNewJWTToken создает новый JWT-токен для пользователя.
 // Эта функция принимает идентификатор пользователя и возвращает сгенерированный JWT-токен.
 func (v *JWTParser) CreateNewJWTToken(userID string) (string, error) {
+	now := time.Now()
 	claims := &Claims{
 		UserID: userID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(v.lifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(v.jwtSecret))
+	token := jwt.NewWithClaims(v.signingKey.method, claims)
+	if v.signingKey.id != "" {
+		token.Header["kid"] = v.signingKey.id
+	}
+	return token.SignedString(v.signingKey.signKey)
 }
 
 // UserFromJWTToken извлекает идентификатор пользователя из JWT-токена.
 // Эта функция принимает JWT-токен и возвращает идентификатор пользователя, если токен валиден.
+// Ключ проверки выбирается по заголовку kid, а алгоритм токена должен совпадать с алгоритмом ключа.
 func (v *JWTParser) UserFromJWTToken(tokenString string) (string, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := v.verifyKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %q", kid)
+		}
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(v.jwtSecret), nil
+		return key.verifyKey, nil
 	})
 
 	if err != nil {
@@ -71,3 +185,122 @@ func (v *JWTParser) UserFromJWTToken(tokenString string) (string, error) {
 
 	return claims.UserID, nil
 }
+
+// JWKS возвращает открытые ключи проверки токенов в формате JSON Web Key Set.
+// Симметричный ключ HS256 не публикуется.
+func (v *JWTParser) JWKS() model.JSONWebKeySet {
+	set := model.JSONWebKeySet{Keys: []model.JSONWebKey{}}
+
+	// Ключ подписи публикуется первым, остальные ключи — в порядке идентификаторов.
+	keys := []jwtKey{v.signingKey}
+	for _, id := range slices.Sorted(maps.Keys(v.verifyKeys)) {
+		if id != v.signingKey.id {
+			keys = append(keys, v.verifyKeys[id])
+		}
+	}
+
+	for _, key := range keys {
+		switch public := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, model.JSONWebKey{
+				KeyType:   "RSA",
+				KeyID:     key.id,
+				Use:       "sig",
+				Algorithm: key.method.Alg(),
+				Modulus:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				Exponent:  base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, model.JSONWebKey{
+				KeyType:   "OKP",
+				KeyID:     key.id,
+				Use:       "sig",
+				Algorithm: key.method.Alg(),
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	return set
+}
+
+// symmetricJWTKey возвращает ключ HS256 для заданного секрета.
+func symmetricJWTKey(secret string) jwtKey {
+	return jwtKey{
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+}
+
+// asymmetricJWTKey возвращает ключ проверки для открытого ключа RSA или Ed25519.
+// Идентификатор ключа вычисляется по хешу открытого ключа, поэтому не зависит от конфигурации экземпляров сервиса.
+func asymmetricJWTKey(public crypto.PublicKey) (jwtKey, error) {
+	var method jwt.SigningMethod
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return jwtKey{}, fmt.Errorf("rsa key must be at least %d bits", minRSAKeyBits)
+		}
+		method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return jwtKey{}, fmt.Errorf("unsupported key type %T: rsa or ed25519 key is required", public)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return jwtKey{}, err
+	}
+	sum := sha256.Sum256(der)
+
+	return jwtKey{
+		id:        base64.RawURLEncoding.EncodeToString(sum[:])[:jwtKeyIDLength],
+		method:    method,
+		verifyKey: public,
+	}, nil
+}
+
+// parsePrivateKeyPEM возвращает закрытый ключ из блока PEM в формате PKCS#8 или PKCS#1.
+func parsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no pem block found")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("failed to parse private key: pkcs8 or pkcs1 key is required")
+	}
+	return key, nil
+}
+
+// parsePublicKeyPEM возвращает открытый ключ из блока PEM с открытым ключом (PKIX или PKCS#1) или с закрытым ключом.
+func parsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no pem block found")
+	}
+
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	signer, err := parsePrivateKeyPEM(data)
+	if err != nil {
+		return nil, errors.New("failed to parse public key: pkix or pkcs1 key is required")
+	}
+	return signer.Public(), nil
+}
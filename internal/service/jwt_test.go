@@ -0,0 +1,174 @@
+package service_test
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func encodePrivateKeyPEM(t *testing.T, key crypto.Signer) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func encodePublicKeyPEM(t *testing.T, key crypto.PublicKey) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestJWTParser_HS256(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	parser := service.NewJWTParser("secret", time.Hour, *logger)
+
+	token, err := parser.CreateNewJWTToken("user")
+	require.NoError(t, err)
+
+	userID, err := parser.UserFromJWTToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user", userID)
+	assert.Equal(t, time.Hour, parser.Lifetime())
+	assert.Empty(t, parser.JWKS().Keys)
+
+	other := service.NewJWTParser("other", time.Hour, *logger)
+	_, err = other.UserFromJWTToken(token)
+	assert.Error(t, err)
+
+	expired := service.NewJWTParser("secret", -time.Minute, *logger)
+	token, err = expired.CreateNewJWTToken("user")
+	require.NoError(t, err)
+	_, err = parser.UserFromJWTToken(token)
+	assert.Error(t, err)
+}
+
+func TestJWTParser_Asymmetric(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		key  crypto.Signer
+		alg  string
+		kty  string
+	}{
+		{name: "RS256", key: rsaKey, alg: "RS256", kty: "RSA"},
+		{name: "EdDSA", key: edKey, alg: "EdDSA", kty: "OKP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := service.NewAsymmetricJWTParser(encodePrivateKeyPEM(t, tt.key), nil, "", time.Hour, *logger)
+			require.NoError(t, err)
+
+			token, err := parser.CreateNewJWTToken("user")
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &service.Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.alg, parsed.Method.Alg())
+
+			userID, err := parser.UserFromJWTToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, "user", userID)
+
+			jwks := parser.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tt.kty, jwks.Keys[0].KeyType)
+			assert.Equal(t, tt.alg, jwks.Keys[0].Algorithm)
+			assert.Equal(t, parsed.Header["kid"], jwks.Keys[0].KeyID)
+		})
+	}
+}
+
+func TestJWTParser_Rotation(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	_, oldKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	oldParser, err := service.NewAsymmetricJWTParser(encodePrivateKeyPEM(t, oldKey), nil, "", time.Hour, *logger)
+	require.NoError(t, err)
+	oldToken, err := oldParser.CreateNewJWTToken("old-user")
+	require.NoError(t, err)
+
+	legacyParser := service.NewJWTParser("legacy-secret", time.Hour, *logger)
+	legacyToken, err := legacyParser.CreateNewJWTToken("legacy-user")
+	require.NoError(t, err)
+
+	// Новый ключ подписывает токены, а предыдущий ключ и прежний секрет HS256 продолжают приниматься.
+	parser, err := service.NewAsymmetricJWTParser(
+		encodePrivateKeyPEM(t, newKey),
+		[][]byte{encodePublicKeyPEM(t, oldKey.Public())},
+		"legacy-secret",
+		time.Hour,
+		*logger,
+	)
+	require.NoError(t, err)
+
+	userID, err := parser.UserFromJWTToken(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, "old-user", userID)
+
+	userID, err = parser.UserFromJWTToken(legacyToken)
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-user", userID)
+	assert.Len(t, parser.JWKS().Keys, 2)
+
+	// Без предыдущего ключа старые токены отклоняются.
+	rotated, err := service.NewAsymmetricJWTParser(encodePrivateKeyPEM(t, newKey), nil, "", time.Hour, *logger)
+	require.NoError(t, err)
+	_, err = rotated.UserFromJWTToken(oldToken)
+	assert.Error(t, err)
+	_, err = rotated.UserFromJWTToken(legacyToken)
+	assert.Error(t, err)
+}
+
+func TestJWTParser_RejectsAlgorithmMismatch(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	parser, err := service.NewAsymmetricJWTParser(encodePrivateKeyPEM(t, rsaKey), nil, "", time.Hour, *logger)
+	require.NoError(t, err)
+
+	kid := parser.JWKS().Keys[0].KeyID
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &service.Claims{UserID: "attacker"})
+	forged.Header["kid"] = kid
+	token, err := forged.SignedString(encodePublicKeyPEM(t, rsaKey.Public()))
+	require.NoError(t, err)
+
+	_, err = parser.UserFromJWTToken(token)
+	assert.Error(t, err)
+}
+
+func TestNewAsymmetricJWTParser_InvalidKeys(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+
+	_, err := service.NewAsymmetricJWTParser([]byte("not a pem"), nil, "", time.Hour, *logger)
+	assert.Error(t, err)
+
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, err = service.NewAsymmetricJWTParser(encodePrivateKeyPEM(t, weakKey), nil, "", time.Hour, *logger)
+	assert.Error(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, err = service.NewAsymmetricJWTParser(encodePrivateKeyPEM(t, edKey), [][]byte{[]byte("garbage")}, "", time.Hour, *logger)
+	assert.Error(t, err)
+}
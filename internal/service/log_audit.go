@@ -11,7 +11,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/oegegr/shortener/internal/metrics"
 	"github.com/oegegr/shortener/internal/model"
+
+	"github.com/avast/retry-go"
 )
 
 // LogAuditManager представляет интерфейс для менеджера аудита логов.
@@ -37,7 +40,21 @@ func NewDefaultLogAuditManager(auditors []LogAuditor) *DefaultLogAuditManager {
 // NotifyAllAuditors уведомляет всех аудиторов о новом лог-элементе.
 func (m *DefaultLogAuditManager) NotifyAllAuditors(ctx context.Context, logItem model.LogAuditItem) {
 	for _, auditor := range m.auditors {
-		auditor.SaveLogItem(ctx, logItem)
+		if err := auditor.SaveLogItem(ctx, logItem); err != nil {
+			metrics.AuditDeliveryFailuresTotal.WithLabelValues(auditorName(auditor)).Inc()
+		}
+	}
+}
+
+// auditorName возвращает название аудитора для метрик.
+func auditorName(auditor LogAuditor) string {
+	switch auditor.(type) {
+	case *FileLogAuditor:
+		return "file"
+	case *HTTPLogAuditor:
+		return "http"
+	default:
+		return "other"
 	}
 }
 
@@ -47,6 +64,13 @@ type LogAuditor interface {
 	SaveLogItem(ctx context.Context, item model.LogAuditItem) error
 }
 
+// BatchLogAuditor представляет интерфейс для аудитора логов, который умеет сохранять несколько лог-элементов за одну операцию.
+type BatchLogAuditor interface {
+	LogAuditor
+	// SaveLogItems сохраняет пакет лог-элементов в аудиторе.
+	SaveLogItems(ctx context.Context, items []model.LogAuditItem) error
+}
+
 // FileLogAuditor представляет реализацию аудитора логов, который записывает логи в файл.
 type FileLogAuditor struct {
 	// fileLog представляет путь к файлу логов.
@@ -63,16 +87,24 @@ func NewFileLogAuditor(fileLog string) *FileLogAuditor {
 
 // SaveLogItem сохраняет лог-элемент в файле логов.
 func (a *FileLogAuditor) SaveLogItem(ctx context.Context, item model.LogAuditItem) error {
+	return a.SaveLogItems(ctx, []model.LogAuditItem{item})
+}
+
+// SaveLogItems сохраняет пакет лог-элементов в файле логов, по одному элементу на строку.
+func (a *FileLogAuditor) SaveLogItems(ctx context.Context, items []model.LogAuditItem) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	data, err := json.Marshal(item)
-	if err != nil {
-		return fmt.Errorf("failed to marshal log item: %w", err)
+	var data []byte
+	for _, item := range items {
+		line, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to marshal log item: %w", err)
+		}
+		data = append(data, line...)
+		data = append(data, '\n')
 	}
 
-	data = append(data, '\n')
-
 	file, err := os.OpenFile(a.fileLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to open log file: %w", err)
@@ -112,7 +144,23 @@ func (a *HTTPLogAuditor) SaveLogItem(ctx context.Context, item model.LogAuditIte
 		return fmt.Errorf("failed to marshal log item: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", a.httpAddress, bytes.NewBuffer(data))
+	return a.post(ctx, data)
+}
+
+// SaveLogItems сохраняет пакет лог-элементов, отправляя их JSON-массивом в одном HTTP-запросе.
+func (a *HTTPLogAuditor) SaveLogItems(ctx context.Context, items []model.LogAuditItem) error {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log items: %w", err)
+	}
+
+	return a.post(ctx, data)
+}
+
+// post отправляет данные на HTTP-эндпоинт аудита.
+// Ответ с кодом 4xx означает, что повтор запроса не поможет, и возвращается как неисправимая ошибка.
+func (a *HTTPLogAuditor) post(ctx context.Context, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.httpAddress, bytes.NewBuffer(data))
 	if err != nil {
 		return fmt.Errorf("failed to create HTTP request: %w", err)
 	}
@@ -125,6 +173,10 @@ func (a *HTTPLogAuditor) SaveLogItem(ctx context.Context, item model.LogAuditIte
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return retry.Unrecoverable(fmt.Errorf("HTTP request failed with status: %d %s", resp.StatusCode, resp.Status))
+	}
+
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return fmt.Errorf("HTTP request failed with status: %d %s", resp.StatusCode, resp.Status)
 	}
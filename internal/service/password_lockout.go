@@ -0,0 +1,162 @@
+// Package service содержит реализацию блокировки клиентов после неудачных попыток ввода пароля.
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+)
+
+// LockoutError представляет ошибку блокировки клиента с указанием времени до снятия блокировки.
+type LockoutError struct {
+	// RetryAfter представляет время до снятия блокировки.
+	RetryAfter time.Duration
+}
+
+// Error возвращает текст ошибки.
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", app_error.ErrServiceTooManyAttempts, e.RetryAfter.Round(time.Second))
+}
+
+// Unwrap возвращает app_error.ErrServiceTooManyAttempts, чтобы ошибку можно было проверить через errors.Is.
+func (e *LockoutError) Unwrap() error {
+	return app_error.ErrServiceTooManyAttempts
+}
+
+// pendingRetryAfter представляет время ожидания клиента, все попытки которого заняты незавершенными проверками пароля.
+const pendingRetryAfter = time.Second
+
+// PasswordAttemptLimiter представляет интерфейс учета неудачных попыток ввода пароля.
+// Каждая проверка пароля начинается с Acquire и завершается вызовом Fail или Reset.
+type PasswordAttemptLimiter interface {
+	// Acquire резервирует попытку клиента или возвращает *LockoutError, если клиент заблокирован
+	// либо все оставшиеся попытки заняты незавершенными проверками.
+	Acquire(key string) error
+	// Fail учитывает зарезервированную попытку клиента как неудачную.
+	Fail(key string)
+	// Reset сбрасывает неудачные попытки клиента после успешного ввода пароля.
+	Reset(key string)
+}
+
+// passwordAttempts представляет неудачные попытки ввода пароля одним клиентом.
+type passwordAttempts struct {
+	// failures представляет количество неудачных попыток в текущем окне.
+	failures int
+	// pending представляет количество зарезервированных и еще не завершенных попыток.
+	pending int
+	// windowEnd представляет момент, после которого неудачные попытки забываются.
+	windowEnd time.Time
+	// lockedUntil представляет момент снятия блокировки.
+	lockedUntil time.Time
+}
+
+// InMemoryPasswordAttemptLimiter представляет учет неудачных попыток ввода пароля в памяти процесса.
+// После maxFailures неудачных попыток в течение окна клиент блокируется на время lockout.
+type InMemoryPasswordAttemptLimiter struct {
+	// mu представляет mutex для синхронизации доступа к попыткам.
+	mu sync.Mutex
+	// attempts представляет карту попыток по ключу клиента.
+	attempts map[string]*passwordAttempts
+	// maxFailures представляет количество неудачных попыток до блокировки.
+	maxFailures int
+	// window представляет окно учета неудачных попыток.
+	window time.Duration
+	// lockout представляет длительность блокировки.
+	lockout time.Duration
+	// sweptAt представляет момент последнего удаления устаревших записей.
+	sweptAt time.Time
+	// now возвращает текущее время.
+	now func() time.Time
+}
+
+// NewInMemoryPasswordAttemptLimiter возвращает новый экземпляр InMemoryPasswordAttemptLimiter.
+// Эта функция принимает количество неудачных попыток до блокировки, окно учета попыток и длительность блокировки.
+func NewInMemoryPasswordAttemptLimiter(maxFailures int, window time.Duration, lockout time.Duration) *InMemoryPasswordAttemptLimiter {
+	return &InMemoryPasswordAttemptLimiter{
+		attempts:    make(map[string]*passwordAttempts),
+		maxFailures: maxFailures,
+		window:      window,
+		lockout:     lockout,
+		sweptAt:     time.Now(),
+		now:         time.Now,
+	}
+}
+
+// Acquire резервирует попытку клиента. Проверка блокировки и резервирование выполняются под одной блокировкой,
+// поэтому параллельные запросы не могут проверить больше maxFailures паролей до блокировки клиента.
+func (l *InMemoryPasswordAttemptLimiter) Acquire(key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	attempts := l.current(key, now)
+	if now.Before(attempts.lockedUntil) {
+		return &LockoutError{RetryAfter: attempts.lockedUntil.Sub(now)}
+	}
+	if attempts.failures+attempts.pending >= l.maxFailures {
+		return &LockoutError{RetryAfter: pendingRetryAfter}
+	}
+
+	attempts.pending++
+	return nil
+}
+
+// Fail учитывает зарезервированную попытку клиента как неудачную и блокирует его после maxFailures попыток.
+func (l *InMemoryPasswordAttemptLimiter) Fail(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	attempts := l.current(key, now)
+	if attempts.pending > 0 {
+		attempts.pending--
+	}
+
+	attempts.failures++
+	if attempts.failures >= l.maxFailures {
+		attempts.lockedUntil = now.Add(l.lockout)
+		attempts.windowEnd = attempts.lockedUntil
+		attempts.failures = 0
+	}
+}
+
+// Reset сбрасывает неудачные попытки клиента.
+func (l *InMemoryPasswordAttemptLimiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.attempts, key)
+}
+
+// current возвращает попытки клиента в текущем окне учета, начиная новое окно по его истечении.
+// Незавершенные попытки переносятся в новое окно.
+func (l *InMemoryPasswordAttemptLimiter) current(key string, now time.Time) *passwordAttempts {
+	attempts, ok := l.attempts[key]
+	if !ok {
+		attempts = &passwordAttempts{windowEnd: now.Add(l.window)}
+		l.attempts[key] = attempts
+		return attempts
+	}
+	if !now.Before(attempts.windowEnd) && !now.Before(attempts.lockedUntil) {
+		*attempts = passwordAttempts{windowEnd: now.Add(l.window), pending: attempts.pending}
+	}
+	return attempts
+}
+
+// sweep удаляет записи клиентов, у которых истекли окно учета попыток и блокировка.
+func (l *InMemoryPasswordAttemptLimiter) sweep(now time.Time) {
+	if now.Sub(l.sweptAt) < l.window {
+		return
+	}
+	l.sweptAt = now
+
+	for key, attempts := range l.attempts {
+		if attempts.pending == 0 && !now.Before(attempts.windowEnd) && !now.Before(attempts.lockedUntil) {
+			delete(l.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package service_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryPasswordAttemptLimiter(t *testing.T) {
+	t.Run("Locks After Max Failures", func(t *testing.T) {
+		limiter := service.NewInMemoryPasswordAttemptLimiter(3, time.Minute, time.Minute)
+
+		for range 3 {
+			require.NoError(t, limiter.Acquire("1.2.3.4"))
+			limiter.Fail("1.2.3.4")
+		}
+
+		err := limiter.Acquire("1.2.3.4")
+		require.ErrorIs(t, err, app_error.ErrServiceTooManyAttempts)
+		var lockoutErr *service.LockoutError
+		require.True(t, errors.As(err, &lockoutErr))
+		assert.Greater(t, lockoutErr.RetryAfter, 59*time.Second)
+		assert.NoError(t, limiter.Acquire("5.6.7.8"))
+	})
+
+	t.Run("Reset Clears Failures", func(t *testing.T) {
+		limiter := service.NewInMemoryPasswordAttemptLimiter(2, time.Minute, time.Minute)
+
+		require.NoError(t, limiter.Acquire("1.2.3.4"))
+		limiter.Fail("1.2.3.4")
+		require.NoError(t, limiter.Acquire("1.2.3.4"))
+		limiter.Reset("1.2.3.4")
+		require.NoError(t, limiter.Acquire("1.2.3.4"))
+		limiter.Fail("1.2.3.4")
+
+		assert.NoError(t, limiter.Acquire("1.2.3.4"))
+	})
+
+	t.Run("Pending Attempts Count", func(t *testing.T) {
+		limiter := service.NewInMemoryPasswordAttemptLimiter(3, time.Minute, time.Minute)
+
+		var wg sync.WaitGroup
+		var acquired atomic.Int32
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if limiter.Acquire("1.2.3.4") == nil {
+					acquired.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// Параллельные запросы не могут проверить больше паролей, чем допускает блокировка.
+		assert.Equal(t, int32(3), acquired.Load())
+
+		limiter.Fail("1.2.3.4")
+		require.ErrorIs(t, limiter.Acquire("1.2.3.4"), app_error.ErrServiceTooManyAttempts)
+	})
+
+	t.Run("Lockout Expires", func(t *testing.T) {
+		limiter := service.NewInMemoryPasswordAttemptLimiter(1, time.Minute, 20*time.Millisecond)
+
+		require.NoError(t, limiter.Acquire("1.2.3.4"))
+		limiter.Fail("1.2.3.4")
+		require.Error(t, limiter.Acquire("1.2.3.4"))
+
+		assert.Eventually(t, func() bool {
+			return limiter.Acquire("1.2.3.4") == nil
+		}, time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,114 @@
+// Package service содержит учет эффективной длины генерируемых сокращенных идентификаторов.
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/oegegr/shortener/internal/metrics"
+	"github.com/oegegr/shortener/internal/repository"
+)
+
+// Параметры увеличения длины сокращенных идентификаторов.
+const (
+	// collisionWindow представляет количество попыток генерации, по которым оценивается доля коллизий.
+	collisionWindow = 1000
+	// collisionRateThreshold представляет долю коллизий в окне, при которой длина увеличивается.
+	collisionRateThreshold = 0.01
+)
+
+// ShortCodeLength представляет эффективную длину генерируемых сокращенных идентификаторов.
+// Длина начинается с минимальной и увеличивается на единицу, когда доля коллизий в окне попыток превышает
+// collisionRateThreshold; после максимальной длины рост прекращается. Длина не уменьшается.
+// Если задано хранилище, увеличенная длина сохраняется в нем и восстанавливается после перезапуска.
+type ShortCodeLength struct {
+	// mu представляет mutex для синхронизации доступа к счетчикам.
+	mu sync.Mutex
+	// saveMu представляет mutex, упорядочивающий сохранение длины в хранилище.
+	saveMu sync.Mutex
+	// current представляет текущую длину.
+	current int
+	// maxLength представляет максимальную длину.
+	maxLength int
+	// attempts представляет количество попыток в текущем окне.
+	attempts int
+	// collisions представляет количество коллизий в текущем окне.
+	collisions int
+	// store представляет хранилище длины; nil отключает сохранение.
+	store repository.ShortCodeLengthRepository
+}
+
+// NewShortCodeLength возвращает новый экземпляр ShortCodeLength без сохранения длины между запусками.
+// Эта функция принимает минимальную и максимальную длину; максимальная длина меньше минимальной фиксирует длину.
+func NewShortCodeLength(minLength int, maxLength int) *ShortCodeLength {
+	l := &ShortCodeLength{
+		current:   minLength,
+		maxLength: max(minLength, maxLength),
+	}
+	metrics.ShortCodeLength.Set(float64(minLength))
+	return l
+}
+
+// RestoreShortCodeLength возвращает новый экземпляр ShortCodeLength, сохраняющий длину в хранилище.
+// Эта функция принимает хранилище длины, минимальную и максимальную длину; сохраненная длина ограничивается
+// заданными границами, поэтому изменение конфигурации применяется и к восстановленной длине.
+func RestoreShortCodeLength(ctx context.Context, store repository.ShortCodeLengthRepository, minLength int, maxLength int) (*ShortCodeLength, error) {
+	stored, err := store.LoadShortCodeLength(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	l := NewShortCodeLength(minLength, maxLength)
+	l.current = min(max(stored, minLength), l.maxLength)
+	l.store = store
+	metrics.ShortCodeLength.Set(float64(l.current))
+	return l, nil
+}
+
+// Get возвращает текущую длину сокращенных идентификаторов.
+func (l *ShortCodeLength) Get() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.current
+}
+
+// Observe учитывает попытку генерации и возвращает true, если длина увеличилась.
+// Эта функция принимает признак коллизии. Ошибка сохранения увеличенной длины возвращается вместе с true:
+// длина в памяти все равно увеличивается. Длина сохраняется вне блокировки счетчиков и без отмены вместе
+// с контекстом запроса, чтобы медленное хранилище не задерживало другие запросы, а отмененный запрос не терял увеличение.
+func (l *ShortCodeLength) Observe(ctx context.Context, collided bool) (bool, error) {
+	if !l.observe(collided) {
+		return false, nil
+	}
+	if l.store == nil {
+		return true, nil
+	}
+
+	// Сохранения выполняются по очереди и записывают актуальную длину, поэтому меньшая длина не перезапишет большую.
+	l.saveMu.Lock()
+	defer l.saveMu.Unlock()
+	return true, l.store.SaveShortCodeLength(context.WithoutCancel(ctx), l.Get())
+}
+
+// observe учитывает попытку генерации в счетчиках окна и возвращает true, если длина увеличилась.
+func (l *ShortCodeLength) observe(collided bool) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.attempts++
+	if collided {
+		l.collisions++
+	}
+
+	if float64(l.collisions) >= collisionRateThreshold*collisionWindow && l.current < l.maxLength {
+		l.current++
+		l.attempts, l.collisions = 0, 0
+		metrics.ShortCodeLength.Set(float64(l.current))
+		return true
+	}
+
+	if l.attempts >= collisionWindow {
+		l.attempts, l.collisions = 0, 0
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package service_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortCodeLength_Observe(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Consecutive Collisions", func(t *testing.T) {
+		length := service.NewShortCodeLength(6, 7)
+
+		// Несколько коллизий подряд в одном запросе не увеличивают длину без превышения доли коллизий в окне.
+		for range 5 {
+			grown, err := length.Observe(ctx, true)
+			require.NoError(t, err)
+			assert.False(t, grown)
+		}
+		assert.Equal(t, 6, length.Get())
+	})
+
+	t.Run("Collision Rate", func(t *testing.T) {
+		length := service.NewShortCodeLength(6, 8)
+
+		grown := false
+		for idx := range 1000 {
+			// Каждая десятая попытка завершается одиночной коллизией.
+			if ok, _ := length.Observe(ctx, idx%10 == 0); ok {
+				grown = true
+				break
+			}
+		}
+		assert.True(t, grown)
+		assert.Equal(t, 7, length.Get())
+	})
+
+	t.Run("Rare Collisions", func(t *testing.T) {
+		length := service.NewShortCodeLength(6, 8)
+
+		for idx := range 5000 {
+			length.Observe(ctx, idx%200 == 0)
+		}
+		assert.Equal(t, 6, length.Get())
+	})
+
+	t.Run("Max Length", func(t *testing.T) {
+		length := service.NewShortCodeLength(6, 6)
+
+		for range 1000 {
+			grown, _ := length.Observe(ctx, true)
+			assert.False(t, grown, "length must not exceed max")
+		}
+		assert.Equal(t, 6, length.Get())
+	})
+}
+
+func TestRestoreShortCodeLength(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "storage.json.length")
+
+	store, err := repository.NewInMemoryShortCodeLengthRepository(path)
+	require.NoError(t, err)
+	length, err := service.RestoreShortCodeLength(ctx, store, 6, 8)
+	require.NoError(t, err)
+	assert.Equal(t, 6, length.Get())
+
+	for range 10 {
+		_, err := length.Observe(ctx, true)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 7, length.Get())
+
+	// После перезапуска длина восстанавливается из файла.
+	store, err = repository.NewInMemoryShortCodeLengthRepository(path)
+	require.NoError(t, err)
+	restored, err := service.RestoreShortCodeLength(ctx, store, 6, 8)
+	require.NoError(t, err)
+	assert.Equal(t, 7, restored.Get())
+
+	// Сохраненная длина ограничивается границами из конфигурации.
+	clamped, err := service.RestoreShortCodeLength(ctx, store, 5, 6)
+	require.NoError(t, err)
+	assert.Equal(t, 6, clamped.Get())
+}
@@ -2,32 +2,143 @@
 package service
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
 	"math/big"
+	"strconv"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/repository"
 )
 
 // ShortCodeProvider представляет интерфейс для провайдера коротких кодов.
 type ShortCodeProvider interface {
-	// Get возвращает короткий код заданной длины.
-	Get(length int) string
+	// Get возвращает короткий код не короче заданной длины для оригинального URL-адреса.
+	// Эта функция принимает номер попытки, начиная с нуля: после коллизии провайдер должен вернуть другой код.
+	Get(ctx context.Context, originalURL string, length int, attempt int) (string, error)
 }
 
-// RandomShortCodeProvider представляет реализацию провайдера коротких кодов на основе случайных чисел.
-type RandomShortCodeProvider struct{}
+// Способы генерации коротких кодов.
+const (
+	// ShortCodeRandom означает случайные коды.
+	ShortCodeRandom = "random"
+	// ShortCodeSequential означает коды из значений счетчика в кодировке base62.
+	ShortCodeSequential = "sequential"
+	// ShortCodeObfuscated означает коды из значений счетчика, переставленных сетью Фейстеля с секретным ключом.
+	ShortCodeObfuscated = "obfuscated"
+	// ShortCodeHash означает коды из хэша оригинального URL-адреса.
+	ShortCodeHash = "hash"
+)
 
 // chars представляет строку с допустимыми символами для короткого кода.
 const (
 	chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 )
 
+// base62 представляет основание кодировки коротких кодов.
+const base62 = uint64(len(chars))
+
+// RandomShortCodeProvider представляет реализацию провайдера коротких кодов на основе случайных чисел.
+type RandomShortCodeProvider struct{}
+
 // Get возвращает короткий код заданной длины, сгенерированный на основе случайных чисел.
-func (p *RandomShortCodeProvider) Get(length int) string {
+func (p *RandomShortCodeProvider) Get(ctx context.Context, originalURL string, length int, attempt int) (string, error) {
 	b := make([]byte, length)
 	max := big.NewInt(int64(len(chars)))
 
 	for i := range b {
-		n, _ := rand.Int(rand.Reader, max)
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
 		b[i] = chars[n.Int64()]
 	}
+	return string(b), nil
+}
+
+// SequenceShortCodeProvider представляет провайдер коротких кодов на основе счетчика.
+// Разные значения счетчика всегда дают разные коды, поэтому коллизии возможны только с пользовательскими идентификаторами.
+type SequenceShortCodeProvider struct {
+	// sequence представляет счетчик, из значений которого строятся коды.
+	sequence repository.SequenceRepository
+	// permutation представляет перестановку значений счетчика; nil означает последовательные коды.
+	permutation *feistelPermutation
+}
+
+// NewSequenceShortCodeProvider возвращает новый экземпляр SequenceShortCodeProvider.
+// Эта функция принимает счетчик и секретный ключ перестановки: с пустым ключом коды идут подряд,
+// с непустым — значения счетчика переставляются, и по коду нельзя угадать соседние коды.
+func NewSequenceShortCodeProvider(sequence repository.SequenceRepository, secret string) *SequenceShortCodeProvider {
+	provider := &SequenceShortCodeProvider{sequence: sequence}
+	if secret != "" {
+		provider.permutation = newFeistelPermutation([]byte(secret))
+	}
+	return provider
+}
+
+// Get возвращает код следующего значения счетчика в кодировке base62.
+// Код дополняется до заданной длины и удлиняется, когда значение счетчика в нее не помещается.
+func (p *SequenceShortCodeProvider) Get(ctx context.Context, originalURL string, length int, attempt int) (string, error) {
+	n, err := p.sequence.NextSequence(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get next sequence value: %w", err)
+	}
+
+	width := max(length, base62Width(n))
+	if p.permutation == nil {
+		return encodeBase62(n, width), nil
+	}
+
+	domainWidth := min(width, maxFeistelWidth)
+	if base62Width(n) > domainWidth {
+		return "", fmt.Errorf("%w: sequence value %d", app_error.ErrServiceShortCodeSpaceExhausted, n)
+	}
+	return encodeBase62(p.permutation.permute(n, domainWidth), width), nil
+}
+
+// HashShortCodeProvider представляет провайдер коротких кодов на основе хэша SHA-256 оригинального URL-адреса.
+// Один и тот же URL-адрес всегда получает один и тот же код, в том числе после удаления и повторного сокращения.
+type HashShortCodeProvider struct{}
+
+// Get возвращает код из хэша оригинального URL-адреса; после коллизии к URL-адресу добавляется номер попытки.
+func (p *HashShortCodeProvider) Get(ctx context.Context, originalURL string, length int, attempt int) (string, error) {
+	input := originalURL
+	if attempt > 0 {
+		input += "\x00" + strconv.Itoa(attempt)
+	}
+
+	sum := sha256.Sum256([]byte(input))
+	n := new(big.Int).SetBytes(sum[:])
+	base := big.NewInt(int64(base62))
+	digit := new(big.Int)
+
+	b := make([]byte, length)
+	for i := range b {
+		n.DivMod(n, base, digit)
+		b[i] = chars[digit.Int64()]
+	}
+	return string(b), nil
+}
+
+// base62Width возвращает количество цифр значения в кодировке base62.
+func base62Width(n uint64) int {
+	width := 1
+	for n >= base62 {
+		n /= base62
+		width++
+	}
+	return width
+}
+
+// encodeBase62 возвращает значение в кодировке base62, дополненное слева нулевой цифрой до заданной длины.
+func encodeBase62(n uint64, width int) string {
+	width = max(width, base62Width(n))
+	b := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		b[i] = chars[n%base62]
+		n /= base62
+	}
 	return string(b)
 }
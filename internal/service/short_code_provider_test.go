@@ -1,19 +1,94 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"testing"
+
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func BenchmarkRandomShortCodeProvider_Get(b *testing.B) {
 	provider := &RandomShortCodeProvider{}
 	lengths := []int{5, 10, 20, 50}
+	ctx := context.Background()
 
 	for _, length := range lengths {
 		b.Run(fmt.Sprintf("length=%d", length), func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
-				provider.Get(length)
+				provider.Get(ctx, "", length, 0)
 			}
 		})
 	}
 }
+
+func TestSequenceShortCodeProvider_Sequential(t *testing.T) {
+	ctx := context.Background()
+	sequence, err := repository.NewInMemorySequenceRepository("", repository.DefaultSequenceBlockSize)
+	require.NoError(t, err)
+	provider := NewSequenceShortCodeProvider(sequence, "")
+
+	var codes []string
+	for range 3 {
+		code, err := provider.Get(ctx, "https://example.com", 4, 0)
+		require.NoError(t, err)
+		codes = append(codes, code)
+	}
+	assert.Equal(t, []string{"aaab", "aaac", "aaad"}, codes)
+
+	// Значение, не помещающееся в заданную длину, удлиняет код.
+	assert.Equal(t, "baa", encodeBase62(base62*base62, 2))
+}
+
+func TestSequenceShortCodeProvider_Obfuscated(t *testing.T) {
+	ctx := context.Background()
+	sequence, err := repository.NewInMemorySequenceRepository("", repository.DefaultSequenceBlockSize)
+	require.NoError(t, err)
+	provider := NewSequenceShortCodeProvider(sequence, "secret")
+
+	seen := make(map[string]bool)
+	for range 1000 {
+		code, err := provider.Get(ctx, "https://example.com", 6, 0)
+		require.NoError(t, err)
+		assert.Len(t, code, 6)
+		assert.False(t, seen[code], code)
+		seen[code] = true
+	}
+	assert.False(t, seen["aaaaab"], "obfuscated codes must not follow the counter")
+}
+
+func TestFeistelPermutation_Bijection(t *testing.T) {
+	const width = 2
+	domain := base62 * base62
+	permutation := newFeistelPermutation([]byte("secret"))
+
+	images := make(map[uint64]bool, domain)
+	for n := range domain {
+		image := permutation.permute(n, width)
+		require.Less(t, image, domain)
+		images[image] = true
+	}
+	assert.Len(t, images, int(domain))
+	assert.NotEqual(t, permutation.permute(1, width), newFeistelPermutation([]byte("other")).permute(1, width))
+}
+
+func TestHashShortCodeProvider_Get(t *testing.T) {
+	ctx := context.Background()
+	provider := &HashShortCodeProvider{}
+
+	first, err := provider.Get(ctx, "https://example.com", 8, 0)
+	require.NoError(t, err)
+	again, err := provider.Get(ctx, "https://example.com", 8, 0)
+	require.NoError(t, err)
+	other, err := provider.Get(ctx, "https://example.org", 8, 0)
+	require.NoError(t, err)
+	retried, err := provider.Get(ctx, "https://example.com", 8, 1)
+	require.NoError(t, err)
+
+	assert.Len(t, first, 8)
+	assert.Equal(t, first, again)
+	assert.NotEqual(t, first, other)
+	assert.NotEqual(t, first, retried)
+}
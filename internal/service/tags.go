@@ -0,0 +1,56 @@
+// Package service содержит проверку и нормализацию меток URL-адресов.
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+)
+
+// maxTagsPerURL представляет максимальное количество меток одного URL-адреса.
+const maxTagsPerURL = 20
+
+// maxTagLength представляет максимальную длину метки.
+const maxTagLength = 64
+
+// tagPattern представляет допустимые символы метки после нормализации.
+var tagPattern = regexp.MustCompile(`^[\p{Ll}\p{Lo}0-9_.:-]+$`)
+
+// NormalizeTag возвращает метку без пробелов по краям в нижнем регистре и проверяет ее.
+// Эта функция возвращает ошибку app_error.ErrServiceInvalidTag с описанием нарушенного правила.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+
+	if tag == "" || len(tag) > maxTagLength {
+		return "", fmt.Errorf("%w: length must be between 1 and %d bytes", app_error.ErrServiceInvalidTag, maxTagLength)
+	}
+
+	if !tagPattern.MatchString(tag) {
+		return "", fmt.Errorf("%w: %q contains forbidden characters", app_error.ErrServiceInvalidTag, tag)
+	}
+
+	return tag, nil
+}
+
+// NormalizeTags возвращает отсортированный список нормализованных меток без повторов.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, tag)
+	}
+
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+
+	if len(normalized) > maxTagsPerURL {
+		return nil, fmt.Errorf("%w: at most %d tags are allowed", app_error.ErrServiceInvalidTag, maxTagsPerURL)
+	}
+	return normalized, nil
+}
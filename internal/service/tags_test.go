@@ -0,0 +1,45 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tooMany := make([]string, 21)
+	for idx := range tooMany {
+		tooMany[idx] = strings.Repeat("t", idx+1)
+	}
+
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "Empty", tags: nil, want: []string{}},
+		{name: "Lowercase Sort And Dedupe", tags: []string{"Team", " docs ", "team"}, want: []string{"docs", "team"}},
+		{name: "Allowed Punctuation", tags: []string{"q1:2025", "go-lang", "v1.2_x"}, want: []string{"go-lang", "q1:2025", "v1.2_x"}},
+		{name: "Unicode Letters", tags: []string{"Проект"}, want: []string{"проект"}},
+		{name: "Blank", tags: []string{"  "}, wantErr: true},
+		{name: "Space Inside", tags: []string{"two words"}, wantErr: true},
+		{name: "Too Long", tags: []string{strings.Repeat("a", 65)}, wantErr: true},
+		{name: "Too Many", tags: tooMany, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.NormalizeTags(tt.tags)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, app_error.ErrServiceInvalidTag)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+// Package service содержит реализацию черного списка URL-адресов с перезагрузкой из файла.
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// blocklistRegexPrefix представляет префикс строки файла черного списка с регулярным выражением.
+const blocklistRegexPrefix = "regex:"
+
+// blocklist представляет загруженный черный список.
+type blocklist struct {
+	// domains представляет множество запрещенных доменов; поддомены запрещенного домена тоже запрещены.
+	domains map[string]struct{}
+	// patterns представляет регулярные выражения, проверяемые на полном URL-адресе.
+	patterns []*regexp.Regexp
+}
+
+// BlocklistPolicy представляет правило, запрещающее URL-адреса из черного списка.
+// Черный список загружается из файла, в котором каждая строка содержит домен или регулярное выражение с префиксом "regex:";
+// пустые строки и строки, начинающиеся с "#", пропускаются. Файл перечитывается при изменении, а при ошибке разбора
+// продолжает действовать предыдущая версия списка.
+type BlocklistPolicy struct {
+	// path представляет путь к файлу черного списка.
+	path string
+	// list представляет текущий черный список.
+	list atomic.Pointer[blocklist]
+	// modTime представляет время изменения загруженной версии файла.
+	modTime time.Time
+	// size представляет размер загруженной версии файла.
+	size int64
+	// reloadInterval представляет интервал проверки изменения файла.
+	reloadInterval time.Duration
+	// logger представляет логгер для записи сообщений.
+	logger zap.SugaredLogger
+	// stop представляет канал для остановки перезагрузки.
+	stop chan struct{}
+	// wg представляет группу ожидания для потока перезагрузки.
+	wg sync.WaitGroup
+}
+
+// NewBlocklistPolicy возвращает новый экземпляр BlocklistPolicy и запускает перезагрузку файла.
+// Эта функция принимает путь к файлу черного списка, интервал проверки изменения файла и логгер.
+func NewBlocklistPolicy(path string, reloadInterval time.Duration, logger zap.SugaredLogger) (*BlocklistPolicy, error) {
+	p := &BlocklistPolicy{
+		path:           path,
+		reloadInterval: reloadInterval,
+		logger:         logger,
+		stop:           make(chan struct{}),
+	}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	p.Start()
+	return p, nil
+}
+
+// Start запускает поток перезагрузки файла черного списка.
+func (p *BlocklistPolicy) Start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.reloadInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				if err := p.reloadIfChanged(); err != nil {
+					p.logger.Errorf("failed to reload url blocklist: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop останавливает поток перезагрузки файла черного списка.
+func (p *BlocklistPolicy) Stop() {
+	close(p.stop)
+	p.wg.Wait()
+}
+
+// Check проверяет, что URL-адрес не входит в черный список.
+func (p *BlocklistPolicy) Check(ctx context.Context, rawURL string, u *url.URL) error {
+	list := p.list.Load()
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	for domain := host; domain != ""; {
+		if _, ok := list.domains[domain]; ok {
+			return &URLPolicyError{Rule: URLRuleBlocklist, URL: rawURL, Reason: fmt.Sprintf("domain %s is blocked", domain)}
+		}
+		idx := strings.IndexByte(domain, '.')
+		if idx < 0 {
+			break
+		}
+		domain = domain[idx+1:]
+	}
+
+	for _, pattern := range list.patterns {
+		if pattern.MatchString(rawURL) {
+			return &URLPolicyError{Rule: URLRuleBlocklist, URL: rawURL, Reason: fmt.Sprintf("url matches blocked pattern %s", pattern)}
+		}
+	}
+	return nil
+}
+
+// reloadIfChanged перечитывает файл черного списка, если изменились время изменения или размер файла.
+func (p *BlocklistPolicy) reloadIfChanged() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return nil
+	}
+	return p.reload()
+}
+
+// reload читает и разбирает файл черного списка.
+func (p *BlocklistPolicy) reload() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat blocklist file: %w", err)
+	}
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to read blocklist file: %w", err)
+	}
+
+	list, err := parseBlocklist(data)
+	if err != nil {
+		return err
+	}
+
+	p.list.Store(list)
+	p.modTime = info.ModTime()
+	p.size = info.Size()
+	p.logger.Infof("url blocklist loaded: %d domains, %d patterns", len(list.domains), len(list.patterns))
+	return nil
+}
+
+// parseBlocklist разбирает содержимое файла черного списка.
+func parseBlocklist(data []byte) (*blocklist, error) {
+	list := &blocklist{domains: make(map[string]struct{})}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if expr, ok := strings.CutPrefix(line, blocklistRegexPrefix); ok {
+			pattern, err := regexp.Compile(strings.TrimSpace(expr))
+			if err != nil {
+				return nil, fmt.Errorf("invalid blocklist pattern on line %d: %w", lineNum, err)
+			}
+			list.patterns = append(list.patterns, pattern)
+			continue
+		}
+
+		list.domains[strings.TrimSuffix(strings.ToLower(line), ".")] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read blocklist file: %w", err)
+	}
+	return list, nil
+}
@@ -3,96 +3,293 @@ package service
 
 import (
 	"context"
-	"errors"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/oegegr/shortener/internal/metrics"
+	"github.com/oegegr/shortener/internal/model"
 	"github.com/oegegr/shortener/internal/repository"
 	"go.uber.org/zap"
 )
 
-// ErrDeleteQueueIsFull представляет ошибку, которая возникает при переполнении очереди удаления.
-var ErrDeleteQueueIsFull = errors.New("delete queue is full")
+// deletionMaxAttempts представляет максимальное количество попыток выполнения задачи удаления.
+const deletionMaxAttempts = 5
+
+// deletionRetryDelay представляет начальную задержку перед повторной попыткой; задержка растет экспоненциально.
+const deletionRetryDelay = 1 * time.Second
+
+// deletionLease представляет время, после которого выполняемая задача считается брошенной и выполняется повторно.
+const deletionLease = 1 * time.Minute
+
+// deletionPurgeInterval представляет интервал удаления завершенных задач, срок хранения которых истек.
+const deletionPurgeInterval = 10 * time.Minute
+
+// DeletionJobProvider представляет интерфейс для получения состояния задач удаления.
+type DeletionJobProvider interface {
+	// GetDeletionJob возвращает задачу удаления пользователя по идентификатору.
+	GetDeletionJob(ctx context.Context, userID string, jobID string) (*model.DeletionJob, error)
+}
 
 // QueueDeletionStrategy представляет реализацию стратегии удаления URL-адресов с использованием очереди.
+// Задачи удаления сохраняются в хранилище задач и выполняются фоновыми потоками, поэтому переживают перезапуск сервиса.
 type QueueDeletionStrategy struct {
 	// urlRepository представляет репозиторий URL-адресов.
 	urlRepository repository.URLRepository
+	// jobRepository представляет хранилище задач удаления.
+	jobRepository repository.DeletionJobRepository
 	// logger представляет логгер для записи сообщений.
 	logger zap.SugaredLogger
 	// workerNum представляет количество рабочих потоков для удаления URL-адресов.
 	workerNum int
-	// deleteQueue представляет очередь удаления URL-адресов.
-	deleteQueue chan deleteTask
+	// batchSize представляет максимальное количество задач, выполняемых за один проход.
+	batchSize int
+	// pollInterval представляет интервал проверки хранилища на наличие задач.
+	pollInterval time.Duration
+	// waitTimeout представляет время ожидания для одного прохода удаления URL-адресов.
+	waitTimeout time.Duration
+	// retention представляет срок хранения завершенных задач, в течение которого доступно их состояние.
+	retention time.Duration
+	// notify представляет канал для пробуждения рабочих потоков при появлении новой задачи.
+	notify chan struct{}
+	// stop представляет канал для остановки рабочих потоков.
+	stop chan struct{}
 	// workerWG представляет группу ожидания для рабочих потоков.
 	workerWG sync.WaitGroup
-	// waitTimeout представляет время ожидания для удаления URL-адресов.
-	waitTimeout time.Duration
-}
-
-// deleteTask представляет задачу удаления URL-адресов.
-type deleteTask struct {
-	// ctx представляет контекст задачи.
-	ctx context.Context
-	// shortIDs представляет список идентификаторов URL-адресов для удаления.
-	shortIDs []string
 }
 
 // NewQueueURLDeletionStrategy возвращает новый экземпляр QueueDeletionStrategy.
-// Эта функция принимает репозиторий URL-адресов, логгер, количество рабочих потоков, размер очереди и время ожидания.
+// Эта функция принимает репозиторий URL-адресов, хранилище задач удаления, логгер, количество рабочих потоков,
+// размер пакета задач, интервал проверки хранилища, время ожидания и срок хранения завершенных задач.
 func NewQueueURLDeletionStrategy(
 	repo repository.URLRepository,
+	jobRepo repository.DeletionJobRepository,
 	logger zap.SugaredLogger,
 	workerNum int,
-	taskNum int,
+	batchSize int,
+	pollInterval time.Duration,
 	waitTimeout time.Duration,
+	retention time.Duration,
 ) *QueueDeletionStrategy {
 	delStrategy := &QueueDeletionStrategy{
 		urlRepository: repo,
+		jobRepository: jobRepo,
 		logger:        logger,
 		workerNum:     workerNum,
-		deleteQueue:   make(chan deleteTask, taskNum),
+		batchSize:     batchSize,
+		pollInterval:  pollInterval,
 		waitTimeout:   waitTimeout,
+		retention:     retention,
+		notify:        make(chan struct{}, 1),
+		stop:          make(chan struct{}),
 	}
 	delStrategy.Start()
 	return delStrategy
 }
 
-// Start запускает рабочие потоки для удаления URL-адресов.
+// Start запускает рабочие потоки для удаления URL-адресов и фоновое удаление завершенных задач.
 func (s *QueueDeletionStrategy) Start() {
-	s.workerWG.Add(s.workerNum)
+	ctx, cancel := context.WithTimeout(context.Background(), s.waitTimeout)
+	defer cancel()
+	if count, err := s.jobRepository.CountUnfinishedJobs(ctx); err == nil {
+		metrics.DeletionQueueDepth.Set(float64(count))
+	}
+
+	s.workerWG.Add(s.workerNum + 1)
 	for workerID := 0; workerID < s.workerNum; workerID++ {
 		go s.worker()
 	}
+	go s.purger()
 }
 
 // Stop останавливает рабочие потоки для удаления URL-адресов.
+// Невыполненные задачи остаются в хранилище и будут выполнены после перезапуска.
 func (s *QueueDeletionStrategy) Stop() {
-	close(s.deleteQueue)
+	close(s.stop)
 	s.workerWG.Wait()
 }
 
-// DeleteURL добавляет задачу удаления URL-адресов в очередь.
-// Эта функция принимает контекст и список идентификаторов URL-адресов для удаления.
-func (s *QueueDeletionStrategy) DeleteURL(ctx context.Context, shortIDs []string) error {
+// DeleteURL сохраняет задачу удаления URL-адресов в хранилище и возвращает ее идентификатор.
+// Эта функция принимает контекст, идентификатор пользователя и список идентификаторов URL-адресов для удаления.
+// Контекст запроса используется только для сохранения задачи: задача выполняется независимо от его отмены.
+func (s *QueueDeletionStrategy) DeleteURL(ctx context.Context, userID string, shortIDs []string) (string, error) {
+	job := model.NewDeletionJob(uuid.NewString(), userID, shortIDs, time.Now().UTC())
+
+	if err := s.jobRepository.CreateJob(context.WithoutCancel(ctx), *job); err != nil {
+		s.logger.Errorf("failed to save deletion job: %v", err)
+		return "", err
+	}
+	metrics.DeletionQueueDepth.Inc()
+
 	select {
-	case s.deleteQueue <- deleteTask{ctx, shortIDs}:
-		return nil
+	case s.notify <- struct{}{}:
 	default:
-		s.logger.Error("delete queue is full")
-		return ErrDeleteQueueIsFull
 	}
+	return job.ID, nil
+}
+
+// GetDeletionJob возвращает задачу удаления пользователя по идентификатору.
+// Задачи других пользователей не раскрываются: для них возвращается repository.ErrRepoNotFound.
+func (s *QueueDeletionStrategy) GetDeletionJob(ctx context.Context, userID string, jobID string) (*model.DeletionJob, error) {
+	job, err := s.jobRepository.FindJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.UserID != userID {
+		return nil, repository.ErrRepoNotFound
+	}
+	return job, nil
 }
 
 // worker представляет рабочий поток для удаления URL-адресов.
 func (s *QueueDeletionStrategy) worker() {
 	defer s.workerWG.Done()
 
-	for task := range s.deleteQueue {
-		err := s.urlRepository.DeleteURL(task.ctx, task.shortIDs)
-		if err != nil {
-			s.logger.Error(err)
-			continue
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.notify:
+		case <-ticker.C:
+		}
+
+		// Пока задачи выбираются полными пакетами, в хранилище могут оставаться готовые задачи.
+		for s.processBatch() == s.batchSize {
+			select {
+			case <-s.stop:
+				return
+			default:
+			}
+		}
+	}
+}
+
+// purger представляет фоновый поток удаления завершенных задач, срок хранения которых истек.
+func (s *QueueDeletionStrategy) purger() {
+	defer s.workerWG.Done()
+
+	ticker := time.NewTicker(deletionPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.PurgeFinishedJobs()
+		}
+	}
+}
+
+// PurgeFinishedJobs удаляет из хранилища завершенные задачи, срок хранения которых истек.
+func (s *QueueDeletionStrategy) PurgeFinishedJobs() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.waitTimeout)
+	defer cancel()
+
+	purged, err := s.jobRepository.PurgeFinishedJobs(ctx, time.Now().UTC().Add(-s.retention))
+	if err != nil {
+		s.logger.Errorf("failed to purge finished deletion jobs: %v", err)
+		return
+	}
+	if purged > 0 {
+		s.logger.Infof("finished deletion jobs have been purged: %d", purged)
+	}
+}
+
+// processBatch выбирает пакет готовых задач и выполняет их, объединяя задачи одного пользователя в один запрос к репозиторию.
+// Эта функция возвращает количество выбранных задач.
+func (s *QueueDeletionStrategy) processBatch() int {
+	ctx, cancel := context.WithTimeout(context.Background(), s.waitTimeout)
+	defer cancel()
+
+	jobs, err := s.jobRepository.ClaimJobs(ctx, time.Now().UTC(), deletionLease, s.batchSize)
+	if err != nil {
+		s.logger.Errorf("failed to claim deletion jobs: %v", err)
+		return 0
+	}
+
+	userJobs := make(map[string][]model.DeletionJob)
+	for _, job := range jobs {
+		userJobs[job.UserID] = append(userJobs[job.UserID], job)
+	}
+
+	for userID, jobs := range userJobs {
+		s.processUserJobs(ctx, userID, jobs)
+	}
+	return len(jobs)
+}
+
+// processUserJobs удаляет URL-адреса из всех задач пользователя одним запросом к репозиторию и сохраняет результаты задач.
+func (s *QueueDeletionStrategy) processUserJobs(ctx context.Context, userID string, jobs []model.DeletionJob) {
+	var shortIDs []string
+	for _, job := range jobs {
+		shortIDs = append(shortIDs, job.ShortIDs...)
+	}
+	slices.Sort(shortIDs)
+	shortIDs = slices.Compact(shortIDs)
+
+	results, err := s.urlRepository.DeleteURL(ctx, userID, shortIDs)
+	if err != nil {
+		s.logger.Errorf("failed to delete urls of user %s: %v", userID, err)
+		for _, job := range jobs {
+			s.retryJob(ctx, job, err)
 		}
+		return
+	}
+
+	statuses := make(map[string]model.DeleteStatus, len(results))
+	for _, result := range results {
+		if result.Status == model.DeleteStatusNotOwned {
+			s.logger.Warnf("user %s tried to delete url %s owned by another user", userID, result.ShortID)
+		}
+		statuses[result.ShortID] = result.Status
+	}
+
+	for _, job := range jobs {
+		job.Results = make([]model.DeleteResult, 0, len(job.ShortIDs))
+		for _, id := range job.ShortIDs {
+			job.Results = append(job.Results, model.DeleteResult{ShortID: id, Status: statuses[id]})
+		}
+		job.Attempts++
+		job.Status = model.DeletionJobDone
+		job.Error = ""
+		s.finishJob(ctx, job)
+	}
+}
+
+// retryJob откладывает задачу для повторной попытки с экспоненциальной задержкой
+// или помечает ее невыполненной, если попытки исчерпаны.
+func (s *QueueDeletionStrategy) retryJob(ctx context.Context, job model.DeletionJob, cause error) {
+	now := time.Now().UTC()
+	job.Attempts++
+	job.Error = cause.Error()
+	job.UpdatedAt = now
+
+	if job.Attempts >= deletionMaxAttempts {
+		job.Status = model.DeletionJobFailed
+		metrics.DeletionJobsFailedTotal.Inc()
+		s.finishJob(ctx, job)
+		return
+	}
+
+	job.Status = model.DeletionJobPending
+	job.NextAttemptAt = now.Add(deletionRetryDelay << (job.Attempts - 1))
+	if err := s.jobRepository.UpdateJob(ctx, job); err != nil {
+		s.logger.Errorf("failed to update deletion job %s: %v", job.ID, err)
+	}
+}
+
+// finishJob сохраняет задачу в конечном состоянии.
+func (s *QueueDeletionStrategy) finishJob(ctx context.Context, job model.DeletionJob) {
+	job.UpdatedAt = time.Now().UTC()
+	if err := s.jobRepository.UpdateJob(ctx, job); err != nil {
+		// Задача останется в состоянии выполнения и будет повторена после истечения аренды.
+		s.logger.Errorf("failed to update deletion job %s: %v", job.ID, err)
+		return
 	}
+	metrics.DeletionQueueDepth.Dec()
 }
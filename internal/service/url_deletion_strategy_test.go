@@ -0,0 +1,109 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// waitDeletionJob ожидает перехода задачи удаления в конечное состояние.
+func waitDeletionJob(t *testing.T, strategy *service.QueueDeletionStrategy, userID string, jobID string) *model.DeletionJob {
+	t.Helper()
+
+	var job *model.DeletionJob
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = strategy.GetDeletionJob(context.Background(), userID, jobID)
+		require.NoError(t, err)
+		return job.Status == model.DeletionJobDone || job.Status == model.DeletionJobFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestQueueDeletionStrategy_DeleteURL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	logger := zaptest.NewLogger(t).Sugar()
+	urlRepo, err := repository.NewInMemoryURLRepository("", repository.DefaultJournalConfig(), *logger)
+	require.NoError(t, err)
+	jobRepo, err := repository.NewInMemoryDeletionJobRepository("", *logger)
+	require.NoError(t, err)
+
+	require.NoError(t, urlRepo.CreateURL(ctx, []model.URLItem{
+		*model.NewURLItem("https://one.com", "one", "owner", false),
+		*model.NewURLItem("https://two.com", "two", "stranger", false),
+	}))
+
+	strategy := service.NewQueueURLDeletionStrategy(urlRepo, jobRepo, *logger, 2, 10, 10*time.Millisecond, time.Second, time.Hour)
+	defer strategy.Stop()
+
+	jobID, err := strategy.DeleteURL(ctx, "owner", []string{"one", "two", "missing"})
+	require.NoError(t, err)
+
+	// Отмена контекста запроса не прерывает выполнение задачи.
+	cancel()
+
+	job := waitDeletionJob(t, strategy, "owner", jobID)
+	assert.Equal(t, model.DeletionJobDone, job.Status)
+	assert.Equal(t, []model.DeleteResult{
+		{ShortID: "one", Status: model.DeleteStatusDeleted},
+		{ShortID: "two", Status: model.DeleteStatusNotOwned},
+		{ShortID: "missing", Status: model.DeleteStatusNotFound},
+	}, job.Results)
+
+	_, err = strategy.GetDeletionJob(context.Background(), "stranger", jobID)
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+}
+
+func TestQueueDeletionStrategy_RetryOnFailure(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	urlRepo := new(repository.MockURLRepository)
+	jobRepo, err := repository.NewInMemoryDeletionJobRepository("", *logger)
+	require.NoError(t, err)
+
+	urlRepo.On("DeleteURL", mock.Anything, "owner", []string{"one"}).
+		Return([]model.DeleteResult(nil), errors.New("db is down")).Once()
+	urlRepo.On("DeleteURL", mock.Anything, "owner", []string{"one"}).
+		Return([]model.DeleteResult{{ShortID: "one", Status: model.DeleteStatusDeleted}}, nil).Once()
+
+	strategy := service.NewQueueURLDeletionStrategy(urlRepo, jobRepo, *logger, 1, 10, 10*time.Millisecond, time.Second, time.Hour)
+	defer strategy.Stop()
+
+	jobID, err := strategy.DeleteURL(ctx, "owner", []string{"one"})
+	require.NoError(t, err)
+
+	job := waitDeletionJob(t, strategy, "owner", jobID)
+	assert.Equal(t, model.DeletionJobDone, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+	urlRepo.AssertExpectations(t)
+}
+
+func TestQueueDeletionStrategy_PurgeFinishedJobs(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	urlRepo, err := repository.NewInMemoryURLRepository("", repository.DefaultJournalConfig(), *logger)
+	require.NoError(t, err)
+	jobRepo, err := repository.NewInMemoryDeletionJobRepository("", *logger)
+	require.NoError(t, err)
+
+	strategy := service.NewQueueURLDeletionStrategy(urlRepo, jobRepo, *logger, 1, 10, 10*time.Millisecond, time.Second, 0)
+	defer strategy.Stop()
+
+	jobID, err := strategy.DeleteURL(ctx, "owner", []string{"one"})
+	require.NoError(t, err)
+	waitDeletionJob(t, strategy, "owner", jobID)
+
+	strategy.PurgeFinishedJobs()
+
+	_, err = strategy.GetDeletionJob(ctx, "owner", jobID)
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+}
@@ -0,0 +1,110 @@
+// Package service содержит реализацию фоновой очистки URL-адресов с истекшим сроком действия.
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/oegegr/shortener/internal/repository"
+	"go.uber.org/zap"
+)
+
+// ExpiredURLSweeper представляет фоновый процесс, периодически удаляющий URL-адреса с истекшим сроком действия.
+// URL-адреса остаются в репозитории в течение срока хранения после истечения срока действия:
+// переход по ним возвращает ошибку ErrServiceURLExpired, а сокращенный идентификатор не может быть занят повторно.
+type ExpiredURLSweeper struct {
+	// urlRepository представляет репозиторий URL-адресов.
+	urlRepository repository.URLRepository
+	// clickRepository представляет репозиторий переходов по URL-адресам.
+	clickRepository repository.ClickRepository
+	// logger представляет логгер для записи сообщений.
+	logger zap.SugaredLogger
+	// interval представляет интервал между очистками.
+	interval time.Duration
+	// retention представляет срок хранения URL-адресов после истечения срока действия.
+	retention time.Duration
+	// stop представляет канал для остановки фонового процесса.
+	stop chan struct{}
+	// wg представляет группу ожидания для фонового процесса.
+	wg sync.WaitGroup
+}
+
+// NewExpiredURLSweeper возвращает новый экземпляр ExpiredURLSweeper и запускает фоновый процесс.
+// Эта функция принимает репозиторий URL-адресов, репозиторий переходов, логгер, интервал между очистками
+// и срок хранения URL-адресов после истечения срока действия.
+func NewExpiredURLSweeper(
+	repo repository.URLRepository,
+	clickRepo repository.ClickRepository,
+	logger zap.SugaredLogger,
+	interval time.Duration,
+	retention time.Duration,
+) *ExpiredURLSweeper {
+	sweeper := &ExpiredURLSweeper{
+		urlRepository:   repo,
+		clickRepository: clickRepo,
+		logger:          logger,
+		interval:        interval,
+		retention:       retention,
+		stop:            make(chan struct{}),
+	}
+	sweeper.Start()
+	return sweeper
+}
+
+// Start запускает фоновый процесс очистки.
+func (s *ExpiredURLSweeper) Start() {
+	s.wg.Add(1)
+	go s.run()
+}
+
+// Stop останавливает фоновый процесс очистки и дожидается его завершения.
+func (s *ExpiredURLSweeper) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// Sweep удаляет URL-адреса, срок хранения которых после истечения срока действия прошел, вместе с переходами по ним.
+// Переходы удаляются первыми, чтобы при ошибке URL-адреса остались до следующей очистки, а не переходы без URL-адресов.
+func (s *ExpiredURLSweeper) Sweep(ctx context.Context) {
+	before := time.Now().Add(-s.retention)
+	ids, err := s.urlRepository.FindExpiredURL(ctx, before)
+	if err != nil {
+		s.logger.Errorf("failed to find expired urls: %v", err)
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	if err := s.clickRepository.DeleteClicks(ctx, ids); err != nil {
+		s.logger.Errorf("failed to delete clicks of expired urls: %v", err)
+		return
+	}
+
+	deleted, err := s.urlRepository.PurgeExpiredURL(ctx, ids, before)
+	if err != nil {
+		s.logger.Errorf("failed to sweep expired urls: %v", err)
+		return
+	}
+	if deleted > 0 {
+		s.logger.Infof("expired urls have been swept: %d", deleted)
+	}
+}
+
+// run представляет цикл фонового процесса очистки.
+func (s *ExpiredURLSweeper) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Sweep(context.Background())
+		case <-s.stop:
+			return
+		}
+	}
+}
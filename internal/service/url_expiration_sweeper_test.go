@@ -0,0 +1,53 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestExpiredURLSweeper_Sweep(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t).Sugar()
+	urlRepo, err := repository.NewInMemoryURLRepository("", repository.DefaultJournalConfig(), *logger)
+	require.NoError(t, err)
+	clickRepo := repository.NewInMemoryClickRepository()
+
+	now := time.Now()
+	recentlyExpired := now.Add(-time.Minute)
+	longExpired := now.Add(-48 * time.Hour)
+
+	recent := *model.NewURLItem("https://recent.com", "recent", "user", false)
+	recent.ExpiresAt = &recentlyExpired
+	old := *model.NewURLItem("https://old.com", "old", "user", false)
+	old.ExpiresAt = &longExpired
+	require.NoError(t, urlRepo.CreateURL(ctx, []model.URLItem{recent, old}))
+	require.NoError(t, clickRepo.SaveClicks(ctx, []model.Click{
+		{ShortID: "recent", TS: longExpired},
+		{ShortID: "old", TS: longExpired},
+	}))
+
+	sweeper := service.NewExpiredURLSweeper(urlRepo, clickRepo, *logger, time.Hour, 24*time.Hour)
+	defer sweeper.Stop()
+	sweeper.Sweep(ctx)
+
+	item, err := urlRepo.FindURLByID(ctx, "recent")
+	require.NoError(t, err)
+	assert.True(t, item.IsExpired(now))
+	stats, err := clickRepo.GetClickStats(ctx, "recent")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Total)
+
+	_, err = urlRepo.FindURLByID(ctx, "old")
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+	stats, err = clickRepo.GetClickStats(ctx, "old")
+	require.NoError(t, err)
+	assert.Zero(t, stats.Total)
+}
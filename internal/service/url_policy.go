@@ -0,0 +1,268 @@
+// Package service содержит реализацию политик проверки сокращаемых URL-адресов.
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+)
+
+// Названия правил политики проверки URL-адресов, возвращаемые клиенту.
+const (
+	URLRuleSyntax        = "syntax"
+	URLRuleScheme        = "scheme"
+	URLRuleMaxLength     = "max_length"
+	URLRulePrivateHost   = "private_host"
+	URLRuleBlocklist     = "blocklist"
+	URLRuleSelfReference = "self_reference"
+	URLRuleUnresolvable  = "unresolvable_host"
+)
+
+// URLPolicyError представляет ошибку проверки URL-адреса с указанием сработавшего правила.
+type URLPolicyError struct {
+	// Rule представляет название сработавшего правила.
+	Rule string
+	// URL представляет отклоненный URL-адрес.
+	URL string
+	// Reason представляет описание причины отклонения.
+	Reason string
+}
+
+// Error возвращает текст ошибки.
+func (e *URLPolicyError) Error() string {
+	return fmt.Sprintf("%s: %s", app_error.ErrServiceURLRejected, e.Reason)
+}
+
+// Unwrap возвращает app_error.ErrServiceURLRejected, чтобы ошибку можно было проверить через errors.Is.
+func (e *URLPolicyError) Unwrap() error {
+	return app_error.ErrServiceURLRejected
+}
+
+// URLPolicy представляет интерфейс правила проверки URL-адреса.
+type URLPolicy interface {
+	// Check проверяет разобранный URL-адрес и возвращает *URLPolicyError, если адрес нарушает правило.
+	Check(ctx context.Context, rawURL string, u *url.URL) error
+}
+
+// URLValidator представляет интерфейс для проверки сокращаемых URL-адресов.
+type URLValidator interface {
+	// Validate проверяет URL-адрес и возвращает *URLPolicyError, если адрес отклонен.
+	Validate(ctx context.Context, rawURL string) error
+}
+
+// URLPolicyEngine представляет набор правил проверки URL-адресов, применяемых по порядку до первого нарушения.
+type URLPolicyEngine struct {
+	// policies представляет список правил.
+	policies []URLPolicy
+}
+
+// NewURLPolicyEngine возвращает новый экземпляр URLPolicyEngine.
+// Эта функция принимает список правил; пустой список пропускает любой корректный URL-адрес.
+func NewURLPolicyEngine(policies ...URLPolicy) *URLPolicyEngine {
+	return &URLPolicyEngine{policies: policies}
+}
+
+// Validate разбирает URL-адрес и проверяет его всеми правилами.
+func (e *URLPolicyEngine) Validate(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return &URLPolicyError{Rule: URLRuleSyntax, URL: rawURL, Reason: "url cannot be parsed"}
+	}
+
+	for _, policy := range e.policies {
+		if err := policy.Check(ctx, rawURL, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop останавливает фоновые задачи правил, например перезагрузку черного списка.
+func (e *URLPolicyEngine) Stop() {
+	for _, policy := range e.policies {
+		if stopper, ok := policy.(interface{ Stop() }); ok {
+			stopper.Stop()
+		}
+	}
+}
+
+// SchemePolicy представляет правило, разрешающее только заданные схемы URL-адресов.
+type SchemePolicy struct {
+	// allowed представляет множество разрешенных схем в нижнем регистре.
+	allowed map[string]struct{}
+}
+
+// NewSchemePolicy возвращает новый экземпляр SchemePolicy.
+// Эта функция принимает список разрешенных схем, например http и https.
+func NewSchemePolicy(schemes []string) *SchemePolicy {
+	allowed := make(map[string]struct{}, len(schemes))
+	for _, scheme := range schemes {
+		if scheme = strings.ToLower(strings.TrimSpace(scheme)); scheme != "" {
+			allowed[scheme] = struct{}{}
+		}
+	}
+	return &SchemePolicy{allowed: allowed}
+}
+
+// Check проверяет, что схема URL-адреса разрешена и адрес содержит хост.
+func (p *SchemePolicy) Check(ctx context.Context, rawURL string, u *url.URL) error {
+	if _, ok := p.allowed[strings.ToLower(u.Scheme)]; !ok {
+		return &URLPolicyError{Rule: URLRuleScheme, URL: rawURL, Reason: fmt.Sprintf("scheme %q is not allowed", u.Scheme)}
+	}
+	if u.Hostname() == "" {
+		return &URLPolicyError{Rule: URLRuleScheme, URL: rawURL, Reason: "url has no host"}
+	}
+	return nil
+}
+
+// MaxLengthPolicy представляет правило, ограничивающее длину URL-адреса.
+type MaxLengthPolicy struct {
+	// maxLength представляет максимальную длину URL-адреса в байтах.
+	maxLength int
+}
+
+// NewMaxLengthPolicy возвращает новый экземпляр MaxLengthPolicy.
+// Эта функция принимает максимальную длину URL-адреса в байтах.
+func NewMaxLengthPolicy(maxLength int) *MaxLengthPolicy {
+	return &MaxLengthPolicy{maxLength: maxLength}
+}
+
+// Check проверяет, что длина URL-адреса не превышает максимальную.
+func (p *MaxLengthPolicy) Check(ctx context.Context, rawURL string, u *url.URL) error {
+	if len(rawURL) > p.maxLength {
+		return &URLPolicyError{Rule: URLRuleMaxLength, URL: rawURL, Reason: fmt.Sprintf("url is longer than %d bytes", p.maxLength)}
+	}
+	return nil
+}
+
+// HostResolver представляет интерфейс для получения IP-адресов хоста; ему соответствует *net.Resolver.
+type HostResolver interface {
+	// LookupIPAddr возвращает IP-адреса хоста.
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// PrivateHostPolicy представляет правило, запрещающее URL-адреса, указывающие на локальные и внутренние сети.
+// Проверяются IP-адреса в URL-адресе, включая десятичную, восьмеричную и шестнадцатеричную запись IPv4, и имя localhost.
+// Если задан resolver, доменные имена разрешаются и проверяются их IP-адреса; URL-адрес, хост которого не удалось разрешить, отклоняется,
+// чтобы имя, недоступное при проверке, не указало на внутренний адрес при переходе.
+type PrivateHostPolicy struct {
+	// resolver представляет резолвер доменных имен; nil отключает разрешение имен.
+	resolver HostResolver
+	// timeout представляет время ожидания разрешения имени.
+	timeout time.Duration
+}
+
+// NewPrivateHostPolicy возвращает новый экземпляр PrivateHostPolicy.
+// Эта функция принимает резолвер доменных имен (может быть nil) и время ожидания разрешения имени.
+func NewPrivateHostPolicy(resolver HostResolver, timeout time.Duration) *PrivateHostPolicy {
+	return &PrivateHostPolicy{resolver: resolver, timeout: timeout}
+}
+
+// Check проверяет, что хост URL-адреса не является локальным или внутренним.
+func (p *PrivateHostPolicy) Check(ctx context.Context, rawURL string, u *url.URL) error {
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return &URLPolicyError{Rule: URLRulePrivateHost, URL: rawURL, Reason: "url points to localhost"}
+	}
+
+	if ip := parseHostIP(host); ip != nil {
+		if isInternalIP(ip) {
+			return &URLPolicyError{Rule: URLRulePrivateHost, URL: rawURL, Reason: fmt.Sprintf("url points to internal address %s", ip)}
+		}
+		return nil
+	}
+
+	if p.resolver == nil {
+		return nil
+	}
+
+	resolveCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	addrs, err := p.resolver.LookupIPAddr(resolveCtx, host)
+	if err != nil || len(addrs) == 0 {
+		return &URLPolicyError{Rule: URLRuleUnresolvable, URL: rawURL, Reason: fmt.Sprintf("host %s cannot be resolved", host)}
+	}
+	for _, addr := range addrs {
+		if isInternalIP(addr.IP) {
+			return &URLPolicyError{Rule: URLRulePrivateHost, URL: rawURL, Reason: fmt.Sprintf("host %s resolves to internal address %s", host, addr.IP)}
+		}
+	}
+	return nil
+}
+
+// parseHostIP возвращает IP-адрес, записанный в хосте, или nil, если хост является доменным именем.
+// Помимо стандартной записи поддерживается запись IPv4, которую понимают браузеры: 2130706433, 0x7f.1, 0177.0.0.1.
+func parseHostIP(host string) net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip
+	}
+
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return nil
+	}
+
+	var value uint64
+	for idx, part := range parts {
+		number, err := strconv.ParseUint(part, 0, 32)
+		if err != nil {
+			return nil
+		}
+		if idx < len(parts)-1 {
+			if number > 0xff {
+				return nil
+			}
+			value = value<<8 | number
+			continue
+		}
+
+		// Последняя часть заполняет все оставшиеся байты адреса.
+		rest := uint(4 - idx)
+		if number >= 1<<(8*rest) {
+			return nil
+		}
+		value = value<<(8*rest) | number
+	}
+	return net.IPv4(byte(value>>24), byte(value>>16), byte(value>>8), byte(value))
+}
+
+// isInternalIP возвращает true для loopback, частных, link-local и неопределенных адресов.
+func isInternalIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsUnspecified()
+}
+
+// SelfReferencePolicy представляет правило, запрещающее URL-адреса, указывающие на сам сервис сокращения,
+// чтобы сокращенные ссылки не перенаправляли друг на друга по кругу.
+type SelfReferencePolicy struct {
+	// host представляет имя хоста сервиса в нижнем регистре.
+	host string
+}
+
+// NewSelfReferencePolicy возвращает новый экземпляр SelfReferencePolicy.
+// Эта функция принимает базовый URL-адрес сокращенных ссылок.
+func NewSelfReferencePolicy(baseURL string) (*SelfReferencePolicy, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid base url %q", baseURL)
+	}
+	return &SelfReferencePolicy{host: strings.ToLower(u.Hostname())}, nil
+}
+
+// Check проверяет, что URL-адрес не указывает на хост сервиса.
+func (p *SelfReferencePolicy) Check(ctx context.Context, rawURL string, u *url.URL) error {
+	if strings.TrimSuffix(strings.ToLower(u.Hostname()), ".") == p.host {
+		return &URLPolicyError{Rule: URLRuleSelfReference, URL: rawURL, Reason: "url points back to the shortener"}
+	}
+	return nil
+}
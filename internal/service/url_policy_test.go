@@ -0,0 +1,125 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// staticResolver представляет резолвер доменных имен с заданными адресами.
+type staticResolver map[string][]net.IPAddr
+
+// LookupIPAddr возвращает заданные адреса хоста.
+func (r staticResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	addrs, ok := r[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return addrs, nil
+}
+
+func TestURLPolicyEngine_Validate(t *testing.T) {
+	selfPolicy, err := service.NewSelfReferencePolicy("http://short.com:8080")
+	require.NoError(t, err)
+	resolver := staticResolver{
+		"example.com":          {{IP: net.ParseIP("93.184.216.34")}},
+		"internal.example.com": {{IP: net.ParseIP("10.1.2.3")}},
+		"short.com":            {{IP: net.ParseIP("93.184.216.35")}},
+	}
+
+	engine := service.NewURLPolicyEngine(
+		service.NewSchemePolicy([]string{"http", "https"}),
+		service.NewMaxLengthPolicy(64),
+		service.NewPrivateHostPolicy(resolver, time.Second),
+		selfPolicy,
+	)
+
+	tests := []struct {
+		name string
+		url  string
+		rule string
+	}{
+		{name: "valid url", url: "https://example.com/path?q=1"},
+		{name: "unresolvable host", url: "https://unknown.example.com", rule: service.URLRuleUnresolvable},
+		{name: "javascript scheme", url: "javascript:alert(1)", rule: service.URLRuleScheme},
+		{name: "file scheme", url: "file:///etc/passwd", rule: service.URLRuleScheme},
+		{name: "no host", url: "http:///path", rule: service.URLRuleScheme},
+		{name: "too long", url: "https://example.com/" + strings.Repeat("a", 64), rule: service.URLRuleMaxLength},
+		{name: "metadata address", url: "http://169.254.169.254/latest/meta-data", rule: service.URLRulePrivateHost},
+		{name: "loopback", url: "http://127.0.0.1:8080", rule: service.URLRulePrivateHost},
+		{name: "decimal loopback", url: "http://2130706433/", rule: service.URLRulePrivateHost},
+		{name: "hex loopback", url: "http://0x7f.1/", rule: service.URLRulePrivateHost},
+		{name: "ipv6 loopback", url: "http://[::1]/", rule: service.URLRulePrivateHost},
+		{name: "private network", url: "http://192.168.0.1/admin", rule: service.URLRulePrivateHost},
+		{name: "localhost", url: "http://LocalHost./", rule: service.URLRulePrivateHost},
+		{name: "resolves to private", url: "http://internal.example.com", rule: service.URLRulePrivateHost},
+		{name: "self reference", url: "https://SHORT.com/abc", rule: service.URLRuleSelfReference},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.Validate(context.Background(), tt.url)
+			if tt.rule == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var policyErr *service.URLPolicyError
+			require.ErrorAs(t, err, &policyErr)
+			assert.Equal(t, tt.rule, policyErr.Rule)
+			assert.Equal(t, tt.url, policyErr.URL)
+			assert.ErrorIs(t, err, app_error.ErrServiceURLRejected)
+		})
+	}
+}
+
+func TestBlocklistPolicy_Reload(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# phishing\nevil.com\nregex:\\.exe$\n"), 0644))
+
+	blocklist, err := service.NewBlocklistPolicy(path, 10*time.Millisecond, *logger)
+	require.NoError(t, err)
+	defer blocklist.Stop()
+	engine := service.NewURLPolicyEngine(blocklist)
+
+	ctx := context.Background()
+	assert.ErrorIs(t, engine.Validate(ctx, "https://evil.com"), app_error.ErrServiceURLRejected)
+	assert.ErrorIs(t, engine.Validate(ctx, "https://login.Evil.com/form"), app_error.ErrServiceURLRejected)
+	assert.ErrorIs(t, engine.Validate(ctx, "https://files.com/setup.exe"), app_error.ErrServiceURLRejected)
+	assert.NoError(t, engine.Validate(ctx, "https://notevil.com"))
+	assert.NoError(t, engine.Validate(ctx, "https://bad.org"))
+
+	require.NoError(t, os.WriteFile(path, []byte("bad.org\n"), 0644))
+	assert.Eventually(t, func() bool {
+		return engine.Validate(ctx, "https://bad.org") != nil && engine.Validate(ctx, "https://evil.com") == nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// Ошибка разбора не заменяет действующий список.
+	require.NoError(t, os.WriteFile(path, []byte("regex:([\n"), 0644))
+	time.Sleep(50 * time.Millisecond)
+	assert.Error(t, engine.Validate(ctx, "https://bad.org"))
+}
+
+func TestBlocklistPolicy_InvalidFile(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+
+	_, err := service.NewBlocklistPolicy(filepath.Join(t.TempDir(), "missing.txt"), time.Second, *logger)
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("regex:([\n"), 0644))
+	_, err = service.NewBlocklistPolicy(path, time.Second, *logger)
+	assert.Error(t, err)
+}
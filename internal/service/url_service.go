@@ -5,85 +5,130 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
+	"unicode/utf8"
 
 	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/metrics"
 	"github.com/oegegr/shortener/internal/model"
 	"github.com/oegegr/shortener/internal/repository"
 
 	"github.com/avast/retry-go"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
-// maxCollisionAttempts представляет максимальное количество попыток для разрешения коллизий.
+// maxCollisionAttempts представляет максимальное количество попыток для разрешения коллизий;
+// если последняя попытка увеличила длину сокращенных идентификаторов, выполняется еще одна.
 const maxCollisionAttempts = 10
 
 // retryCollisionTimeout представляет время ожидания между попытками для разрешения коллизий.
 const retryCollisionTimeout = 100 * time.Millisecond
 
+// maxTitleLength представляет максимальную длину заголовка URL-адреса в символах.
+const maxTitleLength = 256
+
+// maxPasswordLength представляет максимальную длину пароля URL-адреса в байтах; bcrypt не учитывает более длинный ввод.
+const maxPasswordLength = 72
+
 // URLShortener представляет интерфейс для сервиса сокращения URL-адресов.
 type URLShortener interface {
-	// GetShortURL возвращает сокращенный URL-адрес для заданного URL-адреса и идентификатора пользователя.
-	GetShortURL(ctx context.Context, url string, userID string) (string, error)
-	// GetShortURLBatch возвращает список сокращенных URL-адресов для заданного списка URL-адресов и идентификатора пользователя.
-	GetShortURLBatch(ctx context.Context, urls []string, userID string) ([]string, error)
+	// GetShortURL возвращает сокращенный URL-адрес для заданных параметров и идентификатора пользователя.
+	GetShortURL(ctx context.Context, params model.ShortenParams, userID string) (string, error)
+	// GetShortURLBatch возвращает список сокращенных URL-адресов для заданного списка параметров и идентификатора пользователя.
+	GetShortURLBatch(ctx context.Context, params []model.ShortenParams, userID string) ([]string, error)
 	// GetOriginalURL возвращает оригинальный URL-адрес для заданного сокращенного URL-адреса.
 	GetOriginalURL(ctx context.Context, shortURL string) (string, error)
+	// GetURLItem возвращает действующий элемент URL-адреса для заданного сокращенного URL-адреса.
+	GetURLItem(ctx context.Context, shortURL string) (*model.URLItem, error)
+	// UnlockURL проверяет пароль защищенного URL-адреса и возвращает оригинальный URL-адрес.
+	// Эта функция принимает сокращенный URL-адрес, пароль и ключ клиента для учета неудачных попыток.
+	UnlockURL(ctx context.Context, shortURL string, password string, clientKey string) (string, error)
 	// GetUserURL возвращает список URL-адресов для заданного идентификатора пользователя.
 	GetUserURL(ctx context.Context, userID string) ([]model.UserURL, error)
-	// DeleteUserURL удаляет URL-адреса для заданного идентификатора пользователя и списка сокращенных URL-адресов.
-	DeleteUserURL(ctx context.Context, userID string, shortIDs []string) error
+	// GetUserURLPage возвращает страницу URL-адресов пользователя и курсор следующей страницы;
+	// пустой курсор означает последнюю страницу.
+	GetUserURLPage(ctx context.Context, userID string, query model.UserURLQuery) ([]model.UserURL, string, error)
+	// DeleteUserURL ставит в очередь удаление URL-адресов для заданного идентификатора пользователя и списка сокращенных URL-адресов
+	// и возвращает идентификатор задачи удаления.
+	DeleteUserURL(ctx context.Context, userID string, shortIDs []string) (string, error)
+	// UpdateURL изменяет оригинальный URL-адрес сокращенного URL-адреса, принадлежащего пользователю,
+	// и возвращает сокращенный URL-адрес и запись об изменении.
+	UpdateURL(ctx context.Context, userID string, shortID string, newURL string) (string, *model.URLEdit, error)
+	// GetURLEdits возвращает историю изменений сокращенного URL-адреса, принадлежащего пользователю.
+	GetURLEdits(ctx context.Context, userID string, shortID string) ([]model.URLEdit, error)
+	// SetURLTags заменяет метки сокращенного URL-адреса, принадлежащего пользователю, и возвращает нормализованные метки.
+	SetURLTags(ctx context.Context, userID string, shortID string, tags []string) ([]string, error)
+	// GetUserTags возвращает метки пользователя с количеством URL-адресов.
+	GetUserTags(ctx context.Context, userID string) ([]model.TagCount, error)
+}
+
+// QRCodeProvider представляет интерфейс для получения QR-кодов сокращенных URL-адресов.
+type QRCodeProvider interface {
+	// GetQRContent возвращает содержимое QR-кода — сокращенный URL-адрес для заданного сокращенного кода.
+	GetQRContent(ctx context.Context, shortCode string) (string, error)
 }
 
 // URLDeletionStrategy представляет интерфейс для стратегии удаления URL-адресов.
 type URLDeletionStrategy interface {
-	// DeleteURL удаляет URL-адреса для заданного контекста и списка сокращенных URL-адресов.
-	DeleteURL(ctx context.Context, shortIDs []string) error
+	// DeleteURL ставит в очередь удаление URL-адресов пользователя для заданного контекста и списка сокращенных URL-адресов
+	// и возвращает идентификатор задачи удаления.
+	DeleteURL(ctx context.Context, userID string, shortIDs []string) (string, error)
 }
 
 // ShortenURLService представляет реализацию сервиса сокращения URL-адресов.
 type ShortenURLService struct {
 	// urlRepository представляет репозиторий URL-адресов.
 	urlRepository repository.URLRepository
-	// shortURLDomain представляет домен для сокращенных URL-адресов.
-	shortURLDomain string
-	// shortURLLength представляет длину сокращенного URL-адреса.
-	shortURLLength int
+	// domains представляет реестр доменов сокращенных URL-адресов.
+	domains *DomainRegistry
+	// codeLength представляет эффективную длину генерируемых сокращенных идентификаторов.
+	codeLength *ShortCodeLength
 	// shortCodeProvider представляет провайдер сокращенных кодов.
 	shortCodeProvider ShortCodeProvider
 	// urlDelStrategy представляет стратегию удаления URL-адресов.
 	urlDelStrategy URLDeletionStrategy
+	// urlValidator представляет проверку сокращаемых URL-адресов.
+	urlValidator URLValidator
+	// passwordLimiter представляет учет неудачных попыток ввода пароля защищенных URL-адресов.
+	passwordLimiter PasswordAttemptLimiter
 	// logger представляет логгер для записи сообщений.
 	logger zap.SugaredLogger
 }
 
 // NewShortenerService возвращает новый экземпляр ShortenURLService.
-// Эта функция принимает репозиторий URL-адресов, домен для сокращенных URL-адресов, длину сокращенного URL-адреса, провайдер сокращенных кодов, стратегию удаления URL-адресов и логгер.
+// Эта функция принимает репозиторий URL-адресов, реестр доменов сокращенных URL-адресов, длину сокращенных идентификаторов, провайдер сокращенных кодов, стратегию удаления URL-адресов,
+// проверку сокращаемых URL-адресов, учет неудачных попыток ввода пароля и логгер.
 func NewShortenerService(
 	repository repository.URLRepository,
-	domain string,
-	urlLength int,
+	domains *DomainRegistry,
+	codeLength *ShortCodeLength,
 	codeProvider ShortCodeProvider,
 	urlDelStrategy URLDeletionStrategy,
+	urlValidator URLValidator,
+	passwordLimiter PasswordAttemptLimiter,
 	logger zap.SugaredLogger,
 ) *ShortenURLService {
 	return &ShortenURLService{
 		urlRepository:     repository,
-		shortURLDomain:    domain,
-		shortURLLength:    urlLength,
+		domains:           domains,
+		codeLength:        codeLength,
 		shortCodeProvider: codeProvider,
 		urlDelStrategy:    urlDelStrategy,
+		urlValidator:      urlValidator,
+		passwordLimiter:   passwordLimiter,
 		logger:            logger,
 	}
 }
 
-// GetShortURL возвращает сокращенный URL-адрес для заданного URL-адреса и идентификатора пользователя.
-func (s *ShortenURLService) GetShortURL(ctx context.Context, url string, userID string) (string, error) {
-	items, err := s.tryGetURLItem(ctx, []string{url}, userID)
+// GetShortURL возвращает сокращенный URL-адрес для заданных параметров и идентификатора пользователя.
+func (s *ShortenURLService) GetShortURL(ctx context.Context, params model.ShortenParams, userID string) (string, error) {
+	items, err := s.tryGetURLItem(ctx, []model.ShortenParams{params}, userID)
 
 	if err != nil {
 		if errors.Is(err, repository.ErrRepoURLAlreadyExists) {
-			return s.resolveURLConflict(ctx, url, err)
+			return s.resolveURLConflict(ctx, params, userID, err)
 		}
 		return "", err
 	}
@@ -91,13 +136,14 @@ func (s *ShortenURLService) GetShortURL(ctx context.Context, url string, userID
 	return s.buildShortURL(items[0]), nil
 }
 
-// DeleteUserURL удаляет URL-адреса для заданного идентификатора пользователя и списка сокращенных URL-адресов.
-func (s *ShortenURLService) DeleteUserURL(ctx context.Context, userID string, shortIDs []string) error {
-	err := s.urlDelStrategy.DeleteURL(ctx, shortIDs)
+// DeleteUserURL ставит в очередь удаление URL-адресов для заданного идентификатора пользователя и списка сокращенных URL-адресов
+// и возвращает идентификатор задачи удаления.
+func (s *ShortenURLService) DeleteUserURL(ctx context.Context, userID string, shortIDs []string) (string, error) {
+	jobID, err := s.urlDelStrategy.DeleteURL(ctx, userID, shortIDs)
 	if err != nil {
-		return err
+		return "", err
 	}
-	return nil
+	return jobID, nil
 }
 
 // GetUserURL возвращает список URL-адресов для заданного идентификатора пользователя.
@@ -108,28 +154,127 @@ func (s *ShortenURLService) GetUserURL(ctx context.Context, userID string) ([]mo
 	}
 	urls := []model.UserURL{}
 	for _, item := range items {
-		userURL := model.UserURL{
-			URL:      item.URL,
-			ShortURL: s.buildShortURL(item),
-		}
-		urls = append(urls, userURL)
+		urls = append(urls, s.newUserURL(item))
 	}
 	return urls, nil
 }
 
-// resolveURLConflict разрешает конфликт URL-адресов, возвращая сокращенный URL-адрес для заданного URL-адреса и ошибки.
-func (s *ShortenURLService) resolveURLConflict(ctx context.Context, url string, urlConflict error) (string, error) {
-	item, err := s.urlRepository.FindURLByURL(ctx, url)
+// GetUserURLPage возвращает страницу URL-адресов пользователя и курсор следующей страницы;
+// пустой курсор означает последнюю страницу.
+func (s *ShortenURLService) GetUserURLPage(ctx context.Context, userID string, query model.UserURLQuery) ([]model.UserURL, string, error) {
+	page, err := s.urlRepository.FindURLPageByUser(ctx, userID, query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	urls := make([]model.UserURL, 0, len(page.Items))
+	for _, item := range page.Items {
+		urls = append(urls, s.newUserURL(item))
+	}
+
+	var next string
+	if page.Next != nil {
+		next = page.Next.Encode()
+	}
+	return urls, next, nil
+}
+
+// UpdateURL изменяет оригинальный URL-адрес сокращенного URL-адреса, принадлежащего пользователю,
+// и возвращает сокращенный URL-адрес и запись об изменении с прежним оригинальным URL-адресом.
+// Новый URL-адрес проходит ту же проверку, что и при сокращении. Эта функция возвращает repository.ErrRepoNotFound,
+// если URL-адрес не найден или удален, app_error.ErrServiceURLNotOwned, если он принадлежит другому пользователю,
+// app_error.ErrServiceURLExpired, если срок его действия истек, и repository.ErrRepoURLAlreadyExists, если новый URL-адрес уже сокращен.
+func (s *ShortenURLService) UpdateURL(ctx context.Context, userID string, shortID string, newURL string) (string, *model.URLEdit, error) {
+	if err := s.urlValidator.Validate(ctx, newURL); err != nil {
+		return "", nil, err
+	}
+
+	edit := model.URLEdit{
+		ShortID:  shortID,
+		UserID:   userID,
+		NewURL:   newURL,
+		EditedAt: time.Now().UTC(),
+	}
+	updated, err := s.urlRepository.UpdateURL(ctx, edit)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRepoNotOwned):
+			return "", nil, app_error.ErrServiceURLNotOwned
+		case errors.Is(err, repository.ErrRepoURLExpired):
+			return "", nil, app_error.ErrServiceURLExpired
+		}
+		return "", nil, err
+	}
+
+	return s.buildShortURL(model.URLItem{ShortID: shortID}), updated, nil
+}
+
+// GetURLEdits возвращает историю изменений сокращенного URL-адреса, принадлежащего пользователю.
+// Эта функция возвращает repository.ErrRepoNotFound, если URL-адрес не найден или удален,
+// и app_error.ErrServiceURLNotOwned, если URL-адрес принадлежит другому пользователю.
+func (s *ShortenURLService) GetURLEdits(ctx context.Context, userID string, shortID string) ([]model.URLEdit, error) {
+	item, err := s.urlRepository.FindURLByID(ctx, shortID)
+	if err != nil {
+		return nil, err
+	}
+
+	if item == nil || item.IsDeleted {
+		return nil, repository.ErrRepoNotFound
+	}
+
+	if item.UserID != userID {
+		return nil, app_error.ErrServiceURLNotOwned
+	}
+
+	return s.urlRepository.FindURLEdits(ctx, shortID)
+}
+
+// SetURLTags заменяет метки сокращенного URL-адреса, принадлежащего пользователю, и возвращает нормализованные метки.
+// Эта функция возвращает app_error.ErrServiceInvalidTag для недопустимых меток, repository.ErrRepoNotFound,
+// если URL-адрес не найден или удален, и app_error.ErrServiceURLNotOwned, если он принадлежит другому пользователю.
+func (s *ShortenURLService) SetURLTags(ctx context.Context, userID string, shortID string, tags []string) ([]string, error) {
+	normalized, err := NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.urlRepository.SetTags(ctx, userID, shortID, normalized, time.Now().UTC()); err != nil {
+		if errors.Is(err, repository.ErrRepoNotOwned) {
+			return nil, app_error.ErrServiceURLNotOwned
+		}
+		return nil, err
+	}
+
+	return normalized, nil
+}
+
+// GetUserTags возвращает метки пользователя с количеством не удаленных URL-адресов.
+func (s *ShortenURLService) GetUserTags(ctx context.Context, userID string) ([]model.TagCount, error) {
+	return s.urlRepository.CountTagsByUser(ctx, userID)
+}
+
+// resolveURLConflict разрешает конфликт URL-адресов, возвращая сокращенный URL-адрес, уже выданный для URL-адреса в домене параметров, и ошибку конфликта.
+// Если запрошен пользовательский идентификатор, а URL-адрес уже сокращен под другим идентификатором, возвращается ErrServiceURLAlreadyShortened.
+func (s *ShortenURLService) resolveURLConflict(ctx context.Context, params model.ShortenParams, userID string, urlConflict error) (string, error) {
+	domain, err := s.domains.Resolve(params.Domain, userID)
 	if err != nil {
 		return "", err
 	}
+
+	item, err := s.urlRepository.FindURLByURL(ctx, domain, params.URL)
+	if err != nil {
+		return "", err
+	}
+	if params.Alias != "" && item.ShortID != params.Alias {
+		return "", app_error.ErrServiceURLAlreadyShortened
+	}
 	return s.buildShortURL(*item), urlConflict
 }
 
-// GetShortURLBatch возвращает список сокращенных URL-адресов для заданного списка URL-адресов и идентификатора пользователя.
-func (s *ShortenURLService) GetShortURLBatch(ctx context.Context, urls []string, userID string) ([]string, error) {
+// GetShortURLBatch возвращает список сокращенных URL-адресов для заданного списка параметров и идентификатора пользователя.
+func (s *ShortenURLService) GetShortURLBatch(ctx context.Context, params []model.ShortenParams, userID string) ([]string, error) {
 	shorts := []string{}
-	items, err := s.tryGetURLItem(ctx, urls, userID)
+	items, err := s.tryGetURLItem(ctx, params, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -140,26 +285,113 @@ func (s *ShortenURLService) GetShortURLBatch(ctx context.Context, urls []string,
 }
 
 // GetOriginalURL возвращает оригинальный URL-адрес для заданного сокращенного URL-адреса.
+// Для защищенных паролем URL-адресов возвращается app_error.ErrServiceURLProtected: их можно открыть только через UnlockURL.
 func (s *ShortenURLService) GetOriginalURL(ctx context.Context, shortCode string) (string, error) {
-	urlItem, err := s.urlRepository.FindURLByID(ctx, shortCode)
+	urlItem, err := s.findActiveURLItem(ctx, shortCode)
+	if err != nil {
+		return "", err
+	}
+
+	if urlItem.IsProtected() {
+		return "", app_error.ErrServiceURLProtected
+	}
+
+	return urlItem.URL, nil
+}
 
+// UnlockURL проверяет пароль защищенного URL-адреса и возвращает оригинальный URL-адрес.
+// После нескольких неудачных попыток клиент блокируется, и возвращается *LockoutError даже для верного пароля.
+// Попытки учитываются отдельно для каждого URL-адреса, чтобы блокировка клиента на одном URL-адресе не затрагивала другие.
+func (s *ShortenURLService) UnlockURL(ctx context.Context, shortCode string, password string, clientKey string) (string, error) {
+	urlItem, err := s.findActiveURLItem(ctx, shortCode)
 	if err != nil {
 		return "", err
 	}
 
-	if urlItem.IsDeleted {
-		return "", app_error.ErrServiceURLGone
+	if !urlItem.IsProtected() {
+		return urlItem.URL, nil
+	}
+
+	attemptKey := shortCode + " " + clientKey
+	if err := s.passwordLimiter.Acquire(attemptKey); err != nil {
+		return "", err
 	}
 
+	if err := bcrypt.CompareHashAndPassword([]byte(urlItem.PasswordHash), []byte(password)); err != nil {
+		s.passwordLimiter.Fail(attemptKey)
+		s.logger.Warnf("wrong password for url %s from %s", shortCode, clientKey)
+		return "", app_error.ErrServiceWrongPassword
+	}
+
+	s.passwordLimiter.Reset(attemptKey)
 	return urlItem.URL, nil
 }
 
-// getURLItem возвращает список элементов URL-адресов для заданного списка URL-адресов и идентификатора пользователя.
-func (s *ShortenURLService) getURLItem(ctx context.Context, originalURL []string, userID string) ([]model.URLItem, error) {
+// GetURLItem возвращает действующий элемент URL-адреса для заданного сокращенного URL-адреса.
+// Для удаленных и истекших URL-адресов возвращаются те же ошибки, что и в GetOriginalURL.
+func (s *ShortenURLService) GetURLItem(ctx context.Context, shortCode string) (*model.URLItem, error) {
+	return s.findActiveURLItem(ctx, shortCode)
+}
+
+// GetQRContent возвращает содержимое QR-кода — сокращенный URL-адрес для заданного сокращенного кода.
+// QR-код строится только для действующих URL-адресов: для удаленных и истекших возвращаются те же ошибки, что и при переходе.
+func (s *ShortenURLService) GetQRContent(ctx context.Context, shortCode string) (string, error) {
+	urlItem, err := s.findActiveURLItem(ctx, shortCode)
+	if err != nil {
+		return "", err
+	}
+
+	return s.buildShortURL(*urlItem), nil
+}
+
+// findActiveURLItem возвращает элемент URL-адреса по сокращенному коду, если он не удален и не истек.
+func (s *ShortenURLService) findActiveURLItem(ctx context.Context, shortCode string) (*model.URLItem, error) {
+	urlItem, err := s.urlRepository.FindURLByID(ctx, shortCode)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if urlItem == nil {
+		return nil, repository.ErrRepoNotFound
+	}
+
+	if urlItem.IsDeleted {
+		return nil, app_error.ErrServiceURLGone
+	}
+
+	if urlItem.IsExpired(time.Now()) {
+		return nil, app_error.ErrServiceURLExpired
+	}
+
+	return urlItem, nil
+}
+
+// getURLItem возвращает список элементов URL-адресов для заданного списка параметров и идентификатора пользователя.
+// Эта функция принимает хэши паролей в порядке параметров и номер попытки для провайдера сокращенных кодов.
+func (s *ShortenURLService) getURLItem(ctx context.Context, params []model.ShortenParams, passwordHashes []string, userID string, attempt int) ([]model.URLItem, error) {
 	items := []model.URLItem{}
-	for _, url := range originalURL {
-		shortCode := s.shortCodeProvider.Get(s.shortURLLength)
-		item := model.NewURLItem(url, shortCode, userID, false)
+	now := time.Now().UTC()
+	length := s.codeLength.Get()
+	for idx, param := range params {
+		item := model.NewURLItem(param.URL, model.ShortKey(param.Domain, param.Alias), userID, false)
+		item.Domain = param.Domain
+		item.PasswordHash = passwordHashes[idx]
+		item.ExpiresAt = param.ExpiresAt
+		item.Title = param.Title
+		item.Preview = param.Preview
+		item.Tags = param.Tags
+		item.CreatedAt = now
+		item.UpdatedAt = now
+		if param.Alias != "" {
+			item.IsCustom = true
+		} else {
+			code, err := s.shortCodeProvider.Get(ctx, param.URL, length, attempt)
+			if err != nil {
+				return nil, err
+			}
+			item.ShortID = model.ShortKey(param.Domain, code)
+		}
 		items = append(items, *item)
 	}
 	err := s.urlRepository.CreateURL(ctx, items)
@@ -169,25 +401,74 @@ func (s *ShortenURLService) getURLItem(ctx context.Context, originalURL []string
 	return items, nil
 }
 
-// tryGetURLItem возвращает список элементов URL-адресов для заданного списка URL-адресов и идентификатора пользователя с повторными попытками в случае коллизий.
-func (s *ShortenURLService) tryGetURLItem(ctx context.Context, originalURL []string, userID string) ([]model.URLItem, error) {
+// tryGetURLItem возвращает список элементов URL-адресов для заданного списка параметров и идентификатора пользователя с повторными попытками в случае коллизий.
+// Конфликт пользовательского идентификатора не является коллизией и возвращается без повторных попыток.
+func (s *ShortenURLService) tryGetURLItem(ctx context.Context, params []model.ShortenParams, userID string) ([]model.URLItem, error) {
+	// Параметры копируются, чтобы нормализация меток не изменяла срез вызывающей стороны.
+	params = slices.Clone(params)
+	for idx, param := range params {
+		if err := s.urlValidator.Validate(ctx, param.URL); err != nil {
+			return nil, err
+		}
+		if utf8.RuneCountInString(param.Title) > maxTitleLength {
+			return nil, fmt.Errorf("%w: length must not exceed %d", app_error.ErrServiceInvalidTitle, maxTitleLength)
+		}
+		if len(param.Password) > maxPasswordLength {
+			return nil, fmt.Errorf("%w: length must not exceed %d bytes", app_error.ErrServiceInvalidPassword, maxPasswordLength)
+		}
+		tags, err := NormalizeTags(param.Tags)
+		if err != nil {
+			return nil, err
+		}
+		params[idx].Tags = tags
+		domain, err := s.domains.Resolve(param.Domain, userID)
+		if err != nil {
+			return nil, err
+		}
+		params[idx].Domain = domain
+		if param.Alias == "" {
+			continue
+		}
+		if err := ValidateAlias(param.Alias); err != nil {
+			return nil, err
+		}
+	}
+
+	// Пароли хэшируются до повторных попыток: bcrypt намеренно медленный.
+	passwordHashes, err := hashPasswords(params)
+	if err != nil {
+		return nil, err
+	}
+
+	generatesCodes := slices.ContainsFunc(params, func(param model.ShortenParams) bool { return param.Alias == "" })
+
 	var items []model.URLItem
-	err := retry.Do(
+	attempt := 0
+	grown := false
+	err = retry.Do(
 		func() error {
 			var err error
-			items, err = s.getURLItem(ctx, originalURL, userID)
+			items, err = s.getURLItem(ctx, params, passwordHashes, userID, attempt)
+			attempt++
+			if generatesCodes {
+				grown = s.observeCodeLength(ctx, errors.Is(err, repository.ErrRepoShortIDAlreadyExists))
+			}
 			return err
 		},
 		retry.RetryIf(
 			func(err error) bool {
-				return errors.Is(err, repository.ErrRepoShortIDAlreadyExists)
+				// Если последняя попытка увеличила длину, запрос повторяется еще раз уже с новой длиной.
+				return errors.Is(err, repository.ErrRepoShortIDAlreadyExists) && (attempt < maxCollisionAttempts || grown)
 			},
 		),
 		retry.LastErrorOnly(true),
-		retry.Attempts(maxCollisionAttempts),
+		retry.Attempts(maxCollisionAttempts+1),
 		retry.MaxDelay(retryCollisionTimeout),
 		retry.Context(ctx),
-		retry.OnRetry(func(n uint, err error) { s.logger.Debugln("Retry error: ", err.Error()) }),
+		retry.OnRetry(func(n uint, err error) {
+			metrics.ShortCodeCollisionsTotal.Inc()
+			s.logger.Debugln("Retry error: ", err.Error())
+		}),
 	)
 
 	if err != nil {
@@ -196,7 +477,59 @@ func (s *ShortenURLService) tryGetURLItem(ctx context.Context, originalURL []str
 	return items, nil
 }
 
+// observeCodeLength учитывает попытку генерации сокращенных идентификаторов, сообщает об увеличении их длины
+// и возвращает true, если длина увеличилась.
+// Эта функция принимает признак коллизии; ошибка сохранения длины только записывается в лог.
+func (s *ShortenURLService) observeCodeLength(ctx context.Context, collided bool) bool {
+	grown, err := s.codeLength.Observe(ctx, collided)
+	if err != nil {
+		s.logger.Errorf("failed to save short code length: %v", err)
+	}
+	if grown {
+		s.logger.Infof("short code length increased to %d due to collisions", s.codeLength.Get())
+	}
+	return grown
+}
+
+// hashPasswords возвращает bcrypt-хэши паролей в порядке параметров; для параметров без пароля возвращается пустая строка.
+func hashPasswords(params []model.ShortenParams) ([]string, error) {
+	hashes := make([]string, len(params))
+	for idx, param := range params {
+		if param.Password == "" {
+			continue
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(param.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		hashes[idx] = string(hash)
+	}
+	return hashes, nil
+}
+
+// newUserURL возвращает представление URL-адреса для его владельца.
+// Нулевые моменты создания и изменения (у URL-адресов, созданных до появления полей) не передаются.
+func (s *ShortenURLService) newUserURL(item model.URLItem) model.UserURL {
+	userURL := model.UserURL{
+		URL:            item.URL,
+		ShortURL:       s.buildShortURL(item),
+		LastAccessedAt: item.LastAccessedAt,
+		Title:          item.Title,
+		Tags:           item.Tags,
+		IsDeleted:      item.IsDeleted,
+	}
+	if !item.CreatedAt.IsZero() {
+		userURL.CreatedAt = &item.CreatedAt
+	}
+	if !item.UpdatedAt.IsZero() {
+		userURL.UpdatedAt = &item.UpdatedAt
+	}
+	return userURL
+}
+
 // buildShortURL возвращает сокращенный URL-адрес для заданного элемента URL-адреса.
+// Домен определяется по ключу, поэтому достаточно элемента с заполненным ShortID.
 func (s *ShortenURLService) buildShortURL(item model.URLItem) string {
-	return fmt.Sprintf("%s/%s", s.shortURLDomain, item.ShortID)
+	code, domain := model.SplitShortKey(item.ShortID)
+	return fmt.Sprintf("%s/%s", s.domains.BaseURL(domain), code)
 }
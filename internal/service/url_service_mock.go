@@ -13,15 +13,15 @@ type MockURLService struct {
 	mock.Mock
 }
 
-// GetShortURL возвращает сокращенный URL-адрес для заданного URL-адреса и идентификатора пользователя (мок-реализация).
-func (m *MockURLService) GetShortURL(ctx context.Context, originalURL string, userID string) (string, error) {
-	args := m.Called(ctx, originalURL, userID)
+// GetShortURL возвращает сокращенный URL-адрес для заданных параметров и идентификатора пользователя (мок-реализация).
+func (m *MockURLService) GetShortURL(ctx context.Context, params model.ShortenParams, userID string) (string, error) {
+	args := m.Called(ctx, params, userID)
 	return args.String(0), args.Error(1)
 }
 
-// GetShortURLBatch возвращает список сокращенных URL-адресов для заданного списка URL-адресов и идентификатора пользователя (мок-реализация).
-func (m *MockURLService) GetShortURLBatch(ctx context.Context, urls []string, userID string) ([]string, error) {
-	args := m.Called(ctx, urls, userID)
+// GetShortURLBatch возвращает список сокращенных URL-адресов для заданного списка параметров и идентификатора пользователя (мок-реализация).
+func (m *MockURLService) GetShortURLBatch(ctx context.Context, params []model.ShortenParams, userID string) ([]string, error) {
+	args := m.Called(ctx, params, userID)
 	return args.Get(0).([]string), args.Error(1)
 }
 
@@ -31,6 +31,25 @@ func (m *MockURLService) GetOriginalURL(ctx context.Context, shortURL string) (s
 	return args.String(0), args.Error(1)
 }
 
+// GetURLItem возвращает действующий элемент URL-адреса для заданного сокращенного URL-адреса (мок-реализация).
+func (m *MockURLService) GetURLItem(ctx context.Context, shortURL string) (*model.URLItem, error) {
+	args := m.Called(ctx, shortURL)
+	item, _ := args.Get(0).(*model.URLItem)
+	return item, args.Error(1)
+}
+
+// UnlockURL проверяет пароль защищенного URL-адреса и возвращает оригинальный URL-адрес (мок-реализация).
+func (m *MockURLService) UnlockURL(ctx context.Context, shortURL string, password string, clientKey string) (string, error) {
+	args := m.Called(ctx, shortURL, password, clientKey)
+	return args.String(0), args.Error(1)
+}
+
+// GetQRContent возвращает содержимое QR-кода сокращенного URL-адреса (мок-реализация).
+func (m *MockURLService) GetQRContent(ctx context.Context, shortCode string) (string, error) {
+	args := m.Called(ctx, shortCode)
+	return args.String(0), args.Error(1)
+}
+
 // GetUserURL возвращает список URL-адресов для заданного идентификатора пользователя (мок-реализация).
 func (m *MockURLService) GetUserURL(ctx context.Context, userID string) ([]model.UserURL, error) {
 	args := m.Called(ctx, userID)
@@ -38,7 +57,42 @@ func (m *MockURLService) GetUserURL(ctx context.Context, userID string) ([]model
 }
 
 // DeleteUserURL удаляет URL-адреса для заданного идентификатора пользователя и списка сокращенных URL-адресов (мок-реализация).
-func (m *MockURLService) DeleteUserURL(ctx context.Context, userID string, shortIDs []string) error {
+func (m *MockURLService) DeleteUserURL(ctx context.Context, userID string, shortIDs []string) (string, error) {
 	args := m.Called(ctx, userID, shortIDs)
-	return args.Error(1)
+	return args.String(0), args.Error(1)
+}
+
+// UpdateURL изменяет оригинальный URL-адрес сокращенного URL-адреса (мок-реализация).
+func (m *MockURLService) UpdateURL(ctx context.Context, userID string, shortID string, newURL string) (string, *model.URLEdit, error) {
+	args := m.Called(ctx, userID, shortID, newURL)
+	edit, _ := args.Get(1).(*model.URLEdit)
+	return args.String(0), edit, args.Error(2)
+}
+
+// GetURLEdits возвращает историю изменений сокращенного URL-адреса (мок-реализация).
+func (m *MockURLService) GetURLEdits(ctx context.Context, userID string, shortID string) ([]model.URLEdit, error) {
+	args := m.Called(ctx, userID, shortID)
+	edits, _ := args.Get(0).([]model.URLEdit)
+	return edits, args.Error(1)
+}
+
+// SetURLTags заменяет метки сокращенного URL-адреса (мок-реализация).
+func (m *MockURLService) SetURLTags(ctx context.Context, userID string, shortID string, tags []string) ([]string, error) {
+	args := m.Called(ctx, userID, shortID, tags)
+	normalized, _ := args.Get(0).([]string)
+	return normalized, args.Error(1)
+}
+
+// GetUserTags возвращает метки пользователя с количеством URL-адресов (мок-реализация).
+func (m *MockURLService) GetUserTags(ctx context.Context, userID string) ([]model.TagCount, error) {
+	args := m.Called(ctx, userID)
+	counts, _ := args.Get(0).([]model.TagCount)
+	return counts, args.Error(1)
+}
+
+// GetUserURLPage возвращает страницу URL-адресов пользователя (мок-реализация).
+func (m *MockURLService) GetUserURLPage(ctx context.Context, userID string, query model.UserURLQuery) ([]model.UserURL, string, error) {
+	args := m.Called(ctx, userID, query)
+	urls, _ := args.Get(0).([]model.UserURL)
+	return urls, args.String(1), args.Error(2)
 }
@@ -3,13 +3,17 @@ package service_test
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	app_error "github.com/oegegr/shortener/internal/error"
 	"github.com/oegegr/shortener/internal/model"
 	"github.com/oegegr/shortener/internal/repository"
 	"github.com/oegegr/shortener/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -17,60 +21,129 @@ type MockShortCodeProvider struct {
 	mock.Mock
 }
 
-func (m *MockShortCodeProvider) Get(length int) string {
-	args := m.Called(length)
-	return args.String(0)
+func (m *MockShortCodeProvider) Get(ctx context.Context, originalURL string, length int, attempt int) (string, error) {
+	args := m.Called(ctx, originalURL, length, attempt)
+	return args.String(0), args.Error(1)
 }
 
 type MockURLDelStrategy struct {
 	mock.Mock
 }
 
-func (m *MockURLDelStrategy) DeleteURL(ctx context.Context, ids []string) error {
-	args := m.Called(ctx, ids)
-	return args.Error(0)
+func (m *MockURLDelStrategy) DeleteURL(ctx context.Context, userID string, ids []string) (string, error) {
+	args := m.Called(ctx, userID, ids)
+	return args.String(0), args.Error(1)
 }
 
 const (
 	user string = "test"
 )
 
+// serviceDeps представляет зависимости сервиса сокращения URL-адресов в тестах; незаданные поля заменяются значениями по умолчанию.
+type serviceDeps struct {
+	// domains представляет реестр доменов; по умолчанию https://short.com.
+	domains *service.DomainRegistry
+	// codeLength представляет длину сокращенных идентификаторов; по умолчанию фиксированная длина 6.
+	codeLength *service.ShortCodeLength
+	// urlValidator представляет проверку сокращаемых URL-адресов; по умолчанию политика без правил.
+	urlValidator service.URLValidator
+	// passwordLimiter представляет учет неудачных попыток ввода пароля; по умолчанию 5 попыток в минуту.
+	passwordLimiter service.PasswordAttemptLimiter
+}
+
+// newTestService возвращает сервис сокращения URL-адресов с заданными репозиторием, провайдером сокращенных кодов,
+// стратегией удаления и зависимостями.
+func newTestService(t *testing.T, repo repository.URLRepository, provider service.ShortCodeProvider, delStrategy service.URLDeletionStrategy, deps serviceDeps) *service.ShortenURLService {
+	t.Helper()
+
+	if deps.domains == nil {
+		deps.domains = service.NewDomainRegistry("https://short.com")
+	}
+	if deps.codeLength == nil {
+		deps.codeLength = service.NewShortCodeLength(6, 6)
+	}
+	if deps.urlValidator == nil {
+		deps.urlValidator = service.NewURLPolicyEngine()
+	}
+	if deps.passwordLimiter == nil {
+		deps.passwordLimiter = service.NewInMemoryPasswordAttemptLimiter(5, time.Minute, time.Minute)
+	}
+	logger := zaptest.NewLogger(t).Sugar()
+	return service.NewShortenerService(repo, deps.domains, deps.codeLength, provider, delStrategy, deps.urlValidator, deps.passwordLimiter, *logger)
+}
+
 func TestShortenURLService_GetShortURL_Success(t *testing.T) {
 	repoMock := new(repository.MockURLRepository)
 	provider := new(MockShortCodeProvider)
 	delStrategy := new(MockURLDelStrategy)
 	ctx := context.Background()
-	logger := zaptest.NewLogger(t).Sugar()
-	svc := service.NewShortenerService(repoMock, "https://short.com", 6, provider, delStrategy, *logger)
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
 
 	originalURL := "https://original.com/long/url"
 	expectedShortCode := "abc123"
 
 	repoMock.On("CreateURL", mock.Anything, mock.AnythingOfType("[]model.URLItem")).Return(nil).Once()
-	provider.On("Get", 6).Return(expectedShortCode)
+	provider.On("Get", mock.Anything, mock.Anything, 6, mock.Anything).Return(expectedShortCode, nil)
 
-	shortURL, err := svc.GetShortURL(ctx, originalURL, user)
+	shortURL, err := svc.GetShortURL(ctx, model.ShortenParams{URL: originalURL}, user)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "https://short.com/"+expectedShortCode, shortURL)
 	repoMock.AssertExpectations(t)
 }
 
+func TestShortenURLService_GetShortURLBatch_PolicyViolation(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	policy := service.NewURLPolicyEngine(service.NewSchemePolicy([]string{"http", "https"}))
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{urlValidator: policy})
+
+	params := []model.ShortenParams{{URL: "https://original.com"}, {URL: "javascript:alert(1)"}}
+	_, err := svc.GetShortURLBatch(ctx, params, user)
+
+	var policyErr *service.URLPolicyError
+	assert.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, service.URLRuleScheme, policyErr.Rule)
+	assert.Equal(t, "javascript:alert(1)", policyErr.URL)
+	repoMock.AssertNotCalled(t, "CreateURL", mock.Anything, mock.Anything)
+}
+
+func TestShortenURLService_GetShortURL_Preview(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
+
+	provider.On("Get", mock.Anything, mock.Anything, 6, mock.Anything).Return("abc123", nil)
+	repoMock.On("CreateURL", mock.Anything, mock.MatchedBy(func(items []model.URLItem) bool {
+		return len(items) == 1 && items[0].Title == "Docs" && items[0].Preview && !items[0].CreatedAt.IsZero()
+	})).Return(nil).Once()
+
+	_, err := svc.GetShortURL(ctx, model.ShortenParams{URL: "https://original.com", Title: "Docs", Preview: true}, user)
+	assert.NoError(t, err)
+
+	_, err = svc.GetShortURL(ctx, model.ShortenParams{URL: "https://original.com", Title: strings.Repeat("я", 257)}, user)
+	assert.ErrorIs(t, err, app_error.ErrServiceInvalidTitle)
+	repoMock.AssertExpectations(t)
+}
+
 func TestShortenURLService_GetShortURL_CollisionRecovery(t *testing.T) {
 	repoMock := new(repository.MockURLRepository)
 	provider := new(MockShortCodeProvider)
 	delStrategy := new(MockURLDelStrategy)
 	ctx := context.Background()
-	logger := zaptest.NewLogger(t).Sugar()
-	svc := service.NewShortenerService(repoMock, "https://short.com", 6, provider, delStrategy, *logger)
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
 
 	originalURL := "https://original.com/long/url"
 
 	repoMock.On("CreateURL", mock.Anything, mock.Anything).Return(repository.ErrRepoShortIDAlreadyExists).Twice()
 	repoMock.On("CreateURL", mock.Anything, mock.Anything).Return(nil).Once()
-	provider.On("Get", 6).Return("any")
+	provider.On("Get", mock.Anything, mock.Anything, 6, mock.Anything).Return("any", nil)
 
-	shortURL, err := svc.GetShortURL(ctx, originalURL, user)
+	shortURL, err := svc.GetShortURL(ctx, model.ShortenParams{URL: originalURL}, user)
 
 	assert.NoError(t, err)
 	assert.Contains(t, shortURL, "https://short.com/")
@@ -83,15 +156,14 @@ func TestShortenURLService_GetShortURL_MaxCollisions(t *testing.T) {
 	provider := new(MockShortCodeProvider)
 	delStrategy := new(MockURLDelStrategy)
 	ctx := context.Background()
-	logger := zaptest.NewLogger(t).Sugar()
-	svc := service.NewShortenerService(repoMock, "https://short.com", 6, provider, delStrategy, *logger)
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
 
 	originalURL := "https://original.com/long/url"
 
 	repoMock.On("CreateURL", mock.Anything, mock.Anything).Return(repository.ErrRepoShortIDAlreadyExists).Times(10)
-	provider.On("Get", 6).Return("any")
+	provider.On("Get", mock.Anything, mock.Anything, 6, mock.Anything).Return("any", nil)
 
-	shortURL, err := svc.GetShortURL(ctx, originalURL, user)
+	shortURL, err := svc.GetShortURL(ctx, model.ShortenParams{URL: originalURL}, user)
 
 	assert.Error(t, err)
 	assert.Equal(t, repository.ErrRepoShortIDAlreadyExists, err)
@@ -99,21 +171,69 @@ func TestShortenURLService_GetShortURL_MaxCollisions(t *testing.T) {
 	repoMock.AssertExpectations(t)
 }
 
+func TestShortenURLService_GetShortURL_GrowsCodeLength(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	codeLength := service.NewShortCodeLength(6, 7)
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{codeLength: codeLength})
+
+	// Длина растет по доле коллизий в окне: предыдущие коллизии оставляют до порога одну.
+	for range 9 {
+		_, err := codeLength.Observe(ctx, true)
+		require.NoError(t, err)
+	}
+
+	repoMock.On("CreateURL", mock.Anything, mock.Anything).Return(repository.ErrRepoShortIDAlreadyExists).Once()
+	repoMock.On("CreateURL", mock.Anything, mock.Anything).Return(nil).Once()
+	provider.On("Get", mock.Anything, mock.Anything, 6, 0).Return("abcdef", nil).Once()
+	provider.On("Get", mock.Anything, mock.Anything, 7, 1).Return("abcdefg", nil).Once()
+
+	shortURL, err := svc.GetShortURL(ctx, model.ShortenParams{URL: "https://original.com"}, user)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://short.com/abcdefg", shortURL)
+	assert.Equal(t, 7, codeLength.Get())
+	provider.AssertExpectations(t)
+}
+
+func TestShortenURLService_GetShortURL_RetriesAfterGrowth(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	codeLength := service.NewShortCodeLength(6, 7)
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{codeLength: codeLength})
+
+	// Десятая коллизия запроса увеличивает длину, и запрос повторяется с новой длиной.
+	repoMock.On("CreateURL", mock.Anything, mock.Anything).Return(repository.ErrRepoShortIDAlreadyExists).Times(10)
+	repoMock.On("CreateURL", mock.Anything, mock.Anything).Return(nil).Once()
+	provider.On("Get", mock.Anything, mock.Anything, 6, mock.Anything).Return("abcdef", nil).Times(10)
+	provider.On("Get", mock.Anything, mock.Anything, 7, 10).Return("abcdefg", nil).Once()
+
+	shortURL, err := svc.GetShortURL(ctx, model.ShortenParams{URL: "https://original.com"}, user)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://short.com/abcdefg", shortURL)
+	provider.AssertExpectations(t)
+	repoMock.AssertExpectations(t)
+}
+
 func TestShortenURLService_GetShortURL_RepositoryError(t *testing.T) {
 	repoMock := new(repository.MockURLRepository)
 	provider := new(MockShortCodeProvider)
 	delStrategy := new(MockURLDelStrategy)
 	ctx := context.Background()
-	logger := zaptest.NewLogger(t).Sugar()
-	svc := service.NewShortenerService(repoMock, "https://short.com", 6, provider, delStrategy, *logger)
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
 
 	originalURL := "https://original.com/long/url"
 	testError := errors.New("database failure")
 
 	repoMock.On("CreateURL", mock.Anything, mock.Anything).Return(testError)
-	provider.On("Get", 6).Return("any")
+	provider.On("Get", mock.Anything, mock.Anything, 6, mock.Anything).Return("any", nil)
 
-	shortURL, err := svc.GetShortURL(ctx, originalURL, user)
+	shortURL, err := svc.GetShortURL(ctx, model.ShortenParams{URL: originalURL}, user)
 
 	assert.Error(t, err)
 	assert.Equal(t, testError, err)
@@ -126,8 +246,7 @@ func TestShortenURLService_GetOriginalURL_Success(t *testing.T) {
 	provider := new(MockShortCodeProvider)
 	delStrategy := new(MockURLDelStrategy)
 	ctx := context.Background()
-	logger := zaptest.NewLogger(t).Sugar()
-	svc := service.NewShortenerService(repoMock, "https://short.com", 6, provider, delStrategy, *logger)
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
 
 	shortCode := "abc123"
 	expectedURL := "https://original.com/long/url"
@@ -142,13 +261,32 @@ func TestShortenURLService_GetOriginalURL_Success(t *testing.T) {
 	repoMock.AssertExpectations(t)
 }
 
+func TestShortenURLService_GetQRContent(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
+
+	repoMock.On("FindURLByID", ctx, "abc123").Return(&model.URLItem{ShortID: "abc123", URL: "https://original.com"}, nil).Once()
+	repoMock.On("FindURLByID", ctx, "deleted").Return(&model.URLItem{ShortID: "deleted", IsDeleted: true}, nil).Once()
+
+	// QR-код кодирует сокращенный URL-адрес, а не оригинальный.
+	content, err := svc.GetQRContent(ctx, "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://short.com/abc123", content)
+
+	_, err = svc.GetQRContent(ctx, "deleted")
+	assert.ErrorIs(t, err, app_error.ErrServiceURLGone)
+	repoMock.AssertExpectations(t)
+}
+
 func TestShortenURLService_GetOriginalURL_NotFound(t *testing.T) {
 	repoMock := new(repository.MockURLRepository)
 	provider := new(MockShortCodeProvider)
 	delStrategy := new(MockURLDelStrategy)
 	ctx := context.Background()
-	logger := zaptest.NewLogger(t).Sugar()
-	svc := service.NewShortenerService(repoMock, "https://short.com", 6, provider, delStrategy, *logger)
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
 
 	shortCode := "invalid123"
 
@@ -167,8 +305,7 @@ func TestShortenURLService_GetOriginalURL_RepositoryError(t *testing.T) {
 	provider := new(MockShortCodeProvider)
 	delStrategy := new(MockURLDelStrategy)
 	ctx := context.Background()
-	logger := zaptest.NewLogger(t).Sugar()
-	svc := service.NewShortenerService(repoMock, "https://short.com", 6, provider, delStrategy, *logger)
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
 
 	shortCode := "abc123"
 	testError := errors.New("database error")
@@ -182,3 +319,303 @@ func TestShortenURLService_GetOriginalURL_RepositoryError(t *testing.T) {
 	assert.Empty(t, originalURL)
 	repoMock.AssertExpectations(t)
 }
+
+func TestShortenURLService_DeleteUserURL_PassesOwner(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
+
+	shortIDs := []string{"abc123", "def456"}
+
+	delStrategy.On("DeleteURL", ctx, user, shortIDs).Return("job", nil).Once()
+
+	jobID, err := svc.DeleteUserURL(ctx, user, shortIDs)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "job", jobID)
+	delStrategy.AssertExpectations(t)
+}
+
+func TestShortenURLService_GetShortURL_CustomAlias(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
+
+	repoMock.On("CreateURL", mock.Anything, mock.MatchedBy(func(items []model.URLItem) bool {
+		return len(items) == 1 && items[0].ShortID == "spring-sale" && items[0].IsCustom
+	})).Return(nil).Once()
+
+	shortURL, err := svc.GetShortURL(ctx, model.ShortenParams{URL: "https://original.com", Alias: "spring-sale"}, user)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://short.com/spring-sale", shortURL)
+	provider.AssertNotCalled(t, "Get", mock.Anything)
+	repoMock.AssertExpectations(t)
+}
+
+func TestShortenURLService_GetShortURL_AliasConflictNotRetried(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
+
+	repoMock.On("CreateURL", mock.Anything, mock.Anything).Return(repository.ErrRepoAliasAlreadyExists).Once()
+
+	shortURL, err := svc.GetShortURL(ctx, model.ShortenParams{URL: "https://original.com", Alias: "taken"}, user)
+
+	assert.ErrorIs(t, err, repository.ErrRepoAliasAlreadyExists)
+	assert.Empty(t, shortURL)
+	repoMock.AssertNumberOfCalls(t, "CreateURL", 1)
+}
+
+func TestShortenURLService_GetShortURL_AliasForShortenedURL(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
+
+	repoMock.On("CreateURL", mock.Anything, mock.Anything).Return(repository.ErrRepoURLAlreadyExists).Once()
+	repoMock.On("FindURLByURL", mock.Anything, "", "https://original.com").Return(model.NewURLItem("https://original.com", "abc123", user, false), nil).Once()
+
+	shortURL, err := svc.GetShortURL(ctx, model.ShortenParams{URL: "https://original.com", Alias: "fresh"}, user)
+
+	assert.ErrorIs(t, err, app_error.ErrServiceURLAlreadyShortened)
+	assert.NotErrorIs(t, err, repository.ErrRepoURLAlreadyExists)
+	assert.Empty(t, shortURL)
+	repoMock.AssertExpectations(t)
+}
+
+func TestShortenURLService_GetShortURL_Domain(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	domains := service.NewDomainRegistry("https://short.com",
+		service.ShortDomain{BaseURL: "https://brand.link"},
+		service.ShortDomain{BaseURL: "https://partner.link", Users: []string{"partner"}},
+	)
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{domains: domains})
+
+	repoMock.On("CreateURL", mock.Anything, mock.MatchedBy(func(items []model.URLItem) bool {
+		return len(items) == 1 && items[0].ShortID == "abc123@brand.link" && items[0].Domain == "brand.link"
+	})).Return(nil).Once()
+	provider.On("Get", mock.Anything, mock.Anything, 6, mock.Anything).Return("abc123", nil)
+
+	shortURL, err := svc.GetShortURL(ctx, model.ShortenParams{URL: "https://original.com", Domain: "Brand.Link"}, user)
+	require.NoError(t, err)
+	assert.Equal(t, "https://brand.link/abc123", shortURL)
+
+	repoMock.On("CreateURL", mock.Anything, mock.Anything).Return(repository.ErrRepoURLAlreadyExists).Once()
+	repoMock.On("FindURLByURL", mock.Anything, "brand.link", "https://original.com").
+		Return(&model.URLItem{ShortID: "abc123@brand.link", Domain: "brand.link", URL: "https://original.com"}, nil).Once()
+
+	shortURL, err = svc.GetShortURL(ctx, model.ShortenParams{URL: "https://original.com", Domain: "brand.link"}, user)
+	assert.ErrorIs(t, err, repository.ErrRepoURLAlreadyExists)
+	assert.Equal(t, "https://brand.link/abc123", shortURL)
+
+	_, err = svc.GetShortURL(ctx, model.ShortenParams{URL: "https://original.com", Domain: "partner.link"}, user)
+	assert.ErrorIs(t, err, app_error.ErrServiceDomainNotAllowed)
+	_, err = svc.GetShortURL(ctx, model.ShortenParams{URL: "https://original.com", Domain: "unknown.link"}, user)
+	assert.ErrorIs(t, err, app_error.ErrServiceUnknownDomain)
+	repoMock.AssertExpectations(t)
+}
+
+func TestValidateAlias(t *testing.T) {
+	tests := []struct {
+		name    string
+		alias   string
+		wantErr bool
+	}{
+		{name: "valid", alias: "spring-sale_2024", wantErr: false},
+		{name: "too short", alias: "ab", wantErr: true},
+		{name: "too long", alias: strings.Repeat("a", 65), wantErr: true},
+		{name: "bad charset", alias: "spring/sale", wantErr: true},
+		{name: "reserved", alias: "ping", wantErr: true},
+		{name: "reserved case insensitive", alias: "API", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.ValidateAlias(tt.alias)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, app_error.ErrServiceInvalidAlias)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestShortenURLService_GetOriginalURL_Expired(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
+
+	expiredAt := time.Now().Add(-time.Minute)
+	urlItem := &model.URLItem{ShortID: "abc123", URL: "https://original.com", ExpiresAt: &expiredAt}
+
+	repoMock.On("FindURLByID", ctx, "abc123").Return(urlItem, nil).Once()
+
+	originalURL, err := svc.GetOriginalURL(ctx, "abc123")
+
+	assert.ErrorIs(t, err, app_error.ErrServiceURLExpired)
+	assert.Empty(t, originalURL)
+	repoMock.AssertExpectations(t)
+}
+
+func TestShortenURLService_PasswordProtected(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{passwordLimiter: service.NewInMemoryPasswordAttemptLimiter(2, time.Minute, time.Minute)})
+
+	var stored model.URLItem
+	provider.On("Get", mock.Anything, mock.Anything, 6, mock.Anything).Return("abc123", nil)
+	repoMock.On("CreateURL", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).([]model.URLItem)[0]
+	}).Return(nil).Once()
+
+	_, err := svc.GetShortURL(ctx, model.ShortenParams{URL: "https://original.com", Password: "s3cret"}, user)
+	require.NoError(t, err)
+	assert.True(t, stored.IsProtected())
+	assert.NotContains(t, stored.PasswordHash, "s3cret")
+
+	repoMock.On("FindURLByID", ctx, "abc123").Return(&stored, nil)
+
+	_, err = svc.GetOriginalURL(ctx, "abc123")
+	assert.ErrorIs(t, err, app_error.ErrServiceURLProtected)
+
+	originalURL, err := svc.UnlockURL(ctx, "abc123", "s3cret", "1.2.3.4")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://original.com", originalURL)
+
+	_, err = svc.UnlockURL(ctx, "abc123", "wrong", "1.2.3.4")
+	assert.ErrorIs(t, err, app_error.ErrServiceWrongPassword)
+	_, err = svc.UnlockURL(ctx, "abc123", "wrong", "1.2.3.4")
+	assert.ErrorIs(t, err, app_error.ErrServiceWrongPassword)
+
+	_, err = svc.UnlockURL(ctx, "abc123", "s3cret", "1.2.3.4")
+	assert.ErrorIs(t, err, app_error.ErrServiceTooManyAttempts)
+
+	// Блокировка не затрагивает других клиентов.
+	originalURL, err = svc.UnlockURL(ctx, "abc123", "s3cret", "5.6.7.8")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://original.com", originalURL)
+
+	_, err = svc.GetShortURL(ctx, model.ShortenParams{URL: "https://original.com", Password: strings.Repeat("p", 73)}, user)
+	assert.ErrorIs(t, err, app_error.ErrServiceInvalidPassword)
+}
+
+func TestShortenURLService_UpdateURL(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	policy := service.NewURLPolicyEngine(service.NewSchemePolicy([]string{"https"}))
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{urlValidator: policy})
+
+	isEdit := func(shortID string, newURL string) any {
+		return mock.MatchedBy(func(edit model.URLEdit) bool {
+			return edit.ShortID == shortID && edit.UserID == user && edit.NewURL == newURL && !edit.EditedAt.IsZero()
+		})
+	}
+	repoMock.On("UpdateURL", mock.Anything, isEdit("abc123", "https://new.com")).
+		Return(&model.URLEdit{ShortID: "abc123", OldURL: "https://old.com", NewURL: "https://new.com"}, nil).Once()
+	repoMock.On("UpdateURL", mock.Anything, isEdit("foreign", "https://new.com")).
+		Return((*model.URLEdit)(nil), repository.ErrRepoNotOwned).Once()
+	repoMock.On("UpdateURL", mock.Anything, isEdit("expired", "https://new.com")).
+		Return((*model.URLEdit)(nil), repository.ErrRepoURLExpired).Once()
+
+	shortURL, edit, err := svc.UpdateURL(ctx, user, "abc123", "https://new.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://short.com/abc123", shortURL)
+	assert.Equal(t, "https://old.com", edit.OldURL)
+
+	_, _, err = svc.UpdateURL(ctx, user, "foreign", "https://new.com")
+	assert.ErrorIs(t, err, app_error.ErrServiceURLNotOwned)
+
+	_, _, err = svc.UpdateURL(ctx, user, "expired", "https://new.com")
+	assert.ErrorIs(t, err, app_error.ErrServiceURLExpired)
+
+	_, _, err = svc.UpdateURL(ctx, user, "abc123", "ftp://new.com")
+	assert.ErrorIs(t, err, app_error.ErrServiceURLRejected)
+	repoMock.AssertExpectations(t)
+}
+
+func TestShortenURLService_GetURLEdits(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
+
+	edits := []model.URLEdit{{ShortID: "abc123", UserID: user, OldURL: "https://old.com", NewURL: "https://new.com"}}
+	repoMock.On("FindURLByID", ctx, "abc123").Return(&model.URLItem{ShortID: "abc123", UserID: user}, nil)
+	repoMock.On("FindURLByID", ctx, "gone").Return(&model.URLItem{ShortID: "gone", UserID: user, IsDeleted: true}, nil)
+	repoMock.On("FindURLEdits", ctx, "abc123").Return(edits, nil).Once()
+
+	found, err := svc.GetURLEdits(ctx, user, "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, edits, found)
+
+	_, err = svc.GetURLEdits(ctx, "stranger", "abc123")
+	assert.ErrorIs(t, err, app_error.ErrServiceURLNotOwned)
+
+	_, err = svc.GetURLEdits(ctx, user, "gone")
+	assert.ErrorIs(t, err, repository.ErrRepoNotFound)
+	repoMock.AssertExpectations(t)
+}
+
+func TestShortenURLService_GetUserURLPage(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
+
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	last := model.URLItem{ShortID: "abc", URL: "https://original.com", CreatedAt: createdAt}
+	query := model.UserURLQuery{Limit: 1, Order: model.SortDesc}
+	repoMock.On("FindURLPageByUser", ctx, user, query).
+		Return(&model.UserURLPage{Items: []model.URLItem{last}, Next: model.NewURLCursor(last)}, nil).Once()
+
+	urls, next, err := svc.GetUserURLPage(ctx, user, query)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.UserURL{{ShortURL: "https://short.com/abc", URL: "https://original.com", CreatedAt: &createdAt}}, urls)
+
+	cursor, err := model.ParseURLCursor(next)
+	assert.NoError(t, err)
+	assert.Equal(t, model.NewURLCursor(last), cursor)
+	repoMock.AssertExpectations(t)
+}
+
+func TestShortenURLService_SetURLTags(t *testing.T) {
+	repoMock := new(repository.MockURLRepository)
+	provider := new(MockShortCodeProvider)
+	delStrategy := new(MockURLDelStrategy)
+	ctx := context.Background()
+	svc := newTestService(t, repoMock, provider, delStrategy, serviceDeps{})
+
+	repoMock.On("SetTags", ctx, user, "abc123", []string{"docs", "team"}, mock.AnythingOfType("time.Time")).Return(nil).Once()
+	repoMock.On("SetTags", ctx, user, "foreign", []string{"docs"}, mock.AnythingOfType("time.Time")).Return(repository.ErrRepoNotOwned).Once()
+
+	tags, err := svc.SetURLTags(ctx, user, "abc123", []string{" Team", "docs", "team "})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"docs", "team"}, tags)
+
+	_, err = svc.SetURLTags(ctx, user, "foreign", []string{"docs"})
+	assert.ErrorIs(t, err, app_error.ErrServiceURLNotOwned)
+
+	_, err = svc.SetURLTags(ctx, user, "abc123", []string{"with space"})
+	assert.ErrorIs(t, err, app_error.ErrServiceInvalidTag)
+	repoMock.AssertExpectations(t)
+}
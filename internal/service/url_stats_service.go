@@ -0,0 +1,53 @@
+// Package service содержит реализацию сервиса статистики переходов по сокращенным URL-адресам.
+package service
+
+import (
+	"context"
+
+	app_error "github.com/oegegr/shortener/internal/error"
+	"github.com/oegegr/shortener/internal/model"
+	"github.com/oegegr/shortener/internal/repository"
+)
+
+// URLStatsProvider представляет интерфейс для получения статистики переходов.
+type URLStatsProvider interface {
+	// GetURLStats возвращает статистику переходов по сокращенному URL-адресу, принадлежащему пользователю.
+	GetURLStats(ctx context.Context, userID string, shortID string) (*model.URLStats, error)
+}
+
+// URLStatsService представляет реализацию сервиса статистики переходов.
+type URLStatsService struct {
+	// urlRepository представляет репозиторий URL-адресов.
+	urlRepository repository.URLRepository
+	// clickRepository представляет репозиторий переходов.
+	clickRepository repository.ClickRepository
+}
+
+// NewURLStatsService возвращает новый экземпляр URLStatsService.
+// Эта функция принимает репозиторий URL-адресов и репозиторий переходов.
+func NewURLStatsService(urlRepo repository.URLRepository, clickRepo repository.ClickRepository) *URLStatsService {
+	return &URLStatsService{
+		urlRepository:   urlRepo,
+		clickRepository: clickRepo,
+	}
+}
+
+// GetURLStats возвращает статистику переходов по сокращенному URL-адресу.
+// Эта функция возвращает repository.ErrRepoNotFound, если URL-адрес не найден,
+// и app_error.ErrServiceURLNotOwned, если URL-адрес принадлежит другому пользователю.
+func (s *URLStatsService) GetURLStats(ctx context.Context, userID string, shortID string) (*model.URLStats, error) {
+	item, err := s.urlRepository.FindURLByID(ctx, shortID)
+	if err != nil {
+		return nil, err
+	}
+
+	if item == nil {
+		return nil, repository.ErrRepoNotFound
+	}
+
+	if item.UserID != userID {
+		return nil, app_error.ErrServiceURLNotOwned
+	}
+
+	return s.clickRepository.GetClickStats(ctx, shortID)
+}
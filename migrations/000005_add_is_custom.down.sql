@@ -0,0 +1,8 @@
+-- migrations/000005_add_is_custom.down.sql
+BEGIN;
+
+DROP INDEX IF EXISTS idx_unique_short_id;
+
+ALTER TABLE url DROP COLUMN IF EXISTS is_custom;
+
+COMMIT;
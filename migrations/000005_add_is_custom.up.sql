@@ -0,0 +1,14 @@
+-- migrations/000005_add_is_custom.up.sql
+BEGIN;
+
+ALTER TABLE url ADD COLUMN is_custom BOOLEAN NOT NULL DEFAULT false;
+
+-- До появления уникального индекса одинаковые short_id могли попасть в таблицу;
+-- более поздние дубликаты получают суффикс с id строки, чтобы индекс создался без удаления данных.
+UPDATE url SET short_id = url.short_id || '-' || url.id
+FROM (SELECT id, row_number() OVER (PARTITION BY short_id ORDER BY id) AS rn FROM url) AS dup
+WHERE url.id = dup.id AND dup.rn > 1;
+
+CREATE UNIQUE INDEX idx_unique_short_id ON url(short_id);
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- migrations/000006_add_expires_at.down.sql
+BEGIN;
+
+DROP INDEX IF EXISTS idx_url_expires_at;
+
+ALTER TABLE url DROP COLUMN IF EXISTS expires_at;
+
+COMMIT;
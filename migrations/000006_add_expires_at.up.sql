@@ -0,0 +1,8 @@
+-- migrations/000006_add_expires_at.up.sql
+BEGIN;
+
+ALTER TABLE url ADD COLUMN expires_at TIMESTAMPTZ;
+
+CREATE INDEX idx_url_expires_at ON url(expires_at) WHERE expires_at IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,3 @@
+-- migrations/000007_create_click_table.down.sql
+DROP INDEX IF EXISTS idx_click_short_id_ts;
+DROP TABLE IF EXISTS click;
@@ -0,0 +1,15 @@
+-- migrations/000007_create_click_table.up.sql
+BEGIN;
+
+CREATE TABLE click (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    short_id VARCHAR(255) NOT NULL,
+    ts TIMESTAMPTZ NOT NULL,
+    referrer TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_hash VARCHAR(64) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_click_short_id_ts ON click(short_id, ts);
+
+COMMIT;
@@ -0,0 +1,3 @@
+-- migrations/000008_create_deletion_job_table.down.sql
+DROP INDEX IF EXISTS idx_deletion_job_status;
+DROP TABLE IF EXISTS deletion_job;
@@ -0,0 +1,19 @@
+-- migrations/000008_create_deletion_job_table.up.sql
+BEGIN;
+
+CREATE TABLE deletion_job (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    short_ids JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    results JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    next_attempt_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_deletion_job_status ON deletion_job(status, next_attempt_at);
+
+COMMIT;
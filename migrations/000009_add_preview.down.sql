@@ -0,0 +1,8 @@
+-- migrations/000009_add_preview.down.sql
+BEGIN;
+
+ALTER TABLE url DROP COLUMN IF EXISTS created_at;
+ALTER TABLE url DROP COLUMN IF EXISTS preview;
+ALTER TABLE url DROP COLUMN IF EXISTS title;
+
+COMMIT;
@@ -0,0 +1,11 @@
+-- migrations/000009_add_preview.up.sql
+BEGIN;
+
+ALTER TABLE url ADD COLUMN title TEXT NOT NULL DEFAULT '';
+ALTER TABLE url ADD COLUMN preview BOOLEAN NOT NULL DEFAULT false;
+-- Время создания существующих URL-адресов неизвестно: столбец добавляется без значения по умолчанию,
+-- чтобы старые строки остались NULL, а now() подставлялся только для новых.
+ALTER TABLE url ADD COLUMN created_at TIMESTAMPTZ;
+ALTER TABLE url ALTER COLUMN created_at SET DEFAULT now();
+
+COMMIT;
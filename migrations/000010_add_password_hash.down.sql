@@ -0,0 +1,6 @@
+-- migrations/000010_add_password_hash.down.sql
+BEGIN;
+
+ALTER TABLE url DROP COLUMN IF EXISTS password_hash;
+
+COMMIT;
@@ -0,0 +1,6 @@
+-- migrations/000010_add_password_hash.up.sql
+BEGIN;
+
+ALTER TABLE url ADD COLUMN password_hash TEXT NOT NULL DEFAULT '';
+
+COMMIT;
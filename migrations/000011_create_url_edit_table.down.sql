@@ -0,0 +1,6 @@
+-- migrations/000011_create_url_edit_table.down.sql
+BEGIN;
+
+DROP TABLE IF EXISTS url_edit;
+
+COMMIT;
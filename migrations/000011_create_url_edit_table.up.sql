@@ -0,0 +1,15 @@
+-- migrations/000011_create_url_edit_table.up.sql
+BEGIN;
+
+CREATE TABLE url_edit (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    short_id VARCHAR(255) NOT NULL,
+    user_id UUID NOT NULL,
+    old_url TEXT NOT NULL,
+    new_url TEXT NOT NULL,
+    edited_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_url_edit_short_id ON url_edit(short_id, edited_at);
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- migrations/000012_add_user_url_indexes.down.sql
+BEGIN;
+
+DROP INDEX IF EXISTS idx_url_url_trgm;
+DROP INDEX IF EXISTS idx_url_user_created;
+
+COMMIT;
@@ -0,0 +1,21 @@
+-- migrations/000012_add_user_url_indexes.up.sql
+BEGIN;
+
+-- URL-адреса с неизвестным временем создания (NULL) упорядочиваются как самые старые.
+CREATE INDEX idx_url_user_created ON url(user_id, COALESCE(created_at, '0001-01-01 00:00:00+00'::timestamptz), short_id);
+
+-- Триграммный индекс ускоряет поиск подстроки в URL-адресах пользователя и необязателен.
+-- Расширение pg_trgm может быть не установлено на сервере, а создавать его может только пользователь с правами CREATE
+-- в базе данных; в этом случае миграция выполняется без индекса, а поиск работает последовательным просмотром.
+-- Индекс можно создать позже вручную командой из этого блока.
+DO $$
+BEGIN
+    CREATE EXTENSION IF NOT EXISTS pg_trgm;
+    CREATE INDEX idx_url_url_trgm ON url USING gin (url gin_trgm_ops);
+EXCEPTION
+    WHEN insufficient_privilege OR undefined_file THEN
+        RAISE NOTICE 'pg_trgm is unavailable (%), idx_url_url_trgm is not created', SQLERRM;
+END
+$$;
+
+COMMIT;
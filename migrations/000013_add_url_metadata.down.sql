@@ -0,0 +1,8 @@
+-- migrations/000013_add_url_metadata.down.sql
+BEGIN;
+
+DROP TABLE IF EXISTS url_tag;
+ALTER TABLE url DROP COLUMN IF EXISTS last_accessed_at;
+ALTER TABLE url DROP COLUMN IF EXISTS updated_at;
+
+COMMIT;
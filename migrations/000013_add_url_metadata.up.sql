@@ -0,0 +1,16 @@
+-- migrations/000013_add_url_metadata.up.sql
+BEGIN;
+
+ALTER TABLE url ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+UPDATE url SET updated_at = COALESCE(created_at, updated_at);
+ALTER TABLE url ADD COLUMN last_accessed_at TIMESTAMPTZ;
+
+CREATE TABLE url_tag (
+    url_id INT NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+    tag VARCHAR(64) NOT NULL,
+    PRIMARY KEY (url_id, tag)
+);
+
+CREATE INDEX idx_url_tag_tag ON url_tag(tag);
+
+COMMIT;
@@ -0,0 +1,6 @@
+-- migrations/000014_add_short_code_sequence.down.sql
+BEGIN;
+
+DROP SEQUENCE IF EXISTS url_short_code_seq;
+
+COMMIT;
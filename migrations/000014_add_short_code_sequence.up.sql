@@ -0,0 +1,6 @@
+-- migrations/000014_add_short_code_sequence.up.sql
+BEGIN;
+
+CREATE SEQUENCE IF NOT EXISTS url_short_code_seq AS BIGINT START WITH 1;
+
+COMMIT;
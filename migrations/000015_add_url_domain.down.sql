@@ -0,0 +1,20 @@
+-- migrations/000015_add_url_domain.down.sql
+BEGIN;
+
+-- Ссылки дополнительных доменов нельзя вернуть в схему без доменов без потери данных:
+-- их ключи "code@host" не открываются через домен по умолчанию, а одинаковые URL-адреса разных доменов
+-- нарушили бы индекс idx_unique_url. Откат отклоняется, пока такие ссылки не удалены или не перенесены вручную.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM url WHERE domain <> '') THEN
+        RAISE EXCEPTION 'cannot roll back migration 15: url table contains links of additional short domains';
+    END IF;
+END
+$$;
+
+DROP INDEX IF EXISTS idx_unique_domain_url;
+CREATE UNIQUE INDEX idx_unique_url ON url(url);
+
+ALTER TABLE url DROP COLUMN IF EXISTS domain;
+
+COMMIT;
@@ -0,0 +1,11 @@
+-- migrations/000015_add_url_domain.up.sql
+BEGIN;
+
+-- Пустой домен означает домен по умолчанию (BaseURL). Для других доменов short_id хранит код вместе с доменом
+-- ("code@host"), поэтому индекс idx_unique_short_id обеспечивает уникальность кода в пределах домена.
+ALTER TABLE url ADD COLUMN domain VARCHAR(255) NOT NULL DEFAULT '';
+
+DROP INDEX IF EXISTS idx_unique_url;
+CREATE UNIQUE INDEX idx_unique_domain_url ON url(domain, url);
+
+COMMIT;
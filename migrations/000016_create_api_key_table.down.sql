@@ -0,0 +1,4 @@
+-- migrations/000016_create_api_key_table.down.sql
+DROP INDEX IF EXISTS idx_api_key_user;
+DROP INDEX IF EXISTS idx_api_key_hash;
+DROP TABLE IF EXISTS api_key;
@@ -0,0 +1,18 @@
+-- migrations/000016_create_api_key_table.up.sql
+BEGIN;
+
+CREATE TABLE api_key (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL,
+    scopes JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_api_key_hash ON api_key(key_hash);
+CREATE INDEX idx_api_key_user ON api_key(user_id, created_at);
+
+COMMIT;
@@ -0,0 +1,6 @@
+-- migrations/000017_create_short_code_length_table.down.sql
+BEGIN;
+
+DROP TABLE IF EXISTS short_code_length;
+
+COMMIT;
@@ -0,0 +1,10 @@
+-- migrations/000017_create_short_code_length_table.up.sql
+BEGIN;
+
+-- Таблица хранит единственную строку с эффективной длиной генерируемых сокращенных идентификаторов.
+CREATE TABLE short_code_length (
+    id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+    length INT NOT NULL
+);
+
+COMMIT;
@@ -0,0 +1,21 @@
+// Package cache содержит интерфейс и реализации кэша байтовых значений с ограниченным временем жизни.
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrCacheMiss представляет ошибку, которая возникает при отсутствии значения в кэше.
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cache представляет интерфейс кэша байтовых значений.
+type Cache interface {
+	// Get возвращает значение по ключу или ErrCacheMiss, если значение отсутствует или устарело.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set сохраняет значение по ключу на заданное время.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete удаляет значения по ключам.
+	Delete(ctx context.Context, keys ...string) error
+}
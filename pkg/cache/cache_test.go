@@ -0,0 +1,77 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/oegegr/shortener/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCache_Eviction(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRUCache(2)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), time.Minute))
+
+	// Обращение к "a" делает "b" самым давно не используемым значением.
+	_, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), time.Minute))
+
+	_, err = c.Get(ctx, "b")
+	assert.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	value, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRUCache_TTLAndDelete(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRUCache(10)
+
+	require.NoError(t, c.Set(ctx, "short", []byte("1"), time.Nanosecond))
+	require.NoError(t, c.Set(ctx, "long", []byte("2"), time.Minute))
+	time.Sleep(time.Millisecond)
+
+	_, err := c.Get(ctx, "short")
+	assert.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	require.NoError(t, c.Delete(ctx, "long", "missing"))
+	_, err = c.Get(ctx, "long")
+	assert.ErrorIs(t, err, cache.ErrCacheMiss)
+}
+
+func TestRedisCache(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	c := cache.NewRedisCache(server.Addr(), "test:")
+	defer c.Close()
+
+	require.NoError(t, c.Ping(ctx))
+
+	_, err := c.Get(ctx, "a")
+	assert.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+	assert.True(t, server.Exists("test:a"))
+
+	value, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+
+	server.FastForward(2 * time.Minute)
+	_, err = c.Get(ctx, "a")
+	assert.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), time.Minute))
+	require.NoError(t, c.Delete(ctx, "b"))
+	_, err = c.Get(ctx, "b")
+	assert.ErrorIs(t, err, cache.ErrCacheMiss)
+}
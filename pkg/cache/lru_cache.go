@@ -0,0 +1,112 @@
+// Package cache содержит реализацию кэша в памяти процесса с вытеснением давно не используемых значений.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// lruEntry представляет элемент кэша LRUCache.
+type lruEntry struct {
+	// key представляет ключ элемента.
+	key string
+	// value представляет значение элемента.
+	value []byte
+	// expiresAt представляет момент, после которого элемент считается устаревшим.
+	expiresAt time.Time
+}
+
+// LRUCache представляет кэш в памяти процесса ограниченного размера.
+// При переполнении вытесняется значение, к которому дольше всего не обращались.
+type LRUCache struct {
+	// mu представляет mutex для синхронизации доступа к данным.
+	mu sync.Mutex
+	// size представляет максимальное количество элементов в кэше.
+	size int
+	// order представляет список элементов в порядке обращения, начиная с последнего.
+	order *list.List
+	// items представляет карту элементов списка по ключу.
+	items map[string]*list.Element
+	// now возвращает текущее время.
+	now func() time.Time
+}
+
+// NewLRUCache возвращает новый экземпляр LRUCache.
+// Эта функция принимает максимальное количество элементов в кэше.
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element, size),
+		now:   time.Now,
+	}
+}
+
+// Get возвращает значение по ключу или ErrCacheMiss.
+func (c *LRUCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.removeElement(elem)
+		return nil, ErrCacheMiss
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.value, nil
+}
+
+// Set сохраняет значение по ключу на заданное время.
+func (c *LRUCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(ttl)
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return nil
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+	return nil
+}
+
+// Delete удаляет значения по ключам.
+func (c *LRUCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := c.items[key]; ok {
+			c.removeElement(elem)
+		}
+	}
+	return nil
+}
+
+// Len возвращает количество элементов в кэше, включая устаревшие.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// removeElement удаляет элемент из списка и карты.
+func (c *LRUCache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*lruEntry).key)
+}
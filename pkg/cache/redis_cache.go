@@ -0,0 +1,64 @@
+// Package cache содержит реализацию кэша поверх сервера, совместимого с протоколом Redis.
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache представляет кэш, хранящий значения на сервере, совместимом с протоколом Redis.
+type RedisCache struct {
+	// client представляет клиент Redis.
+	client *redis.Client
+	// prefix представляет префикс ключей, добавляемый ко всем ключам кэша.
+	prefix string
+}
+
+// NewRedisCache возвращает новый экземпляр RedisCache.
+// Эта функция принимает адрес сервера и префикс ключей.
+func NewRedisCache(address string, prefix string) *RedisCache {
+	return &RedisCache{
+		client: redis.NewClient(&redis.Options{Addr: address}),
+		prefix: prefix,
+	}
+}
+
+// Get возвращает значение по ключу или ErrCacheMiss.
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrCacheMiss
+	}
+	return value, err
+}
+
+// Set сохраняет значение по ключу на заданное время.
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, c.prefix+key, value, ttl).Err()
+}
+
+// Delete удаляет значения по ключам.
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, c.prefix+key)
+	}
+	return c.client.Del(ctx, prefixed...).Err()
+}
+
+// Ping проверяет подключение к серверу.
+func (c *RedisCache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+// Close закрывает подключение к серверу.
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
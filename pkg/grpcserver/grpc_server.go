@@ -0,0 +1,57 @@
+// Package grpcserver содержит обертку для запуска и остановки gRPC сервера.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"google.golang.org/grpc"
+)
+
+// Server представляет gRPC сервер, слушающий заданный адрес.
+// Server реализует тот же интерфейс Start/Stop, что и HTTP сервер из pkg/http.
+type Server struct {
+	// address представляет адрес, на котором слушает сервер.
+	address string
+	// server представляет gRPC сервер.
+	server *grpc.Server
+}
+
+// NewServer возвращает новый экземпляр Server.
+// Эта функция принимает адрес и сконфигурированный gRPC сервер с зарегистрированными сервисами.
+func NewServer(address string, server *grpc.Server) *Server {
+	return &Server{address: address, server: server}
+}
+
+// Start начинает прием соединений и блокируется до остановки сервера.
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+
+	err = s.server.Serve(listener)
+	if errors.Is(err, grpc.ErrServerStopped) {
+		return nil
+	}
+	return err
+}
+
+// Stop останавливает сервер, дожидаясь завершения активных запросов.
+// Если контекст завершается раньше, активные запросы прерываются.
+func (s *Server) Stop(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,100 @@
+package https
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustedProxies представляет подсети прокси-серверов, которым разрешено передавать IP-адрес клиента
+// в заголовках X-Real-IP и X-Forwarded-For.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies разбирает список подсетей в CIDR-нотации через запятую.
+// Пустая строка означает, что заголовки с IP-адресом клиента не принимаются ни от кого.
+func ParseTrustedProxies(spec string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, cidr := range strings.Split(spec, ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", cidr, err)
+		}
+		proxies = append(proxies, subnet)
+	}
+	return proxies, nil
+}
+
+// Contains проверяет, принадлежит ли IP-адрес одной из доверенных подсетей.
+func (p TrustedProxies) Contains(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, subnet := range p {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveClientIP возвращает IP-адрес клиента.
+// Заголовки X-Real-IP и X-Forwarded-For учитываются, только если соединение установлено доверенным прокси-сервером;
+// в X-Forwarded-For выбирается последний адрес справа, не принадлежащий доверенным подсетям.
+// В остальных случаях возвращается адрес соединения без порта.
+func (p TrustedProxies) ResolveClientIP(r *http.Request) string {
+	remote := remoteIP(r)
+	if !p.Contains(net.ParseIP(remote)) {
+		return remote
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		if !p.Contains(ip) || i == 0 {
+			return ip.String()
+		}
+	}
+	return remote
+}
+
+// clientIPKey представляет ключ контекста для IP-адреса клиента.
+type clientIPKey struct{}
+
+// ContextWithClientIP возвращает контекст с IP-адресом клиента, определенным с учетом доверенных прокси-серверов.
+func ContextWithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIP возвращает IP-адрес клиента.
+// Адрес берется из контекста запроса, если его определила middleware-функция с учетом доверенных прокси-серверов,
+// иначе — из адреса соединения без порта. Заголовки запроса напрямую не учитываются.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok && ip != "" {
+		return ip
+	}
+	return remoteIP(r)
+}
+
+// remoteIP возвращает адрес соединения без порта.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
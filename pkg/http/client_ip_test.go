@@ -0,0 +1,60 @@
+package https_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pkghttp "github.com/oegegr/shortener/pkg/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustedProxies_ResolveClientIP(t *testing.T) {
+	proxies, err := pkghttp.ParseTrustedProxies("10.0.0.0/8, 192.168.0.1/32")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		realIP     string
+		forwarded  string
+		want       string
+	}{
+		{name: "untrusted remote ignores headers", remoteAddr: "203.0.113.5:4000", realIP: "1.1.1.1", forwarded: "2.2.2.2", want: "203.0.113.5"},
+		{name: "trusted remote uses X-Real-IP", remoteAddr: "10.0.0.2:4000", realIP: "1.1.1.1", want: "1.1.1.1"},
+		{name: "trusted remote skips trusted hops", remoteAddr: "10.0.0.2:4000", forwarded: "6.6.6.6, 1.1.1.1, 192.168.0.1", want: "1.1.1.1"},
+		{name: "trusted remote with invalid header", remoteAddr: "10.0.0.2:4000", realIP: "garbage", want: "10.0.0.2"},
+		{name: "trusted remote without headers", remoteAddr: "10.0.0.2:4000", want: "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			assert.Equal(t, tt.want, proxies.ResolveClientIP(req))
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.5:4000"
+	req.Header.Set("X-Real-IP", "1.1.1.1")
+
+	assert.Equal(t, "203.0.113.5", pkghttp.ClientIP(req))
+
+	req = req.WithContext(pkghttp.ContextWithClientIP(req.Context(), "1.1.1.1"))
+	assert.Equal(t, "1.1.1.1", pkghttp.ClientIP(req))
+}
+
+func TestParseTrustedProxies_Invalid(t *testing.T) {
+	_, err := pkghttp.ParseTrustedProxies("10.0.0.0/8,garbage")
+	assert.Error(t, err)
+}
@@ -0,0 +1,128 @@
+// Package qr содержит генерацию QR-кодов в форматах PNG и SVG.
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Format представляет формат изображения QR-кода.
+type Format string
+
+// Поддерживаемые форматы изображения QR-кода.
+const (
+	FormatPNG Format = "png"
+	FormatSVG Format = "svg"
+)
+
+// Границы и значения по умолчанию параметров QR-кода.
+const (
+	MinSize      = 64
+	MaxSize      = 2048
+	DefaultSize  = 256
+	DefaultLevel = "M"
+)
+
+// ErrInvalidFormat представляет ошибку, которая возникает при неподдерживаемом формате изображения.
+var ErrInvalidFormat = errors.New("invalid qr format")
+
+// ErrInvalidSize представляет ошибку, которая возникает при размере изображения вне допустимых границ.
+var ErrInvalidSize = errors.New("invalid qr size")
+
+// ErrInvalidLevel представляет ошибку, которая возникает при неизвестном уровне коррекции ошибок.
+var ErrInvalidLevel = errors.New("invalid qr error correction level")
+
+// levels представляет уровни коррекции ошибок по их обозначению.
+var levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// Options представляет параметры изображения QR-кода.
+type Options struct {
+	// Format представляет формат изображения.
+	Format Format
+	// Size представляет ширину и высоту изображения в пикселях.
+	Size int
+	// Level представляет уровень коррекции ошибок: L, M, Q или H.
+	Level string
+}
+
+// DefaultOptions возвращает параметры изображения QR-кода по умолчанию.
+func DefaultOptions() Options {
+	return Options{Format: FormatPNG, Size: DefaultSize, Level: DefaultLevel}
+}
+
+// Validate проверяет параметры изображения QR-кода.
+func (o Options) Validate() error {
+	if o.Format != FormatPNG && o.Format != FormatSVG {
+		return fmt.Errorf("%w: %q", ErrInvalidFormat, o.Format)
+	}
+	if o.Size < MinSize || o.Size > MaxSize {
+		return fmt.Errorf("%w: must be between %d and %d", ErrInvalidSize, MinSize, MaxSize)
+	}
+	if _, ok := levels[o.Level]; !ok {
+		return fmt.Errorf("%w: %q", ErrInvalidLevel, o.Level)
+	}
+	return nil
+}
+
+// ContentType возвращает MIME-тип изображения.
+func (o Options) ContentType() string {
+	if o.Format == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Encode возвращает изображение QR-кода с заданным содержимым.
+func Encode(content string, opts Options) ([]byte, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	code, err := qrcode.New(content, levels[opts.Level])
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode qr code: %w", err)
+	}
+
+	if opts.Format == FormatSVG {
+		return renderSVG(code.Bitmap(), opts.Size), nil
+	}
+
+	data, err := code.PNG(opts.Size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render qr code: %w", err)
+	}
+	return data, nil
+}
+
+// renderSVG возвращает SVG-изображение матрицы QR-кода; темные модули объединяются в один path построчно.
+func renderSVG(bitmap [][]bool, size int) []byte {
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, len(bitmap), len(bitmap))
+	svg.WriteString(`<rect width="100%" height="100%" fill="#fff"/>`)
+	fmt.Fprintf(&svg, `<path fill="#000" d="%s"/>`, path.String())
+	svg.WriteString("</svg>")
+	return []byte(svg.String())
+}
@@ -0,0 +1,69 @@
+package qr_test
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/oegegr/shortener/pkg/qr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode_PNG(t *testing.T) {
+	opts := qr.DefaultOptions()
+	opts.Size = 128
+
+	data, err := qr.Encode("https://short.com/abc123", opts)
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 128, img.Bounds().Dx())
+	assert.Equal(t, 128, img.Bounds().Dy())
+	assert.Equal(t, "image/png", opts.ContentType())
+}
+
+func TestEncode_SVG(t *testing.T) {
+	opts := qr.Options{Format: qr.FormatSVG, Size: 300, Level: "H"}
+
+	data, err := qr.Encode("https://short.com/abc123", opts)
+	require.NoError(t, err)
+
+	svg := string(data)
+	assert.True(t, strings.HasPrefix(svg, "<svg "))
+	assert.Contains(t, svg, `width="300"`)
+	assert.Contains(t, svg, `<path fill="#000" d="M`)
+	assert.Equal(t, "image/svg+xml", opts.ContentType())
+
+	// Более высокий уровень коррекции ошибок дает более плотную матрицу.
+	low, err := qr.Encode("https://short.com/abc123", qr.Options{Format: qr.FormatSVG, Size: 300, Level: "L"})
+	require.NoError(t, err)
+	assert.NotEqual(t, svg, string(low))
+}
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts qr.Options
+		err  error
+	}{
+		{name: "default", opts: qr.DefaultOptions()},
+		{name: "unknown format", opts: qr.Options{Format: "gif", Size: 256, Level: "M"}, err: qr.ErrInvalidFormat},
+		{name: "too small", opts: qr.Options{Format: qr.FormatPNG, Size: 10, Level: "M"}, err: qr.ErrInvalidSize},
+		{name: "too large", opts: qr.Options{Format: qr.FormatPNG, Size: 100000, Level: "M"}, err: qr.ErrInvalidSize},
+		{name: "unknown level", opts: qr.Options{Format: qr.FormatPNG, Size: 256, Level: "X"}, err: qr.ErrInvalidLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Package ratelimit содержит реализацию хранилища корзин токенов в памяти процесса.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// bucket представляет корзину токенов.
+type bucket struct {
+	// tokens представляет количество токенов в корзине на момент updatedAt.
+	tokens float64
+	// updatedAt представляет момент последнего пересчета количества токенов.
+	updatedAt time.Time
+	// fullAt представляет момент, когда корзина заполнится полностью.
+	fullAt time.Time
+}
+
+// InMemoryStore представляет хранилище корзин токенов в памяти процесса.
+// Полные корзины эквивалентны отсутствующим, поэтому периодически удаляются, чтобы память не росла с числом клиентов.
+type InMemoryStore struct {
+	// mu представляет mutex для синхронизации доступа к корзинам.
+	mu sync.Mutex
+	// buckets представляет карту корзин по ключу.
+	buckets map[string]*bucket
+	// sweepInterval представляет интервал удаления полных корзин.
+	sweepInterval time.Duration
+	// sweptAt представляет момент последнего удаления полных корзин.
+	sweptAt time.Time
+	// now возвращает текущее время.
+	now func() time.Time
+}
+
+// NewInMemoryStore возвращает новый экземпляр InMemoryStore.
+// Эта функция принимает интервал удаления полных корзин.
+func NewInMemoryStore(sweepInterval time.Duration) *InMemoryStore {
+	return &InMemoryStore{
+		buckets:       make(map[string]*bucket),
+		sweepInterval: sweepInterval,
+		sweptAt:       time.Now(),
+		now:           time.Now,
+	}
+}
+
+// Take пытается взять cost токенов из каждой корзины с ключами keys.
+// Токены берутся, только если их достаточно во всех корзинах; иначе ни одна корзина не изменяется.
+func (s *InMemoryStore) Take(ctx context.Context, keys []string, limit Limit, cost int) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	buckets := make([]*bucket, 0, len(keys))
+	allowed := true
+	for _, key := range keys {
+		b, ok := s.buckets[key]
+		if !ok {
+			b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+			s.buckets[key] = b
+		}
+
+		elapsed := now.Sub(b.updatedAt).Seconds()
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.updatedAt = now
+		allowed = allowed && b.tokens >= float64(cost)
+		buckets = append(buckets, b)
+	}
+
+	result := Result{Allowed: allowed, Remaining: limit.Burst}
+	for _, b := range buckets {
+		if allowed {
+			b.tokens -= float64(cost)
+		} else {
+			result.RetryAfter = max(result.RetryAfter, durationFor(float64(cost)-b.tokens, limit.Rate))
+		}
+		reset := durationFor(float64(limit.Burst)-b.tokens, limit.Rate)
+		b.fullAt = now.Add(reset)
+
+		result.Remaining = min(result.Remaining, int(b.tokens))
+		result.Reset = max(result.Reset, reset)
+	}
+
+	return result, nil
+}
+
+// sweep удаляет корзины, которые к текущему моменту заполнились полностью.
+func (s *InMemoryStore) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < s.sweepInterval {
+		return
+	}
+	s.sweptAt = now
+
+	for key, b := range s.buckets {
+		if !now.Before(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// durationFor возвращает время накопления заданного количества токенов при заданной скорости пополнения.
+func durationFor(tokens float64, rate float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / rate * float64(time.Second))
+}
@@ -0,0 +1,46 @@
+// Package ratelimit содержит интерфейс и реализации хранилища ограничителей частоты запросов по алгоритму token bucket.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit представляет параметры ограничителя частоты запросов.
+type Limit struct {
+	// Rate представляет скорость пополнения корзины в токенах в секунду.
+	Rate float64
+	// Burst представляет емкость корзины, то есть максимальное количество токенов, доступных сразу.
+	Burst int
+}
+
+// PerMinute возвращает ограничение с заданным количеством токенов в минуту и емкостью корзины.
+func PerMinute(count int, burst int) Limit {
+	return Limit{Rate: float64(count) / 60, Burst: burst}
+}
+
+// Enabled возвращает true, если ограничение задано; нулевая или отрицательная скорость отключает ограничение.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result представляет результат попытки взять токены из корзины.
+type Result struct {
+	// Allowed представляет признак того, что токены были выданы.
+	Allowed bool
+	// Remaining представляет количество целых токенов, оставшихся в корзине.
+	Remaining int
+	// RetryAfter представляет время, через которое в корзине накопится запрошенное количество токенов.
+	RetryAfter time.Duration
+	// Reset представляет время, через которое корзина заполнится полностью.
+	Reset time.Duration
+}
+
+// Store представляет интерфейс хранилища корзин токенов.
+// Реализация для общего хранилища позволяет нескольким экземплярам сервиса использовать одни и те же корзины.
+type Store interface {
+	// Take пытается взять cost токенов из каждой корзины с ключами keys.
+	// Если хотя бы в одной корзине токенов недостаточно, ни одна корзина не изменяется, а в результате возвращается
+	// время ожидания; остаток и время заполнения в результате соответствуют самой ограничивающей корзине.
+	Take(ctx context.Context, keys []string, limit Limit, cost int) (Result, error)
+}
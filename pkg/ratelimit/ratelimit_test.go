@@ -0,0 +1,91 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oegegr/shortener/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryStore_Take(t *testing.T) {
+	ctx := context.Background()
+	store := ratelimit.NewInMemoryStore(time.Minute)
+	limit := ratelimit.Limit{Rate: 20, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		res, err := store.Take(ctx, []string{"client"}, limit, 1)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 1-i, res.Remaining)
+	}
+
+	res, err := store.Take(ctx, []string{"client"}, limit, 1)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Positive(t, res.RetryAfter)
+	assert.LessOrEqual(t, res.RetryAfter, 50*time.Millisecond)
+	assert.GreaterOrEqual(t, res.Reset, res.RetryAfter)
+
+	// Корзины разных клиентов независимы.
+	res, err = store.Take(ctx, []string{"other"}, limit, 1)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	time.Sleep(res.Reset + 60*time.Millisecond)
+	res, err = store.Take(ctx, []string{"client"}, limit, 1)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+}
+
+func TestInMemoryStore_TakeCost(t *testing.T) {
+	ctx := context.Background()
+	store := ratelimit.NewInMemoryStore(time.Minute)
+	limit := ratelimit.PerMinute(60, 10)
+
+	res, err := store.Take(ctx, []string{"client"}, limit, 8)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 2, res.Remaining)
+
+	// Отказ не расходует токены.
+	res, err = store.Take(ctx, []string{"client"}, limit, 5)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.InDelta(t, 3*time.Second, res.RetryAfter, float64(100*time.Millisecond))
+
+	res, err = store.Take(ctx, []string{"client"}, limit, 2)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+}
+
+func TestInMemoryStore_TakeKeys(t *testing.T) {
+	ctx := context.Background()
+	store := ratelimit.NewInMemoryStore(time.Minute)
+	limit := ratelimit.PerMinute(60, 3)
+
+	res, err := store.Take(ctx, []string{"user"}, limit, 3)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	// Отказ одной корзины не расходует токены других.
+	res, err = store.Take(ctx, []string{"ip", "user"}, limit, 1)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	res, err = store.Take(ctx, []string{"ip"}, limit, 3)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+}
+
+func TestLimit_Enabled(t *testing.T) {
+	assert.True(t, ratelimit.PerMinute(60, 10).Enabled())
+	assert.False(t, ratelimit.PerMinute(0, 10).Enabled())
+	assert.False(t, ratelimit.PerMinute(-1, 10).Enabled())
+	assert.False(t, ratelimit.PerMinute(60, 0).Enabled())
+}